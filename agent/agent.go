@@ -24,6 +24,11 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// Units of the running agent modified on partial reloads
+	unitsLock sync.Mutex
+	inputs    *inputUnit
+	outputs   *outputUnit
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -34,7 +39,8 @@ func NewAgent(cfg *config.Config) *Agent {
 	return a
 }
 
-// inputUnit is a group of input plugins and the shared channel they write to.
+// inputUnit is a group of input plugins and the shared channels of the
+// pipelines they write to.
 //
 // ┌───────┐
 // │ Input │───┐
@@ -46,8 +52,14 @@ func NewAgent(cfg *config.Config) *Agent {
 // │ Input │───┘
 // └───────┘
 type inputUnit struct {
-	dst    chan<- telegraf.Metric
+	dst    map[string]chan<- telegraf.Metric
 	inputs []*models.RunningInput
+
+	// Gather loops of the running inputs allowing to control single inputs
+	sync.Mutex
+	ctx   context.Context
+	wg    sync.WaitGroup
+	loops map[*models.RunningInput]*pluginLoop
 }
 
 //  ______     ┌───────────┐     ______
@@ -83,8 +95,9 @@ type aggregatorUnit struct {
 	aggregators []*models.RunningAggregator
 }
 
-// outputUnit is a group of Outputs and their source channel.  Metrics on the
-// channel are written to all outputs.
+// outputUnit is a group of Outputs and the source channels of the pipelines.
+// Metrics on the channel of a pipeline are written to all outputs of the
+// pipeline.
 
 //                            ┌────────┐
 //                       ┌──▶ │ Output │
@@ -97,8 +110,24 @@ type aggregatorUnit struct {
 //                            └────────┘
 
 type outputUnit struct {
-	src     <-chan telegraf.Metric
+	src     map[string]<-chan telegraf.Metric
 	outputs []*models.RunningOutput
+	routes  map[string][]*models.RunningOutput
+
+	// Flush loops of the running outputs allowing to control single outputs
+	sync.RWMutex
+	ctx   context.Context
+	wg    sync.WaitGroup
+	loops map[*models.RunningOutput]*pluginLoop
+}
+
+// pluginLoop is the gather loop of an input or the flush loop of an output.
+type pluginLoop struct {
+	// Stop the loop and wait for it to finish
+	stop func()
+
+	// Request an immediate gather or flush
+	trigger chan struct{}
 }
 
 // Run starts and runs the Agent until the context is done.
@@ -138,31 +167,14 @@ func (a *Agent) Run(ctx context.Context) error {
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	next, ou, err := a.startOutputs(ctx, a.Config.Outputs, a.Config.Pipelines())
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		aggC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			aggC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au = a.startAggregators(aggC, next, a.Config.Aggregators)
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	next, pipelines, err := a.startPipelines(next)
+	if err != nil {
+		return err
 	}
 
 	iu, err := a.startInputs(next, a.Config.Inputs)
@@ -170,6 +182,22 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	// Checkpoint the plugin states while running, the persister is stopped
+	// after all plugins to store the final states on shutdown
+	var persisterWg sync.WaitGroup
+	persisterCtx, cancelPersister := context.WithCancel(context.Background())
+	if a.Config.Persister != nil {
+		persisterWg.Add(1)
+		go func() {
+			defer persisterWg.Done()
+			a.runPersister(persisterCtx)
+		}()
+	}
+
+	a.unitsLock.Lock()
+	a.inputs, a.outputs = iu, ou
+	a.unitsLock.Unlock()
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -177,27 +205,11 @@ func (a *Agent) Run(ctx context.Context) error {
 		a.runOutputs(ou)
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(pu)
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, pipelines)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -207,6 +219,12 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	a.unitsLock.Lock()
+	a.inputs, a.outputs = nil, nil
+	a.unitsLock.Unlock()
+
+	cancelPersister()
+	persisterWg.Wait()
 	if a.Config.Persister != nil {
 		log.Printf("D! [agent] Persisting plugin states")
 		if err := a.Config.Persister.Store(); err != nil {
@@ -341,7 +359,33 @@ func (a *Agent) initPersister() error {
 	return nil
 }
 
-func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
+// runPersister stores the plugin states periodically and on request of the
+// plugins until the context is done.
+func (a *Agent) runPersister(ctx context.Context) {
+	p := a.Config.Persister
+
+	var tick <-chan time.Time
+	if p.Interval > 0 {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-p.Requests():
+		}
+
+		if err := p.Store(); err != nil {
+			log.Printf("E! [agent] Checkpointing plugin states failed: %v", err)
+		}
+	}
+}
+
+func (*Agent) startInputs(dst map[string]chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
 	log.Printf("D! [agent] Starting service inputs")
 
 	unit := &inputUnit{
@@ -361,7 +405,14 @@ func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningIn
 			precision = input.Config.Precision
 		}
 
-		acc := NewAccumulator(input, dst)
+		inputC, found := dst[input.Pipeline()]
+		if !found {
+			stopRunningInputs(unit.inputs)
+
+			return nil, fmt.Errorf("starting input %s: unknown pipeline %q", input.LogName(), input.Pipeline())
+		}
+
+		acc := NewAccumulator(input, inputC)
 		acc.SetPrecision(getPrecision(precision, interval))
 
 		if err := input.Start(acc); err != nil {
@@ -397,63 +448,91 @@ func (a *Agent) runInputs(
 	startTime time.Time,
 	unit *inputUnit,
 ) {
-	var wg sync.WaitGroup
-	tickers := make([]Ticker, 0, len(unit.inputs))
+	unit.Lock()
+	unit.ctx = ctx
+	unit.loops = make(map[*models.RunningInput]*pluginLoop, len(unit.inputs))
 	for _, input := range unit.inputs {
-		// Overwrite agent interval if this plugin has its own.
-		interval := time.Duration(a.Config.Agent.Interval)
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
+		a.gatherInput(startTime, unit, input)
+	}
+	unit.Unlock()
 
-		// Overwrite agent precision if this plugin has its own.
-		precision := time.Duration(a.Config.Agent.Precision)
-		if input.Config.Precision != 0 {
-			precision = input.Config.Precision
-		}
+	<-ctx.Done()
 
-		// Overwrite agent collection_jitter if this plugin has its own.
-		jitter := time.Duration(a.Config.Agent.CollectionJitter)
-		if input.Config.CollectionJitter != 0 {
-			jitter = input.Config.CollectionJitter
-		}
+	// No inputs are added once the context is done, so all gather loops are
+	// accounted for after acquiring the lock
+	unit.Lock()
+	unit.Unlock() //nolint:staticcheck // empty critical section used as barrier
+	unit.wg.Wait()
 
-		// Overwrite agent collection_offset if this plugin has its own.
-		offset := time.Duration(a.Config.Agent.CollectionOffset)
-		if input.Config.CollectionOffset != 0 {
-			offset = input.Config.CollectionOffset
-		}
+	log.Printf("D! [agent] Stopping service inputs")
+	stopRunningInputs(unit.inputs)
 
-		var ticker Ticker
-		if a.Config.Agent.RoundInterval {
-			ticker = NewAlignedTicker(startTime, interval, jitter, offset)
-		} else {
-			ticker = NewUnalignedTicker(interval, jitter, offset)
-		}
-		tickers = append(tickers, ticker)
+	for _, dst := range unit.dst {
+		close(dst)
+	}
+	log.Printf("D! [agent] Input channel closed")
+}
 
-		acc := NewAccumulator(input, unit.dst)
-		acc.SetPrecision(getPrecision(precision, interval))
+// gatherInput starts the periodic gather of the given input. The unit must be
+// locked by the caller.
+func (a *Agent) gatherInput(startTime time.Time, unit *inputUnit, input *models.RunningInput) {
+	// Overwrite agent interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.Interval)
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
-			a.gatherLoop(ctx, acc, input, ticker, interval)
-		}(input)
+	// Overwrite agent precision if this plugin has its own.
+	precision := time.Duration(a.Config.Agent.Precision)
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
 	}
-	defer stopTickers(tickers)
-	wg.Wait()
 
-	log.Printf("D! [agent] Stopping service inputs")
-	stopRunningInputs(unit.inputs)
+	// Overwrite agent collection_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.CollectionJitter)
+	if input.Config.CollectionJitter != 0 {
+		jitter = input.Config.CollectionJitter
+	}
 
-	close(unit.dst)
-	log.Printf("D! [agent] Input channel closed")
+	// Overwrite agent collection_offset if this plugin has its own.
+	offset := time.Duration(a.Config.Agent.CollectionOffset)
+	if input.Config.CollectionOffset != 0 {
+		offset = input.Config.CollectionOffset
+	}
+
+	var ticker Ticker
+	if a.Config.Agent.RoundInterval {
+		ticker = NewAlignedTicker(startTime, interval, jitter, offset)
+	} else {
+		ticker = NewUnalignedTicker(interval, jitter, offset)
+	}
+
+	acc := NewAccumulator(input, unit.dst[input.Pipeline()])
+	acc.SetPrecision(getPrecision(precision, interval))
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	trigger := make(chan struct{}, 1)
+	done := make(chan struct{})
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(done)
+		defer ticker.Stop()
+		a.gatherLoop(ctx, acc, input, ticker, interval, trigger)
+	}()
+
+	unit.loops[input] = &pluginLoop{
+		stop: func() {
+			cancel()
+			<-done
+		},
+		trigger: trigger,
+	}
 }
 
 // testStartInputs is a variation of startInputs for use in --test and --once mode.
 // It differs by logging Start errors and returning only plugins successfully started.
-func (*Agent) testStartInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) *inputUnit {
+func (*Agent) testStartInputs(dst map[string]chan<- telegraf.Metric, inputs []*models.RunningInput) *inputUnit {
 	log.Printf("D! [agent] Starting service inputs")
 
 	unit := &inputUnit{
@@ -465,7 +544,13 @@ func (*Agent) testStartInputs(dst chan<- telegraf.Metric, inputs []*models.Runni
 		// This only applies to the accumulator passed to Start(), the
 		// Gather() accumulator does apply rounding according to the
 		// precision agent setting.
-		acc := NewAccumulator(input, dst)
+		inputC, found := dst[input.Pipeline()]
+		if !found {
+			log.Printf("E! [agent] Starting input %s: unknown pipeline %q", input.LogName(), input.Pipeline())
+			continue
+		}
+
+		acc := NewAccumulator(input, inputC)
 		acc.SetPrecision(time.Nanosecond)
 
 		if err := input.Start(acc); err != nil {
@@ -525,7 +610,7 @@ func (a *Agent) testRunInputs(
 				time.Sleep(500 * time.Millisecond)
 			}
 
-			acc := NewAccumulator(input, unit.dst)
+			acc := NewAccumulator(input, unit.dst[input.Pipeline()])
 			acc.SetPrecision(getPrecision(precision, interval))
 
 			if err := input.Input.Gather(acc); err != nil {
@@ -542,7 +627,9 @@ func (a *Agent) testRunInputs(
 	log.Printf("D! [agent] Stopping service inputs")
 	stopRunningInputs(unit.inputs)
 
-	close(unit.dst)
+	for _, dst := range unit.dst {
+		close(dst)
+	}
 	log.Printf("D! [agent] Input channel closed")
 }
 
@@ -560,14 +647,15 @@ func stopRunningOutputs(outputs []*models.RunningOutput) {
 	}
 }
 
-// gather runs an input's gather function periodically until the context is
-// done.
+// gather runs an input's gather function periodically and on request until
+// the context is done.
 func (a *Agent) gatherLoop(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
 	ticker Ticker,
 	interval time.Duration,
+	trigger <-chan struct{},
 ) {
 	for {
 		select {
@@ -576,6 +664,11 @@ func (a *Agent) gatherLoop(
 			if err != nil {
 				acc.AddError(err)
 			}
+		case <-trigger:
+			err := a.gatherOnce(acc, input, ticker, interval)
+			if err != nil {
+				acc.AddError(err)
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -692,7 +785,7 @@ func (a *Agent) runAggregators(
 
 	// Before calling Add, initialize the aggregation window.  This ensures
 	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
 		agg.UpdateWindow(since, until)
 	}
@@ -703,7 +796,7 @@ func (a *Agent) runAggregators(
 		defer wg.Done()
 		for metric := range unit.src {
 			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
+			for _, agg := range unit.aggregators {
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
@@ -718,7 +811,7 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		wg.Add(1)
 		go func(agg *models.RunningAggregator) {
 			defer wg.Done()
@@ -775,14 +868,21 @@ func (*Agent) push(ctx context.Context, aggregator *models.RunningAggregator, ac
 	}
 }
 
-// startOutputs calls Connect on all outputs and returns the source channel.
-// If an error occurs calling Connect, all started plugins have Close called.
+// startOutputs calls Connect on all outputs and returns the source channel of
+// each of the given pipelines. If an error occurs calling Connect, all started
+// plugins have Close called.
 func (a *Agent) startOutputs(
 	ctx context.Context,
 	outputs []*models.RunningOutput,
-) (chan<- telegraf.Metric, *outputUnit, error) {
-	src := make(chan telegraf.Metric, 100)
-	unit := &outputUnit{src: src}
+	pipelines []string,
+) (map[string]chan<- telegraf.Metric, *outputUnit, error) {
+	dst := make(map[string]chan<- telegraf.Metric, len(pipelines))
+	unit := &outputUnit{src: make(map[string]<-chan telegraf.Metric, len(pipelines))}
+	for _, pipeline := range pipelines {
+		src := make(chan telegraf.Metric, 100)
+		unit.src[pipeline] = src
+		dst[pipeline] = src
+	}
 	for _, output := range outputs {
 		if err := a.connectOutput(ctx, output); err != nil {
 			var fatalErr *internal.FatalError
@@ -801,8 +901,20 @@ func (a *Agent) startOutputs(
 
 		unit.outputs = append(unit.outputs, output)
 	}
+	unit.updateRoutes()
 
-	return src, unit, nil
+	return dst, unit, nil
+}
+
+// updateRoutes assigns the outputs to the pipelines they are bound to. The
+// unit must be locked by the caller while running.
+func (unit *outputUnit) updateRoutes() {
+	unit.routes = make(map[string][]*models.RunningOutput, len(unit.src))
+	for _, output := range unit.outputs {
+		for _, pipeline := range output.Pipelines() {
+			unit.routes[pipeline] = append(unit.routes[pipeline], output)
+		}
+	}
 }
 
 // connectOutput connects to all outputs.
@@ -829,62 +941,97 @@ func (*Agent) connectOutput(ctx context.Context, output *models.RunningOutput) e
 func (a *Agent) runOutputs(
 	unit *outputUnit,
 ) {
-	var wg sync.WaitGroup
-
-	// Start flush loop
-	interval := time.Duration(a.Config.Agent.FlushInterval)
-	jitter := time.Duration(a.Config.Agent.FlushJitter)
-
 	ctx, cancel := context.WithCancel(context.Background())
 
+	unit.Lock()
+	unit.ctx = ctx
+	unit.loops = make(map[*models.RunningOutput]*pluginLoop, len(unit.outputs))
 	for _, output := range unit.outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		jitter := jitter
-		// Overwrite agent flush_jitter if this plugin has its own.
-		if output.Config.FlushJitter != 0 {
-			jitter = output.Config.FlushJitter
-		}
+		a.flushOutput(unit, output)
+	}
+	unit.Unlock()
 
+	var wg sync.WaitGroup
+	for pipeline, src := range unit.src {
 		wg.Add(1)
-		go func(output *models.RunningOutput) {
+		go func() {
 			defer wg.Done()
+			for metric := range src {
+				unit.RLock()
+				outputs := unit.routes[pipeline]
+				for i, output := range outputs {
+					if i == len(outputs)-1 {
+						output.AddMetricNoCopy(metric)
+					} else {
+						output.AddMetric(metric)
+					}
+				}
+				unit.RUnlock()
 
-			ticker := NewRollingTicker(interval, jitter)
-			defer ticker.Stop()
-
-			a.flushLoop(ctx, output, ticker)
-		}(output)
-	}
-
-	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(unit.outputs)-1 {
-				output.AddMetricNoCopy(metric)
-			} else {
-				output.AddMetric(metric)
+				// Metrics of pipelines without outputs are discarded
+				if len(outputs) == 0 {
+					metric.Drop()
+				}
 			}
-		}
+		}()
 	}
+	wg.Wait()
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	unit.Lock()
 	cancel()
-	wg.Wait()
+	unit.Unlock()
+	unit.wg.Wait()
 
 	log.Println("I! [agent] Stopping running outputs")
 	stopRunningOutputs(unit.outputs)
 }
 
-// flushLoop runs an output's flush function periodically until the context is
-// done.
+// flushOutput starts the periodic flush of the given output. The unit must be
+// locked by the caller.
+func (a *Agent) flushOutput(unit *outputUnit, output *models.RunningOutput) {
+	// Overwrite agent flush_interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.FlushInterval)
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	// Overwrite agent flush_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.FlushJitter)
+	if output.Config.FlushJitter != 0 {
+		jitter = output.Config.FlushJitter
+	}
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	trigger := make(chan struct{}, 1)
+	done := make(chan struct{})
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(done)
+
+		ticker := NewRollingTicker(interval, jitter)
+		defer ticker.Stop()
+
+		a.flushLoop(ctx, output, ticker, trigger)
+	}()
+
+	unit.loops[output] = &pluginLoop{
+		stop: func() {
+			cancel()
+			<-done
+		},
+		trigger: trigger,
+	}
+}
+
+// flushLoop runs an output's flush function periodically and on request until
+// the context is done.
 func (a *Agent) flushLoop(
 	ctx context.Context,
 	output *models.RunningOutput,
 	ticker Ticker,
+	trigger <-chan struct{},
 ) {
 	logError := func(err error) {
 		if err != nil {
@@ -901,19 +1048,21 @@ func (a *Agent) flushLoop(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.WriteFinal))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.WriteFinal))
 			return
 		case <-ticker.Elapsed():
 			logError(a.flushOnce(output, ticker, output.Write))
 		case <-flushRequested:
 			logError(a.flushOnce(output, ticker, output.Write))
+		case <-trigger:
+			logError(a.flushOnce(output, ticker, output.Write))
 		case <-output.BatchReady:
 			logError(a.flushBatch(output, output.WriteBatch))
 		}
@@ -999,56 +1148,19 @@ func (a *Agent) runTest(ctx context.Context, wait time.Duration, outputC chan<-
 
 	startTime := time.Now()
 
-	next := outputC
-
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			var err error
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au = a.startAggregators(procC, next, a.Config.Aggregators)
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		var err error
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	next, pipelines, err := a.startPipelines(mergePipelines(outputC, a.Config.Pipelines()))
+	if err != nil {
+		return err
 	}
 
 	iu := a.testStartInputs(next, a.Config.Inputs)
 
 	var wg sync.WaitGroup
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(pu)
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, pipelines)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -1105,31 +1217,14 @@ func (a *Agent) runOnce(ctx context.Context, wait time.Duration) error {
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	next, ou, err := a.startOutputs(ctx, a.Config.Outputs, a.Config.Pipelines())
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au = a.startAggregators(procC, next, a.Config.Aggregators)
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	next, pipelines, err := a.startPipelines(next)
+	if err != nil {
+		return err
 	}
 
 	iu := a.testStartInputs(next, a.Config.Inputs)
@@ -1141,27 +1236,11 @@ func (a *Agent) runOnce(ctx context.Context, wait time.Duration) error {
 		a.runOutputs(ou)
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(pu)
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, pipelines)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -1207,9 +1286,3 @@ func panicRecover(input *models.RunningInput) {
 			"https://github.com/influxdata/telegraf/issues/new/choose")
 	}
 }
-
-func stopTickers(tickers []Ticker) {
-	for _, ticker := range tickers {
-		ticker.Stop()
-	}
-}
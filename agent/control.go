@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+var (
+	// ErrNotRunning is returned when controlling an agent that is not running
+	ErrNotRunning = errors.New("agent is not running")
+	// ErrPluginNotFound is returned when no plugin matches the requested ID
+	ErrPluginNotFound = errors.New("plugin not found")
+)
+
+// PluginInfo describes a plugin of the running agent.
+type PluginInfo struct {
+	Category string
+	Name     string
+	Alias    string
+	ID       string
+	LogLevel string
+}
+
+// Plugins returns the inputs, processors, aggregators and outputs of the
+// running agent.
+func (a *Agent) Plugins() []PluginInfo {
+	a.unitsLock.Lock()
+	defer a.unitsLock.Unlock()
+
+	c := a.Config
+	plugins := make([]PluginInfo, 0, len(c.Inputs)+len(c.Processors)+len(c.Aggregators)+len(c.Outputs))
+	for _, input := range c.Inputs {
+		plugins = append(plugins, newPluginInfo("inputs", input.Config.Name, input.Config.Alias, input.ID(), input.Log()))
+	}
+	for _, processor := range c.Processors {
+		plugins = append(plugins, newPluginInfo("processors", processor.Config.Name, processor.Config.Alias, processor.ID(), processor.Log()))
+	}
+	for _, aggregator := range c.Aggregators {
+		plugins = append(plugins, newPluginInfo("aggregators", aggregator.Config.Name, aggregator.Config.Alias, aggregator.ID(), aggregator.Log()))
+	}
+	for _, output := range c.Outputs {
+		plugins = append(plugins, newPluginInfo("outputs", output.Config.Name, output.Config.Alias, output.ID(), output.Log()))
+	}
+	return plugins
+}
+
+// GatherInput triggers an immediate gather of the running inputs with the
+// given ID.
+func (a *Agent) GatherInput(id string) error {
+	a.unitsLock.Lock()
+	defer a.unitsLock.Unlock()
+
+	unit := a.inputs
+	if unit == nil {
+		return ErrNotRunning
+	}
+
+	unit.Lock()
+	defer unit.Unlock()
+
+	var found bool
+	for input, loop := range unit.loops {
+		if input.ID() == id {
+			trigger(loop)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no running input with ID %q: %w", id, ErrPluginNotFound)
+	}
+	return nil
+}
+
+// FlushOutput triggers an immediate flush of the running outputs with the
+// given ID.
+func (a *Agent) FlushOutput(id string) error {
+	a.unitsLock.Lock()
+	defer a.unitsLock.Unlock()
+
+	unit := a.outputs
+	if unit == nil {
+		return ErrNotRunning
+	}
+
+	unit.RLock()
+	defer unit.RUnlock()
+
+	var found bool
+	for output, loop := range unit.loops {
+		if output.ID() == id {
+			trigger(loop)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no running output with ID %q: %w", id, ErrPluginNotFound)
+	}
+	return nil
+}
+
+// SetLogLevel changes the log-level of all plugins with the given ID.
+func (a *Agent) SetLogLevel(id, level string) error {
+	if telegraf.LogLevelFromString(level) == telegraf.None {
+		return fmt.Errorf("invalid log-level %q", level)
+	}
+
+	a.unitsLock.Lock()
+	defer a.unitsLock.Unlock()
+
+	c := a.Config
+	loggers := make([]telegraf.Logger, 0, 1)
+	for _, input := range c.Inputs {
+		if input.ID() == id {
+			loggers = append(loggers, input.Log())
+		}
+	}
+	for _, processor := range slices.Concat(c.Processors, c.AggProcessors) {
+		if processor.ID() == id {
+			loggers = append(loggers, processor.Log())
+		}
+	}
+	for _, aggregator := range c.Aggregators {
+		if aggregator.ID() == id {
+			loggers = append(loggers, aggregator.Log())
+		}
+	}
+	for _, output := range c.Outputs {
+		if output.ID() == id {
+			loggers = append(loggers, output.Log())
+		}
+	}
+	if len(loggers) == 0 {
+		return fmt.Errorf("no plugin with ID %q: %w", id, ErrPluginNotFound)
+	}
+
+	for _, logger := range loggers {
+		l, ok := logger.(interface{ SetLogLevel(string) error })
+		if !ok {
+			return fmt.Errorf("changing the log-level of plugin %q not supported", id)
+		}
+		if err := l.SetLogLevel(level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPluginInfo(category, name, alias, id string, logger telegraf.Logger) PluginInfo {
+	return PluginInfo{
+		Category: category,
+		Name:     name,
+		Alias:    alias,
+		ID:       id,
+		LogLevel: strings.ToLower(logger.Level().String()),
+	}
+}
+
+// trigger requests an immediate run of the loop, multiple pending requests
+// are merged into one
+func trigger(loop *pluginLoop) {
+	select {
+	case loop.trigger <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+func TestControl(t *testing.T) {
+	output := &reloadOutput{}
+	input := &reloadInput{name: "test"}
+
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(time.Hour)
+	c.Agent.FlushInterval = config.Duration(time.Hour)
+	c.Agent.RoundInterval = false
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(input, &models.InputConfig{Name: "test", ID: "input-id"}),
+	}
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput(output, &models.OutputConfig{Name: "test", Alias: "mine", ID: "output-id"}, 0, 0),
+	}
+
+	a := NewAgent(c)
+	require.ErrorIs(t, a.GatherInput("input-id"), ErrNotRunning)
+	require.ErrorIs(t, a.FlushOutput("output-id"), ErrNotRunning)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	// Wait for the first gather of the input
+	require.Eventually(t, func() bool {
+		return a.GatherInput("input-id") == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The metrics only arrive at the output on an explicit flush
+	require.Zero(t, output.received("test"))
+	require.NoError(t, a.FlushOutput("output-id"))
+	require.Eventually(t, func() bool {
+		return output.received("test") > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.ErrorIs(t, a.GatherInput("unknown"), ErrPluginNotFound)
+	require.ErrorIs(t, a.FlushOutput("input-id"), ErrPluginNotFound)
+
+	// Change the log-level of a single plugin
+	level := c.Inputs[0].Log().Level()
+	require.NoError(t, a.SetLogLevel("output-id", "trace"))
+	require.Equal(t, telegraf.Trace, c.Outputs[0].Log().Level())
+	require.Equal(t, level, c.Inputs[0].Log().Level())
+	require.ErrorContains(t, a.SetLogLevel("output-id", "verbose"), "invalid log-level")
+	require.ErrorIs(t, a.SetLogLevel("unknown", "debug"), ErrPluginNotFound)
+
+	expected := []PluginInfo{
+		{Category: "inputs", Name: "test", ID: "input-id", LogLevel: strings.ToLower(level.String())},
+		{Category: "outputs", Name: "test", Alias: "mine", ID: "output-id", LogLevel: "trace"},
+	}
+	require.Equal(t, expected, a.Plugins())
+}
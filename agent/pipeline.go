@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// pipelineUnit is the processor and aggregator chain of a single pipeline.
+// Inputs write to the head of the chain of their pipeline while the end of the
+// chain writes to the outputs bound to the pipeline.
+//
+//	 ______     ┌────────────┐     ┌─────────────┐     ┌────────────────┐     ______
+//	()_____)──▶ │ Processors │──▶  │ Aggregators │──▶  │ AggProcessors  │──▶ ()_____)
+//	            └────────────┘     └─────────────┘     └────────────────┘
+type pipelineUnit struct {
+	name          string
+	processors    []*processorUnit
+	aggProcessors []*processorUnit
+	aggregators   *aggregatorUnit
+}
+
+// startPipelines sets up the processor and aggregator chain of each pipeline
+// writing to the given output channel of the pipeline. It returns the channels
+// the inputs of each pipeline write to.
+func (a *Agent) startPipelines(outputC map[string]chan<- telegraf.Metric) (map[string]chan<- telegraf.Metric, []*pipelineUnit, error) {
+	inputC := make(map[string]chan<- telegraf.Metric, len(outputC))
+	units := make([]*pipelineUnit, 0, len(outputC))
+	for name, next := range outputC {
+		unit := &pipelineUnit{name: name}
+
+		aggregators := make([]*models.RunningAggregator, 0, len(a.Config.Aggregators))
+		for _, aggregator := range a.Config.Aggregators {
+			if aggregator.Pipeline() == name {
+				aggregators = append(aggregators, aggregator)
+			}
+		}
+
+		if len(aggregators) != 0 {
+			aggC := next
+			aggProcessors := pipelineProcessors(a.Config.AggProcessors, name)
+			if len(aggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
+				var err error
+				aggC, unit.aggProcessors, err = a.startProcessors(next, aggProcessors)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			next, unit.aggregators = a.startAggregators(aggC, next, aggregators)
+		}
+
+		if processors := pipelineProcessors(a.Config.Processors, name); len(processors) != 0 {
+			var err error
+			next, unit.processors, err = a.startProcessors(next, processors)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		inputC[name] = next
+		units = append(units, unit)
+	}
+
+	return inputC, units, nil
+}
+
+// runPipelines runs the chains of all pipelines until all input channels are
+// closed and all metrics have been processed.
+func (a *Agent) runPipelines(startTime time.Time, units []*pipelineUnit) {
+	var wg sync.WaitGroup
+	for _, unit := range units {
+		if unit.aggregators != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.runProcessors(unit.aggProcessors)
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.runAggregators(startTime, unit.aggregators)
+			}()
+		}
+
+		if unit.processors != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.runProcessors(unit.processors)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// pipelineProcessors returns the processors of the given pipeline keeping the
+// processor order.
+func pipelineProcessors(processors models.RunningProcessors, name string) models.RunningProcessors {
+	selected := make(models.RunningProcessors, 0, len(processors))
+	for _, processor := range processors {
+		if processor.Pipeline() == name {
+			selected = append(selected, processor)
+		}
+	}
+	return selected
+}
+
+// mergePipelines returns a channel for each of the given pipelines all
+// forwarding to the given destination. The destination is closed once the
+// channels of all pipelines are closed.
+func mergePipelines(dst chan<- telegraf.Metric, pipelines []string) map[string]chan<- telegraf.Metric {
+	channels := make(map[string]chan<- telegraf.Metric, len(pipelines))
+	if len(pipelines) == 1 {
+		channels[pipelines[0]] = dst
+		return channels
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range pipelines {
+		src := make(chan telegraf.Metric, 100)
+		channels[name] = src
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range src {
+				dst <- m
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(dst)
+	}()
+
+	return channels
+}
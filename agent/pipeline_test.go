@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+func TestPipelines(t *testing.T) {
+	defaultOutput := &reloadOutput{}
+	sensorsOutput := &reloadOutput{}
+	allOutput := &reloadOutput{}
+
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(time.Hour)
+	c.Agent.FlushInterval = config.Duration(time.Hour)
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&reloadInput{name: "system"}, &models.InputConfig{Name: "system"}),
+		models.NewRunningInput(&reloadInput{name: "sensor"}, &models.InputConfig{Name: "sensor", Pipeline: "sensors"}),
+	}
+	c.Processors = models.RunningProcessors{
+		models.NewRunningProcessor(processors.NewStreamingProcessorFromProcessor(&suffixProcessor{}), &models.ProcessorConfig{Name: "suffix", Pipeline: "sensors"}),
+	}
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput(defaultOutput, &models.OutputConfig{Name: "default"}, 0, 0),
+		models.NewRunningOutput(sensorsOutput, &models.OutputConfig{Name: "sensors", Pipelines: []string{"sensors"}}, 0, 0),
+		models.NewRunningOutput(allOutput, &models.OutputConfig{Name: "all", Pipelines: []string{"default", "sensors"}}, 0, 0),
+	}
+
+	a := NewAgent(c)
+	require.NoError(t, a.Once(t.Context(), 0))
+
+	// Metrics only pass the processors of their pipeline and are only written
+	// to the outputs of the pipeline
+	require.Equal(t, 1, defaultOutput.received("system"))
+	require.Zero(t, defaultOutput.received("sensor_processed"))
+	require.Zero(t, sensorsOutput.received("system"))
+	require.Equal(t, 1, sensorsOutput.received("sensor_processed"))
+	require.Equal(t, 1, allOutput.received("system"))
+	require.Equal(t, 1, allOutput.received("sensor_processed"))
+	require.Zero(t, allOutput.received("sensor"))
+}
+
+type suffixProcessor struct{}
+
+func (*suffixProcessor) SampleConfig() string {
+	return ""
+}
+
+func (*suffixProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.SetName(m.Name() + "_processed")
+	}
+	return in
+}
@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/snmp"
+	"github.com/influxdata/telegraf/models"
+)
+
+// Reload applies the given configuration to the running agent by stopping the
+// inputs and outputs removed from the configuration and starting the added
+// ones. Plugins reused from the running configuration, see
+// config.Config.ReusePlugins, keep running without interruption. Processors,
+// aggregators and agent settings are not modified, so the configuration must
+// not require a restart, see config.Config.RestartReason.
+//
+// On error, all added plugins not handed over to the running agent are
+// released. If an error is returned after stopping the first plugin the agent
+// is in an undefined state and must be restarted.
+func (a *Agent) Reload(cfg *config.Config) error {
+	a.unitsLock.Lock()
+	defer a.unitsLock.Unlock()
+
+	if a.inputs == nil || a.outputs == nil {
+		return ErrNotRunning
+	}
+
+	addedInputs, removedInputs := diffPlugins(a.Config.Inputs, cfg.Inputs)
+	addedOutputs, removedOutputs := diffPlugins(a.Config.Outputs, cfg.Outputs)
+	log.Printf("I! [agent] Reloading plugins: %d inputs added, %d removed; %d outputs added, %d removed",
+		len(addedInputs), len(removedInputs), len(addedOutputs), len(removedOutputs))
+
+	// Initialize the new plugins first to keep the running ones untouched in
+	// case of errors
+	for _, input := range addedInputs {
+		if tp, ok := input.Input.(snmp.TranslatorPlugin); ok {
+			tp.SetTranslator(a.Config.Agent.SnmpTranslator)
+		}
+		if err := input.Init(); err != nil {
+			a.Config.DiscardPlugins(addedInputs, addedOutputs)
+			return fmt.Errorf("could not initialize input %s: %w", input.LogName(), err)
+		}
+	}
+	for _, output := range addedOutputs {
+		if err := output.Init(); err != nil {
+			a.Config.DiscardPlugins(addedInputs, addedOutputs)
+			return fmt.Errorf("could not initialize output %s: %w", output.LogName(), err)
+		}
+	}
+	if err := a.registerStates(addedInputs, addedOutputs); err != nil {
+		a.Config.DiscardPlugins(addedInputs, addedOutputs)
+		return err
+	}
+
+	// Stop the removed plugins before starting the new ones as replaced
+	// plugins might use the same resources such as listening ports
+	for _, input := range removedInputs {
+		log.Printf("D! [agent] Stopping input %s", input.LogName())
+		a.inputs.remove(input)
+	}
+	for _, output := range removedOutputs {
+		log.Printf("D! [agent] Stopping output %s", output.LogName())
+		a.outputs.remove(output)
+	}
+	a.unregisterStates(removedInputs, removedOutputs)
+
+	if err := a.addOutputs(addedOutputs); err != nil {
+		a.Config.DiscardPlugins(addedInputs, addedOutputs)
+		return err
+	}
+	if err := a.addInputs(addedInputs); err != nil {
+		// The added outputs are already running and stopped with the agent
+		a.Config.DiscardPlugins(addedInputs, nil)
+		return err
+	}
+
+	a.Config.Inputs = cfg.Inputs
+	a.Config.Outputs = cfg.Outputs
+
+	return nil
+}
+
+// addOutputs connects the given outputs and adds them to the running outputs.
+func (a *Agent) addOutputs(outputs []*models.RunningOutput) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	// Connect outside of the lock to not block writing to the running outputs
+	_, started, err := a.startOutputs(context.Background(), outputs, nil)
+	if err != nil {
+		return err
+	}
+
+	unit := a.outputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	if unit.ctx == nil || unit.ctx.Err() != nil {
+		stopRunningOutputs(started.outputs)
+		return ErrNotRunning
+	}
+	for _, output := range started.outputs {
+		unit.outputs = append(unit.outputs, output)
+		a.flushOutput(unit, output)
+	}
+	unit.updateRoutes()
+
+	return nil
+}
+
+// addInputs starts the given inputs and adds them to the running inputs. The
+// unit stays locked while starting to not close the input channel while new
+// service inputs might already send metrics.
+func (a *Agent) addInputs(inputs []*models.RunningInput) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	unit := a.inputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	if unit.ctx == nil || unit.ctx.Err() != nil {
+		return ErrNotRunning
+	}
+
+	started, err := a.startInputs(unit.dst, inputs)
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	for _, input := range started.inputs {
+		unit.inputs = append(unit.inputs, input)
+		a.gatherInput(startTime, unit, input)
+	}
+
+	return nil
+}
+
+// registerStates registers the stateful inputs and outputs with the persister
+// and restores their states.
+func (a *Agent) registerStates(inputs []*models.RunningInput, outputs []*models.RunningOutput) error {
+	if a.Config.Persister == nil {
+		return nil
+	}
+
+	registered := make([]string, 0, len(inputs)+len(outputs))
+	register := func(id, name string, plugin interface{}) error {
+		p, ok := plugin.(telegraf.StatefulPlugin)
+		if !ok {
+			return nil
+		}
+		if err := a.Config.Persister.Register(id, p); err != nil {
+			for _, id := range registered {
+				a.Config.Persister.Unregister(id)
+			}
+			return fmt.Errorf("could not register %s: %w", name, err)
+		}
+		registered = append(registered, id)
+		return nil
+	}
+
+	for _, input := range inputs {
+		if err := register(input.ID(), "input "+input.LogName(), input.Input); err != nil {
+			return err
+		}
+	}
+	for _, output := range outputs {
+		if err := register(output.ID(), "output "+output.LogName(), output.Output); err != nil {
+			return err
+		}
+	}
+
+	// Restore the states of the new plugins before starting them
+	if err := a.Config.Persister.LoadPlugins(registered); err != nil && !errors.Is(err, os.ErrNotExist) {
+		for _, id := range registered {
+			a.Config.Persister.Unregister(id)
+		}
+		return fmt.Errorf("could not restore plugin states: %w", err)
+	}
+
+	return nil
+}
+
+// unregisterStates removes the stateful inputs and outputs from the persister.
+func (a *Agent) unregisterStates(inputs []*models.RunningInput, outputs []*models.RunningOutput) {
+	if a.Config.Persister == nil {
+		return
+	}
+
+	for _, input := range inputs {
+		if _, ok := input.Input.(telegraf.StatefulPlugin); ok {
+			a.Config.Persister.Unregister(input.ID())
+		}
+	}
+	for _, output := range outputs {
+		if _, ok := output.Output.(telegraf.StatefulPlugin); ok {
+			a.Config.Persister.Unregister(output.ID())
+		}
+	}
+}
+
+// remove stops the gather loop of the given input and stops the input. Inputs
+// are not removed once the agent is shutting down as all inputs are stopped
+// anyway.
+func (unit *inputUnit) remove(input *models.RunningInput) {
+	unit.Lock()
+	loop, found := unit.loops[input]
+	if !found || unit.ctx.Err() != nil {
+		unit.Unlock()
+		return
+	}
+	delete(unit.loops, input)
+	unit.inputs = slices.DeleteFunc(unit.inputs, func(i *models.RunningInput) bool { return i == input })
+
+	// Delay closing the input channel until the input is stopped
+	unit.wg.Add(1)
+	unit.Unlock()
+	defer unit.wg.Done()
+
+	loop.stop()
+	input.Stop()
+}
+
+// remove stops the given output after flushing its buffer one last time.
+// Outputs are not removed once the agent is shutting down as all outputs are
+// flushed and closed anyway.
+func (unit *outputUnit) remove(output *models.RunningOutput) {
+	unit.Lock()
+	loop, found := unit.loops[output]
+	if !found || unit.ctx.Err() != nil {
+		unit.Unlock()
+		return
+	}
+	delete(unit.loops, output)
+	unit.outputs = slices.DeleteFunc(unit.outputs, func(o *models.RunningOutput) bool { return o == output })
+	unit.updateRoutes()
+
+	unit.wg.Add(1)
+	unit.Unlock()
+	defer unit.wg.Done()
+
+	loop.stop()
+	output.Close()
+}
+
+// diffPlugins returns the plugins only contained in the new and the previous
+// list respectively. Plugins reused by the new configuration are the same
+// instances as in the previous one.
+func diffPlugins[T comparable](previous, current []T) (added, removed []T) {
+	for _, p := range current {
+		if !slices.Contains(previous, p) {
+			added = append(added, p)
+		}
+	}
+	for _, p := range previous {
+		if !slices.Contains(current, p) {
+			removed = append(removed, p)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/persister"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+func TestReload(t *testing.T) {
+	keptOutput := &reloadOutput{}
+	removedOutput := &reloadOutput{}
+	keptInput := &reloadInput{name: "kept"}
+	removedInput := &reloadInput{name: "removed"}
+
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(10 * time.Millisecond)
+	c.Agent.FlushInterval = config.Duration(10 * time.Millisecond)
+	c.Agent.RoundInterval = false
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(keptInput, &models.InputConfig{Name: "kept", ID: "kept"}),
+		models.NewRunningInput(removedInput, &models.InputConfig{Name: "removed", ID: "removed"}),
+	}
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput(keptOutput, &models.OutputConfig{Name: "kept", ID: "kept"}, 0, 0),
+		models.NewRunningOutput(removedOutput, &models.OutputConfig{Name: "removed", ID: "removed"}, 0, 0),
+	}
+
+	a := NewAgent(c)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+
+	// Wait for the agent to run all plugins
+	require.Eventually(t, func() bool {
+		return keptOutput.received("removed") > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Replace the second input and output by new instances
+	addedOutput := &reloadOutput{}
+	addedInput := &reloadInput{name: "added"}
+	cfg := config.NewConfig()
+	cfg.Inputs = []*models.RunningInput{
+		c.Inputs[0],
+		models.NewRunningInput(addedInput, &models.InputConfig{Name: "added", ID: "added"}),
+	}
+	cfg.Outputs = []*models.RunningOutput{
+		c.Outputs[0],
+		models.NewRunningOutput(addedOutput, &models.OutputConfig{Name: "added", ID: "added"}, 0, 0),
+	}
+	require.NoError(t, a.Reload(cfg))
+
+	require.True(t, removedInput.isStopped())
+	require.True(t, removedOutput.isClosed())
+	require.False(t, keptInput.isStopped())
+	require.False(t, keptOutput.isClosed())
+	require.Same(t, cfg.Inputs[1], a.Config.Inputs[1])
+	require.Same(t, cfg.Outputs[1], a.Config.Outputs[1])
+
+	// Unchanged outputs receive the metrics of the added input and vice versa
+	require.Eventually(t, func() bool {
+		return keptOutput.received("added") > 0 && addedOutput.received("kept") > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.True(t, keptInput.isStopped())
+	require.True(t, addedInput.isStopped())
+	require.True(t, keptOutput.isClosed())
+	require.True(t, addedOutput.isClosed())
+
+	require.ErrorIs(t, a.Reload(cfg), ErrNotRunning)
+}
+
+func TestReloadRestoresState(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "states.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"kept":"InN0b3JlZCI=","added":"InN0b3JlZCI="}`), 0o600))
+
+	keptInput := &statefulReloadInput{reloadInput: reloadInput{name: "kept"}}
+	output := &reloadOutput{}
+
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(10 * time.Millisecond)
+	c.Agent.FlushInterval = config.Duration(10 * time.Millisecond)
+	c.Agent.RoundInterval = false
+	c.Persister = &persister.Persister{Filename: filename}
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(keptInput, &models.InputConfig{Name: "kept", ID: "kept"}),
+	}
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput(output, &models.OutputConfig{Name: "output", ID: "output"}, 0, 0),
+	}
+
+	a := NewAgent(c)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return output.received("kept") > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "stored", keptInput.getState())
+	keptInput.setState("running")
+
+	// The state of the added input is restored before starting it while the
+	// running input keeps its state
+	addedInput := &statefulReloadInput{reloadInput: reloadInput{name: "added"}}
+	cfg := config.NewConfig()
+	cfg.Inputs = []*models.RunningInput{
+		c.Inputs[0],
+		models.NewRunningInput(addedInput, &models.InputConfig{Name: "added", ID: "added"}),
+	}
+	cfg.Outputs = c.Outputs
+	require.NoError(t, a.Reload(cfg))
+	require.Equal(t, "stored", addedInput.stateOnStart)
+	require.Equal(t, "running", keptInput.getState())
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestReloadDiscardsPluginsOnError(t *testing.T) {
+	output := &reloadOutput{}
+
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(10 * time.Millisecond)
+	c.Agent.FlushInterval = config.Duration(10 * time.Millisecond)
+	c.Agent.RoundInterval = false
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&reloadInput{name: "kept"}, &models.InputConfig{Name: "kept", ID: "kept"}),
+	}
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput(output, &models.OutputConfig{Name: "kept", ID: "kept"}, 0, 0),
+	}
+
+	a := NewAgent(c)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return output.received("kept") > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The added plugins are released if the reload fails
+	addedOutput := &failingReloadOutput{}
+	cfg := config.NewConfig()
+	cfg.Inputs = []*models.RunningInput{
+		c.Inputs[0],
+		models.NewRunningInput(&reloadInput{name: "added"}, &models.InputConfig{Name: "added", ID: "added"}),
+	}
+	cfg.Outputs = []*models.RunningOutput{
+		c.Outputs[0],
+		models.NewRunningOutput(addedOutput, &models.OutputConfig{Name: "added", ID: "added"}, 0, 0),
+	}
+	require.ErrorContains(t, a.Reload(cfg), "could not initialize output")
+	require.True(t, addedOutput.isClosed())
+	require.False(t, output.isClosed())
+	for _, m := range selfstat.Metrics() {
+		require.NotEqual(t, "added", m.Tags()["_id"], m.Name())
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+type failingReloadOutput struct {
+	reloadOutput
+}
+
+func (*failingReloadOutput) Init() error {
+	return errors.New("init failed")
+}
+
+type statefulReloadInput struct {
+	reloadInput
+
+	state        string
+	stateOnStart string
+}
+
+func (i *statefulReloadInput) Start(telegraf.Accumulator) error {
+	i.Lock()
+	defer i.Unlock()
+	i.stateOnStart = i.state
+	return nil
+}
+
+func (i *statefulReloadInput) GetState() interface{} {
+	return i.getState()
+}
+
+func (i *statefulReloadInput) SetState(state interface{}) error {
+	i.setState(state.(string))
+	return nil
+}
+
+func (i *statefulReloadInput) getState() string {
+	i.Lock()
+	defer i.Unlock()
+	return i.state
+}
+
+func (i *statefulReloadInput) setState(state string) {
+	i.Lock()
+	defer i.Unlock()
+	i.state = state
+}
+
+type reloadInput struct {
+	name string
+
+	sync.Mutex
+	stopped bool
+}
+
+func (*reloadInput) SampleConfig() string {
+	return ""
+}
+
+func (*reloadInput) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (i *reloadInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields(i.name, map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+func (i *reloadInput) Stop() {
+	i.Lock()
+	defer i.Unlock()
+	i.stopped = true
+}
+
+func (i *reloadInput) isStopped() bool {
+	i.Lock()
+	defer i.Unlock()
+	return i.stopped
+}
+
+type reloadOutput struct {
+	sync.Mutex
+	metrics map[string]int
+	closed  bool
+}
+
+func (*reloadOutput) SampleConfig() string {
+	return ""
+}
+
+func (*reloadOutput) Connect() error {
+	return nil
+}
+
+func (o *reloadOutput) Close() error {
+	o.Lock()
+	defer o.Unlock()
+	o.closed = true
+	return nil
+}
+
+func (o *reloadOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.metrics == nil {
+		o.metrics = make(map[string]int)
+	}
+	for _, m := range metrics {
+		o.metrics[m.Name()]++
+	}
+	return nil
+}
+
+func (o *reloadOutput) received(name string) int {
+	o.Lock()
+	defer o.Unlock()
+	return o.metrics[name]
+}
+
+func (o *reloadOutput) isClosed() bool {
+	o.Lock()
+	defer o.Unlock()
+	return o.closed
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// controlServer provides a local HTTP API for inspecting and controlling the
+// running agent.
+type controlServer struct {
+	agent    *agent.Agent
+	reload   func()
+	server   *http.Server
+	listener net.Listener
+	socket   string
+}
+
+type pluginStatus struct {
+	Category string                      `json:"category"`
+	Name     string                      `json:"name"`
+	Alias    string                      `json:"alias,omitempty"`
+	ID       string                      `json:"id"`
+	LogLevel string                      `json:"log_level"`
+	Stats    map[string]map[string]int64 `json:"stats,omitempty"`
+}
+
+// newControlServer starts serving the control API on the given address. The
+// address is either a TCP "host:port" or a Unix socket "unix:///path". As the
+// API is unauthenticated, TCP addresses are restricted to the loopback
+// interface and the Unix socket is only accessible by the owner.
+func newControlServer(address string, ag *agent.Agent, reload func()) (*controlServer, error) {
+	s := &controlServer{
+		agent:  ag,
+		reload: reload,
+	}
+
+	var err error
+	if path, found := strings.CutPrefix(address, "unix://"); found {
+		// Remove a stale socket left behind by a previous instance
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale control socket failed: %w", err)
+		}
+		s.socket = path
+		s.listener, err = net.Listen("unix", path)
+		// File permissions do not apply to sockets on Windows
+		if err == nil && runtime.GOOS != "windows" {
+			if err := os.Chmod(path, 0o600); err != nil {
+				s.listener.Close()
+				return nil, fmt.Errorf("restricting permissions of control socket failed: %w", err)
+			}
+		}
+	} else {
+		if err := checkLoopback(address); err != nil {
+			return nil, err
+		}
+		s.listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listening on control address %q failed: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /plugins", s.listPlugins)
+	mux.HandleFunc("GET /plugins/{id}", s.getPlugin)
+	mux.HandleFunc("POST /plugins/{id}/flush", s.flushPlugin)
+	mux.HandleFunc("POST /plugins/{id}/gather", s.gatherPlugin)
+	mux.HandleFunc("PUT /plugins/{id}/log_level", s.setLogLevel)
+	mux.HandleFunc("POST /reload", s.reloadConfig)
+
+	s.server = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("E! Control server failed: %v", err)
+		}
+	}()
+	log.Printf("I! Started control server on %s", address)
+
+	return s, nil
+}
+
+// checkLoopback returns an error if the given TCP address is not restricted to
+// the loopback interface.
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid control address %q: %w", address, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("control address %q is not a loopback address, use a loopback address or a Unix socket", address)
+	}
+	return nil
+}
+
+func (s *controlServer) close() {
+	if err := s.server.Close(); err != nil {
+		log.Printf("E! Closing control server failed: %v", err)
+	}
+	if s.socket != "" {
+		if err := os.Remove(s.socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("E! Removing control socket failed: %v", err)
+		}
+	}
+}
+
+func (s *controlServer) listPlugins(w http.ResponseWriter, _ *http.Request) {
+	plugins := s.agent.Plugins()
+	stats := pluginStats()
+
+	status := make([]pluginStatus, 0, len(plugins))
+	for _, p := range plugins {
+		status = append(status, newPluginStatus(p, stats))
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *controlServer) getPlugin(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	stats := pluginStats()
+
+	// Identical plugins share the same ID so multiple plugins might match
+	status := make([]pluginStatus, 0, 1)
+	for _, p := range s.agent.Plugins() {
+		if p.ID == id {
+			status = append(status, newPluginStatus(p, stats))
+		}
+	}
+	if len(status) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no plugin with ID %q", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *controlServer) flushPlugin(w http.ResponseWriter, r *http.Request) {
+	if err := s.agent.FlushOutput(r.PathValue("id")); err != nil {
+		writeError(w, errorStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *controlServer) gatherPlugin(w http.ResponseWriter, r *http.Request) {
+	if err := s.agent.GatherInput(r.PathValue("id")); err != nil {
+		writeError(w, errorStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *controlServer) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		LogLevel string `json:"log_level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decoding request failed: %w", err))
+		return
+	}
+
+	if err := s.agent.SetLogLevel(r.PathValue("id"), request.LogLevel); err != nil {
+		writeError(w, errorStatus(err, http.StatusBadRequest), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *controlServer) reloadConfig(w http.ResponseWriter, _ *http.Request) {
+	s.reload()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func newPluginStatus(p agent.PluginInfo, stats map[string]map[string]map[string]int64) pluginStatus {
+	return pluginStatus{
+		Category: p.Category,
+		Name:     p.Name,
+		Alias:    p.Alias,
+		ID:       p.ID,
+		LogLevel: p.LogLevel,
+		Stats:    stats[p.ID],
+	}
+}
+
+// pluginStats returns the internal statistics of all plugins indexed by
+// plugin ID, measurement and field
+func pluginStats() map[string]map[string]map[string]int64 {
+	stats := make(map[string]map[string]map[string]int64)
+	for _, m := range selfstat.Peek() {
+		id, found := m.GetTag("_id")
+		if !found {
+			continue
+		}
+		if _, found := stats[id]; !found {
+			stats[id] = make(map[string]map[string]int64)
+		}
+		fields := make(map[string]int64, len(m.FieldList()))
+		for _, field := range m.FieldList() {
+			if v, ok := field.Value.(int64); ok {
+				fields[field.Key] = v
+			}
+		}
+		stats[id][m.Name()] = fields
+	}
+	return stats
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! Writing control response failed: %v", err)
+	}
+}
+
+// errorStatus returns the HTTP status for the given agent error, using the
+// fallback status for errors not caused by the request target or agent state
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, agent.ErrPluginNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, agent.ErrNotRunning):
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
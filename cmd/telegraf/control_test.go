@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+func TestControlServer(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(time.Hour)
+	c.Agent.FlushInterval = config.Duration(time.Hour)
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&controlInput{}, &models.InputConfig{Name: "control", ID: "input-id"}),
+	}
+	ag := agent.NewAgent(c)
+
+	var reloads atomic.Int32
+	socket := filepath.Join(t.TempDir(), "telegraf.sock")
+	server, err := newControlServer("unix://"+socket, ag, func() { reloads.Add(1) })
+	require.NoError(t, err)
+	defer server.close()
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(socket)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	do := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, "http://telegraf"+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodGet, "/plugins", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var plugins []pluginStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plugins))
+	require.Len(t, plugins, 1)
+	require.Equal(t, "inputs", plugins[0].Category)
+	require.Equal(t, "control", plugins[0].Name)
+	require.Contains(t, plugins[0].Stats, "internal_gather")
+
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/plugins/unknown", "").StatusCode)
+
+	resp = do(http.MethodPut, "/plugins/input-id/log_level", `{"log_level": "trace"}`)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, telegraf.Trace, c.Inputs[0].Log().Level())
+	resp = do(http.MethodPut, "/plugins/input-id/log_level", `{"log_level": "verbose"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodPut, "/plugins/unknown/log_level", `{"log_level": "debug"}`)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The agent is not running so gathering is not possible
+	require.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/plugins/input-id/gather", "").StatusCode)
+	require.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/plugins/unknown/flush", "").StatusCode)
+
+	require.Equal(t, http.StatusAccepted, do(http.MethodPost, "/reload", "").StatusCode)
+	require.Equal(t, int32(1), reloads.Load())
+}
+
+func TestControlServerLoopbackOnly(t *testing.T) {
+	ag := agent.NewAgent(config.NewConfig())
+	for _, address := range []string{":0", "0.0.0.0:0", "[::]:0", "192.0.2.1:0", "example.com:0", "invalid"} {
+		_, err := newControlServer(address, ag, func() {})
+		require.Error(t, err, address)
+	}
+
+	for _, address := range []string{"localhost:0", "127.0.0.1:0"} {
+		server, err := newControlServer(address, ag, func() {})
+		require.NoError(t, err, address)
+		server.close()
+	}
+}
+
+type controlInput struct{}
+
+func (*controlInput) SampleConfig() string {
+	return ""
+}
+
+func (*controlInput) Gather(telegraf.Accumulator) error {
+	return nil
+}
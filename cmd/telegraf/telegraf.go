@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -82,6 +83,13 @@ type Telegraf struct {
 
 	cfg *config.Config
 
+	// Agent currently running, used for partial reloads
+	agent     *agent.Agent
+	agentLock sync.Mutex
+
+	// Reloads requested via the control API
+	reloadRequests chan struct{}
+
 	GlobalFlags
 	WindowFlags
 }
@@ -141,6 +149,7 @@ func (t *Telegraf) GetSecretStore(id string) (telegraf.SecretStore, error) {
 
 func (t *Telegraf) reloadLoop() error {
 	reloadConfig := false
+	t.reloadRequests = make(chan struct{}, 1)
 	reload := make(chan bool, 1)
 	reload <- true
 	for <-reload {
@@ -182,25 +191,29 @@ func (t *Telegraf) reloadLoop() error {
 			}
 		}
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Println("I! Reloading Telegraf config")
-					// May need to update the list of known config files
-					// if a delete or create occured. That way on the reload
-					// we ensure we watch the correct files.
-					if err := t.getConfigFiles(); err != nil {
-						log.Println("E! Error loading config files: ", err)
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Println("I! Reloading Telegraf config")
+						if t.reload(reload) {
+							continue
+						}
+					}
+					cancel()
+				case <-t.reloadRequests:
+					log.Println("I! Reloading Telegraf config as requested via the control API")
+					if t.reload(reload) {
+						continue
 					}
-					<-reload
-					reload <- true
+					cancel()
+				case err := <-t.pprofErr:
+					log.Printf("E! pprof server failed: %v", err)
+					cancel()
+				case <-stop:
+					cancel()
 				}
-				cancel()
-			case err := <-t.pprofErr:
-				log.Printf("E! pprof server failed: %v", err)
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
@@ -214,25 +227,119 @@ func (t *Telegraf) reloadLoop() error {
 	return nil
 }
 
+// reload applies the current configuration and returns true if the running
+// agent was updated. Otherwise the agent must be restarted, which is scheduled
+// via the given reload channel.
+func (t *Telegraf) reload(reload chan bool) bool {
+	// May need to update the list of known config files
+	// if a delete or create occured. That way on the reload
+	// we ensure we watch the correct files.
+	if err := t.getConfigFiles(); err != nil {
+		log.Println("E! Error loading config files: ", err)
+	}
+	if t.reloadPlugins() {
+		return true
+	}
+	<-reload
+	reload <- true
+	return false
+}
+
+// requestReload triggers a reload of the configuration without blocking,
+// multiple pending requests are merged into one.
+func (t *Telegraf) requestReload() {
+	select {
+	case t.reloadRequests <- struct{}{}:
+	default:
+	}
+}
+
+// reloadPlugins applies the changed configuration to the running agent if
+// partial reloads are enabled. It returns false if the agent must be restarted
+// to apply the configuration.
+func (t *Telegraf) reloadPlugins() bool {
+	t.agentLock.Lock()
+	ag := t.agent
+	t.agentLock.Unlock()
+	if ag == nil || ag.Config.Agent.ReloadMode != "partial" {
+		return false
+	}
+
+	c := t.newConfiguration()
+	c.ReusePlugins(ag.Config)
+	if err := c.LoadAll(t.configFiles...); err != nil {
+		log.Printf("E! Loading config for partial reload failed, restarting agent: %v", err)
+		ag.Config.DiscardPlugins(c.Inputs, c.Outputs)
+		return false
+	}
+	if len(c.Inputs) == 0 || len(c.Outputs) == 0 {
+		ag.Config.DiscardPlugins(c.Inputs, c.Outputs)
+		return false
+	}
+	if reason := c.RestartReason(ag.Config); reason != "" {
+		log.Printf("I! Restarting agent as %s", reason)
+		ag.Config.DiscardPlugins(c.Inputs, c.Outputs)
+		return false
+	}
+
+	// The agent releases the plugins not handed over on errors
+	if err := ag.Reload(c); err != nil {
+		log.Printf("E! Partial reload failed, restarting agent: %v", err)
+		return false
+	}
+	log.Printf("I! Loaded inputs: %s\n%s", strings.Join(c.InputNames(), " "), c.InputNamesWithSources())
+	log.Printf("I! Loaded outputs: %s\n%s", strings.Join(c.OutputNames(), " "), c.OutputNamesWithSources())
+
+	return true
+}
+
 func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal, fConfig string) {
-	var mytomb tomb.Tomb
-	var watcher watch.FileWatcher
-	if t.watchConfig == "poll" {
-		if t.watchInterval > 0 {
-			watcher = watch.NewPollingFileWatcherWithDuration(fConfig, t.watchInterval)
+	// The watchers end after the file is deleted or renamed, e.g. when being
+	// replaced by editors, so the watcher must be re-armed after those events.
+	var mytomb *tomb.Tomb
+	var changes *watch.FileChanges
+	arm := func() error {
+		var watcher watch.FileWatcher
+		if t.watchConfig == "poll" {
+			if t.watchInterval > 0 {
+				watcher = watch.NewPollingFileWatcherWithDuration(fConfig, t.watchInterval)
+			} else {
+				watcher = watch.NewPollingFileWatcher(fConfig)
+			}
 		} else {
-			watcher = watch.NewPollingFileWatcher(fConfig)
+			watcher = watch.NewInotifyFileWatcher(fConfig)
 		}
-	} else {
-		watcher = watch.NewInotifyFileWatcher(fConfig)
+		mytomb = &tomb.Tomb{}
+		var err error
+		changes, err = watcher.ChangeEvents(mytomb, 0)
+		return err
 	}
-	changes, err := watcher.ChangeEvents(&mytomb, 0)
-	if err != nil {
+	disarm := func() {
+		if mytomb != nil {
+			mytomb.Done()
+		}
+		mytomb = nil
+		changes = watch.NewFileChanges()
+	}
+
+	if err := arm(); err != nil {
 		log.Printf("E! Error watching config file/directory %q: %s\n", fConfig, err)
 		return
 	}
 	log.Printf("I! Config watcher started for %s\n", fConfig)
 
+	// Retry re-arming the watcher while the file/directory does not exist
+	var rearmTimer <-chan time.Time
+	rearm := func() {
+		disarm()
+		if err := arm(); err != nil {
+			disarm()
+			rearmTimer = time.After(time.Second)
+			return
+		}
+		rearmTimer = nil
+	}
+
 	// Setup debounce timer
 	var reloadTimer *time.Timer
 	var reloadPending bool
@@ -278,7 +385,7 @@ func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal,
 			if reloadTimer != nil {
 				reloadTimer.Stop()
 			}
-			mytomb.Done()
+			disarm()
 			return
 
 		case <-changes.Modified:
@@ -301,8 +408,15 @@ func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal,
 			} else {
 				reason = fmt.Sprintf("W! Config file/directory %q deleted\n", fConfig)
 			}
+			rearm()
 			resetTimer(reason)
 
+		case <-rearmTimer:
+			rearm()
+			if rearmTimer == nil {
+				resetTimer(fmt.Sprintf("I! Config file/directory %q recreated\n", fConfig))
+			}
+
 		case <-changes.Truncated:
 			resetTimer(fmt.Sprintf("I! Config file/directory %q truncated\n", fConfig))
 
@@ -326,7 +440,7 @@ func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal,
 				reloadPending = false
 			}
 
-		case <-mytomb.Dying():
+		case <-dying(mytomb):
 			if reloadTimer != nil {
 				reloadTimer.Stop()
 			}
@@ -336,6 +450,15 @@ func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal,
 	}
 }
 
+// dying returns the dying channel of the given tomb or a channel never firing
+// if there is no tomb
+func dying(t *tomb.Tomb) <-chan struct{} {
+	if t == nil {
+		return nil
+	}
+	return t.Dying()
+}
+
 func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal, interval time.Duration, remoteConfigs []string) {
 	configs := strings.Join(remoteConfigs, ", ")
 	log.Printf("I! Remote config watcher started for: %s\n", configs)
@@ -348,8 +471,6 @@ func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal,
 		select {
 		case <-ctx.Done():
 			return
-		case <-signals:
-			return
 		case <-ticker.C:
 			for _, configURL := range remoteConfigs {
 				req, err := http.NewRequest("HEAD", configURL, nil)
@@ -380,8 +501,12 @@ func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal,
 					lastModified[configURL] = modified
 				} else if lastModified[configURL] != modified {
 					log.Printf("I! Remote config modified: %s\n", configURL)
-					signals <- syscall.SIGHUP
-					return
+					lastModified[configURL] = modified
+					select {
+					case signals <- syscall.SIGHUP:
+					case <-ctx.Done():
+						return
+					}
 				}
 			}
 		}
@@ -390,12 +515,7 @@ func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal,
 
 func (t *Telegraf) loadConfiguration() (*config.Config, error) {
 	// If no other options are specified, load the config file and run.
-	c := config.NewConfig()
-	c.Agent.Quiet = t.quiet
-	c.Agent.ConfigURLRetryAttempts = t.configURLRetryAttempts
-	c.OutputFilters = t.outputFilters
-	c.InputFilters = t.inputFilters
-	c.SecretStoreFilters = t.secretstoreFilters
+	c := t.newConfiguration()
 
 	if err := t.getConfigFiles(); err != nil {
 		return c, err
@@ -406,6 +526,16 @@ func (t *Telegraf) loadConfiguration() (*config.Config, error) {
 	return c, nil
 }
 
+func (t *Telegraf) newConfiguration() *config.Config {
+	c := config.NewConfig()
+	c.Agent.Quiet = t.quiet
+	c.Agent.ConfigURLRetryAttempts = t.configURLRetryAttempts
+	c.OutputFilters = t.outputFilters
+	c.InputFilters = t.inputFilters
+	c.SecretStoreFilters = t.secretstoreFilters
+	return c
+}
+
 func (t *Telegraf) getConfigFiles() error {
 	var configFiles []string
 
@@ -455,6 +585,12 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		return fmt.Errorf("agent flush_interval must be positive; found %v", c.Agent.Interval)
 	}
 
+	switch c.Agent.ReloadMode {
+	case "", "full", "partial":
+	default:
+		return fmt.Errorf("invalid agent reload_mode %q", c.Agent.ReloadMode)
+	}
+
 	// Setup logging as configured.
 	logConfig := &logger.Config{
 		Debug:                   c.Agent.Debug || t.debug,
@@ -562,6 +698,23 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		}
 	}
 
+	if c.Agent.ControlAddress != "" {
+		server, err := newControlServer(c.Agent.ControlAddress, ag, t.requestReload)
+		if err != nil {
+			return err
+		}
+		defer server.close()
+	}
+
+	t.agentLock.Lock()
+	t.agent = ag
+	t.agentLock.Unlock()
+	defer func() {
+		t.agentLock.Lock()
+		t.agent = nil
+		t.agentLock.Unlock()
+	}()
+
 	return ag.Run(ctx)
 }
 
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchLocalConfigReplacedByRename(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, os.WriteFile(filename, []byte("# initial\n"), 0o600))
+
+	tg := &Telegraf{GlobalFlags: GlobalFlags{watchConfig: "inotify"}}
+	signals := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tg.watchLocalConfig(ctx, signals, filename)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	reloaded := func(timeout time.Duration) bool {
+		select {
+		case sig := <-signals:
+			return sig == syscall.SIGHUP
+		case <-time.After(timeout):
+			return false
+		}
+	}
+	modify := func() {
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		_, err = f.WriteString("# modified\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	// Wait for the watcher to be armed
+	require.Eventually(t, func() bool {
+		modify()
+		return reloaded(500 * time.Millisecond)
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Replace the file by renaming a new file over it like editors do and
+	// consume all resulting reload requests
+	replacement := filepath.Join(dir, "telegraf.conf.new")
+	require.NoError(t, os.WriteFile(replacement, []byte("# replaced\n"), 0o600))
+	require.NoError(t, os.Rename(replacement, filename))
+	require.True(t, reloaded(3*time.Second))
+	for {
+		if !reloaded(2 * time.Second) {
+			break
+		}
+	}
+
+	// The watcher must still detect changes of the replaced file
+	modify()
+	require.True(t, reloaded(3*time.Second), "no reload after modifying the replaced file")
+}
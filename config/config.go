@@ -81,6 +81,7 @@ type Config struct {
 
 	SecretStores      map[string]telegraf.SecretStore
 	secretStoreSource map[string][]string
+	secretStoreHashes map[string]string
 
 	Agent       *AgentConfig
 	Inputs      []*models.RunningInput
@@ -101,6 +102,10 @@ type Config struct {
 
 	NumberSecrets uint64
 
+	// Running plugins of a previous configuration to reuse by ID
+	reusableInputs  map[string][]*models.RunningInput
+	reusableOutputs map[string][]*models.RunningOutput
+
 	seenAgentTable     bool
 	seenAgentTableOnce sync.Once
 }
@@ -139,6 +144,7 @@ func NewConfig() *Config {
 		AggProcessors:      make([]*models.RunningProcessor, 0),
 		SecretStores:       make(map[string]telegraf.SecretStore),
 		secretStoreSource:  make(map[string][]string),
+		secretStoreHashes:  make(map[string]string),
 		fileProcessors:     make([]*OrderedPlugin, 0),
 		fileAggProcessors:  make([]*OrderedPlugin, 0),
 		InputFilters:       make([]string, 0),
@@ -275,6 +281,24 @@ type AgentConfig struct {
 	// the state in the file will be restored for the plugins.
 	Statefile string `toml:"statefile"`
 
+	// Interval for periodically storing the state of plugins to the statefile
+	// in addition to storing the state on termination. Zero disables the
+	// periodic checkpoints.
+	StatefileInterval Duration `toml:"statefile_interval"`
+
+	// Flush the statefile to disk after writing, to not lose the state on
+	// power failures at the cost of additional disk I/O.
+	StatefileFsync bool `toml:"statefile_fsync"`
+
+	// Mode for applying configuration changes on reload. "full" restarts
+	// all plugins while "partial" only restarts the changed inputs and
+	// outputs.
+	ReloadMode string `toml:"reload_mode"`
+
+	// Address of the local control API, either "host:port" or
+	// "unix:///path/to/socket". Disabled if empty.
+	ControlAddress string `toml:"control_address"`
+
 	// Flag to always keep tags explicitly defined in the plugin itself and
 	// ensure those tags always pass filtering.
 	AlwaysIncludeLocalTags bool `toml:"always_include_local_tags"`
@@ -293,12 +317,43 @@ type AgentConfig struct {
 	ConfigURLRetryAttempts int `toml:"config_url_retry_attempts"`
 
 	// BufferStrategy is the metric buffer type to use for a given output plugin.
-	// Supported types currently are "memory" and "disk_write_through" (alias: "disk").
+	// Supported types currently are "memory", "disk_write_through" (alias: "disk")
+	// and "memory_with_disk_overflow".
 	BufferStrategy string `toml:"buffer_strategy"`
 
 	// BufferDirectory is the directory to store buffer files for serialized
-	// to disk metrics when using the "disk_write_through" buffer strategy.
+	// to disk metrics when using the "disk_write_through" or
+	// "memory_with_disk_overflow" buffer strategies.
 	BufferDirectory string `toml:"buffer_directory"`
+
+	// BufferDiskMaxSize is the maximum size of the buffer files of each
+	// output plugin. The oldest metrics are dropped when exceeding the limit.
+	BufferDiskMaxSize Size `toml:"buffer_disk_max_size"`
+
+	// BufferDiskMaxMetrics is the maximum number of metrics stored on disk for
+	// each output plugin. The oldest metrics are dropped when exceeding the
+	// limit.
+	BufferDiskMaxMetrics int `toml:"buffer_disk_max_metrics"`
+
+	// BufferDiskMaxAge is the maximum time metrics are kept on disk before
+	// being dropped.
+	BufferDiskMaxAge Duration `toml:"buffer_disk_max_age"`
+
+	// CardinalityLimit is the maximum number of distinct series of each input
+	// plugin within the cardinality window. Disabled if zero.
+	CardinalityLimit int `toml:"cardinality_limit"`
+
+	// CardinalityWindow is the time after which a series not seen anymore is
+	// no longer counted towards the limit.
+	CardinalityWindow Duration `toml:"cardinality_window"`
+
+	// CardinalityAction is applied to metrics of new series exceeding the
+	// limit. Supported actions are "drop", "strip_tags" and "aggregate".
+	CardinalityAction string `toml:"cardinality_action"`
+
+	// CardinalityTags are the tag keys stripped or aggregated when exceeding
+	// the limit. Defaults to the tag with the most distinct values.
+	CardinalityTags []string `toml:"cardinality_tags"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -577,6 +632,11 @@ func (c *Config) LoadAll(configFiles ...string) error {
 	}
 	c.NumberSecrets = uint64(count)
 
+	if err := c.linkDeadLetters(); err != nil {
+		return err
+	}
+	c.checkPipelines()
+
 	// Let's link all secrets to their secret-stores
 	return c.LinkSecrets()
 }
@@ -657,6 +717,8 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 	if c.Agent.Statefile != "" {
 		c.Persister = &persister.Persister{
 			Filename: c.Agent.Statefile,
+			Interval: time.Duration(c.Agent.StatefileInterval),
+			Fsync:    c.Agent.StatefileFsync,
 		}
 	}
 
@@ -939,6 +1001,8 @@ func parseConfig(contents []byte) (*ast.Table, error) {
 }
 
 func (c *Config) addAggregator(name, source string, table *ast.Table) error {
+	c.markUnusedField(table, "pipeline")
+
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
 		// Handle removed, deprecated plugins
@@ -948,22 +1012,28 @@ func (c *Config) addAggregator(name, source string, table *ast.Table) error {
 		}
 		return fmt.Errorf("undefined but requested aggregator: %s", name)
 	}
-	aggregator := creator()
 
-	conf, err := c.buildAggregator(name, source, table)
-	if err != nil {
-		return err
-	}
+	// Each pipeline gets its own aggregator instance
+	for _, pipeline := range c.getPipelines(table) {
+		aggregator := creator()
 
-	if err := c.toml.UnmarshalTable(table, aggregator); err != nil {
-		return err
-	}
+		conf, err := c.buildAggregator(name, source, table)
+		if err != nil {
+			return err
+		}
+		conf.Pipeline = pipeline
+		conf.ID = pipelineID(conf.ID, pipeline)
 
-	if err := c.printUserDeprecation("aggregators", name, aggregator); err != nil {
-		return err
-	}
+		if err := c.toml.UnmarshalTable(table, aggregator); err != nil {
+			return err
+		}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+		if err := c.printUserDeprecation("aggregators", name, aggregator); err != nil {
+			return err
+		}
+
+		c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	}
 	return nil
 }
 
@@ -1009,7 +1079,12 @@ func (c *Config) addSecretStore(name, source string, table *ast.Table) error {
 	if _, found := c.SecretStores[storeID]; found {
 		return fmt.Errorf("duplicate ID %q for secretstore %q", storeID, name)
 	}
+	hash, err := generatePluginID("secretstores."+name, table)
+	if err != nil {
+		return err
+	}
 	c.SecretStores[storeID] = store
+	c.secretStoreHashes[storeID] = hash
 	if _, found := c.secretStoreSource[name]; !found {
 		c.secretStoreSource[name] = make([]string, 0)
 	}
@@ -1144,6 +1219,8 @@ func (c *Config) addSerializer(parentname string, table *ast.Table) (*models.Run
 }
 
 func (c *Config) addProcessor(name, source string, table *ast.Table) error {
+	c.markUnusedField(table, "pipeline")
+
 	creator, ok := processors.Processors[name]
 	if !ok {
 		// Handle removed, deprecated plugins
@@ -1165,33 +1242,43 @@ func (c *Config) addProcessor(name, source string, table *ast.Table) error {
 	c.setLocalMissingTomlFieldTracker(missCount)
 	defer c.resetMissingTomlFieldTracker()
 
-	// Set up the processor running before the aggregators
-	processorBeforeConfig, err := c.buildProcessor("processors", name, source, table)
-	if err != nil {
-		return err
-	}
-	processorBefore, count, err := c.setupProcessor(processorBeforeConfig.Name, creator, table)
-	if err != nil {
-		return err
-	}
-	rf := models.NewRunningProcessor(processorBefore, processorBeforeConfig)
-	c.fileProcessors = append(c.fileProcessors, &OrderedPlugin{table.Line, rf})
+	// Each pipeline gets its own processor instances
+	pipelines := c.getPipelines(table)
+	var count int
+	for _, pipeline := range pipelines {
+		// Set up the processor running before the aggregators
+		processorBeforeConfig, err := c.buildProcessor("processors", name, source, table)
+		if err != nil {
+			return err
+		}
+		processorBeforeConfig.Pipeline = pipeline
+		processorBeforeConfig.ID = pipelineID(processorBeforeConfig.ID, pipeline)
+		processorBefore, n, err := c.setupProcessor(processorBeforeConfig.Name, creator, table)
+		if err != nil {
+			return err
+		}
+		count = n
+		rf := models.NewRunningProcessor(processorBefore, processorBeforeConfig)
+		c.fileProcessors = append(c.fileProcessors, &OrderedPlugin{table.Line, rf})
 
-	// Setup another (new) processor instance running after the aggregator
-	processorAfterConfig, err := c.buildProcessor("aggprocessors", name, source, table)
-	if err != nil {
-		return err
-	}
-	processorAfter, _, err := c.setupProcessor(processorAfterConfig.Name, creator, table)
-	if err != nil {
-		return err
+		// Setup another (new) processor instance running after the aggregator
+		processorAfterConfig, err := c.buildProcessor("aggprocessors", name, source, table)
+		if err != nil {
+			return err
+		}
+		processorAfterConfig.Pipeline = pipeline
+		processorAfterConfig.ID = pipelineID(processorAfterConfig.ID, pipeline)
+		processorAfter, _, err := c.setupProcessor(processorAfterConfig.Name, creator, table)
+		if err != nil {
+			return err
+		}
+		rf = models.NewRunningProcessor(processorAfter, processorAfterConfig)
+		c.fileAggProcessors = append(c.fileAggProcessors, &OrderedPlugin{table.Line, rf})
 	}
-	rf = models.NewRunningProcessor(processorAfter, processorAfterConfig)
-	c.fileAggProcessors = append(c.fileAggProcessors, &OrderedPlugin{table.Line, rf})
 
 	// Check the number of misses against the threshold. We need to double
-	// the count as the processor setup is executed twice.
-	missCountThreshold = 2 * count
+	// the count as the processor setup is executed twice per pipeline.
+	missCountThreshold = 2 * count * len(pipelines)
 	for key, count := range missCount {
 		if count <= missCountThreshold {
 			continue
@@ -1270,6 +1357,7 @@ func (c *Config) addOutput(name, source string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
 	}
+	c.markUnusedField(table, "pipeline")
 
 	// For outputs with serializers we need to compute the set of
 	// options that is not covered by both, the serializer and the input.
@@ -1343,6 +1431,13 @@ func (c *Config) addOutput(name, source string, table *ast.Table) error {
 		}
 	}
 
+	// Keep the running instance of unchanged plugins on partial reloads to
+	// not open the buffer of the output twice
+	if ro := c.reusableOutput(outputConfig.ID); ro != nil {
+		c.Outputs = append(c.Outputs, ro)
+		return nil
+	}
+
 	ro := models.NewRunningOutput(output, outputConfig, c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
 	c.Outputs = append(c.Outputs, ro)
 
@@ -1353,6 +1448,7 @@ func (c *Config) addInput(name, source string, table *ast.Table) error {
 	if len(c.InputFilters) > 0 && !sliceContains(name, c.InputFilters) {
 		return nil
 	}
+	c.markUnusedField(table, "pipelines")
 
 	// For inputs with parsers we need to compute the set of
 	// options that is not covered by both, the parser and the input.
@@ -1427,6 +1523,12 @@ func (c *Config) addInput(name, source string, table *ast.Table) error {
 		}
 	}
 
+	// Keep the running instance of unchanged plugins on partial reloads
+	if rp := c.reusableInput(pluginConfig.ID); rp != nil {
+		c.Inputs = append(c.Inputs, rp)
+		return nil
+	}
+
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
 	c.Inputs = append(c.Inputs, rp)
@@ -1618,6 +1720,13 @@ func (c *Config) buildInput(name, source string, tbl *ast.Table) (*models.InputC
 	cp.NameOverride = c.getFieldString(tbl, "name_override")
 	cp.Alias = c.getFieldString(tbl, "alias")
 	cp.LogLevel = c.getFieldString(tbl, "log_level")
+	cp.Pipeline = c.getFieldString(tbl, "pipeline")
+	cp.Cardinality = c.buildCardinality(tbl, models.CardinalityConfig{
+		Limit:  c.Agent.CardinalityLimit,
+		Window: time.Duration(c.Agent.CardinalityWindow),
+		Action: c.Agent.CardinalityAction,
+		Tags:   c.Agent.CardinalityTags,
+	})
 
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
@@ -1663,6 +1772,11 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 		Filter:          filter,
 		BufferStrategy:  bufferStrategy,
 		BufferDirectory: c.Agent.BufferDirectory,
+		BufferDiskLimits: models.DiskBufferLimits{
+			MaxSize:    int64(c.Agent.BufferDiskMaxSize),
+			MaxMetrics: c.Agent.BufferDiskMaxMetrics,
+			MaxAge:     time.Duration(c.Agent.BufferDiskMaxAge),
+		},
 	}
 
 	// TODO: support FieldPass/FieldDrop on outputs
@@ -1677,13 +1791,34 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	oc.NamePrefix = c.getFieldString(tbl, "name_prefix")
 	oc.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	oc.LogLevel = c.getFieldString(tbl, "log_level")
+	oc.Pipelines = c.getFieldStringSlice(tbl, "pipelines")
+	oc.CircuitBreaker.Threshold = c.getFieldInt(tbl, "circuit_breaker_threshold")
+	oc.CircuitBreaker.MinDelay, _ = c.getFieldDuration(tbl, "circuit_breaker_min_delay")
+	oc.CircuitBreaker.MaxDelay, _ = c.getFieldDuration(tbl, "circuit_breaker_max_delay")
+	oc.CircuitBreaker.Jitter, _ = c.getFieldDuration(tbl, "circuit_breaker_jitter")
+	oc.CircuitBreaker.ProbeBatchSize = c.getFieldInt(tbl, "circuit_breaker_probe_batch_size")
+	oc.Cardinality = c.buildCardinality(tbl, models.CardinalityConfig{})
+
+	if node, found := tbl.Fields["dead_letter"]; found {
+		subtbl, ok := node.(*ast.Table)
+		if !ok {
+			return nil, fmt.Errorf("invalid 'dead_letter' setting for outputs.%s, expected a table", name)
+		}
+		oc.DeadLetter, err = c.buildDeadLetter(name, subtbl)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
 
-	if oc.BufferStrategy == "disk_write_through" {
+	switch oc.BufferStrategy {
+	case "disk_write_through":
 		log.Printf("W! Using disk-write-through buffer strategy for plugin outputs.%s, this is an experimental feature", name)
+	case "memory_with_disk_overflow":
+		log.Printf("W! Using memory-with-disk-overflow buffer strategy for plugin outputs.%s, this is an experimental feature", name)
 	}
 
 	// Generate an ID for the plugin
@@ -1691,13 +1826,114 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	return oc, err
 }
 
+// buildCardinality parses the series limit of an input or output overriding
+// the given defaults
+func (c *Config) buildCardinality(tbl *ast.Table, cc models.CardinalityConfig) models.CardinalityConfig {
+	if _, found := tbl.Fields["cardinality_limit"]; found {
+		cc.Limit = c.getFieldInt(tbl, "cardinality_limit")
+	}
+	if window, found := c.getFieldDuration(tbl, "cardinality_window"); found {
+		cc.Window = window
+	}
+	if action := c.getFieldString(tbl, "cardinality_action"); action != "" {
+		cc.Action = action
+	}
+	if _, found := tbl.Fields["cardinality_tags"]; found {
+		cc.Tags = c.getFieldStringSlice(tbl, "cardinality_tags")
+	}
+	return cc
+}
+
+// buildDeadLetter parses the dead-letter destination of an output
+func (c *Config) buildDeadLetter(name string, tbl *ast.Table) (*models.DeadLetterConfig, error) {
+	dl := &models.DeadLetterConfig{
+		Output:              c.getFieldString(tbl, "output"),
+		File:                c.getFieldString(tbl, "file"),
+		RotationMaxArchives: -1,
+		ReasonTag:           c.getFieldString(tbl, "reason_tag"),
+	}
+	if _, found := tbl.Fields["rotation_max_archives"]; found {
+		dl.RotationMaxArchives = c.getFieldInt(tbl, "rotation_max_archives")
+	}
+	dl.RotationInterval, _ = c.getFieldDuration(tbl, "rotation_interval")
+	if size := c.getFieldString(tbl, "rotation_max_size"); size != "" {
+		var s Size
+		if err := s.UnmarshalText([]byte(size)); err != nil {
+			return nil, fmt.Errorf("invalid dead-letter 'rotation_max_size' for outputs.%s: %w", name, err)
+		}
+		dl.RotationMaxSize = int64(s)
+	}
+	if c.hasErrs() {
+		return nil, c.firstErr()
+	}
+
+	if dl.File == "" {
+		return dl, nil
+	}
+
+	// Only pass the serializer options to the serializer
+	options := &ast.Table{
+		Name:     tbl.Name,
+		Position: tbl.Position,
+		Line:     tbl.Line,
+		Type:     tbl.Type,
+		Data:     tbl.Data,
+		Fields:   make(map[string]interface{}, len(tbl.Fields)),
+	}
+	for key, value := range tbl.Fields {
+		switch key {
+		case "output", "file", "rotation_interval", "rotation_max_size", "rotation_max_archives", "reason_tag":
+		default:
+			options.Fields[key] = value
+		}
+	}
+	serializer, err := c.addSerializer(name+"::dead_letter", options)
+	if err != nil {
+		return nil, fmt.Errorf("creating dead-letter serializer for outputs.%s failed: %w", name, err)
+	}
+	dl.Serializer = serializer
+	return dl, nil
+}
+
+// linkDeadLetters connects the outputs with their dead-letter outputs
+func (c *Config) linkDeadLetters() error {
+	for _, output := range c.Outputs {
+		dl := output.Config.DeadLetter
+		if dl == nil || dl.Output == "" {
+			continue
+		}
+
+		var target *models.RunningOutput
+		for _, candidate := range c.Outputs {
+			if candidate.Config.Alias != dl.Output && (candidate.Config.Alias != "" || candidate.Config.Name != dl.Output) {
+				continue
+			}
+			if target != nil {
+				return fmt.Errorf("dead-letter output %q of %s is ambiguous, use an alias", dl.Output, output.LogName())
+			}
+			target = candidate
+		}
+		if target == nil {
+			return fmt.Errorf("dead-letter output %q of %s not found", dl.Output, output.LogName())
+		}
+		if target == output {
+			return fmt.Errorf("dead-letter output of %s must not be the output itself", output.LogName())
+		}
+		output.SetDeadLetterOutput(target)
+	}
+	return nil
+}
+
 func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	switch key {
 	// General options to ignore
 	case "alias", "always_include_local_tags",
 		"buffer_strategy", "buffer_directory",
+		"cardinality_action", "cardinality_limit", "cardinality_tags", "cardinality_window",
+		"circuit_breaker_jitter", "circuit_breaker_max_delay", "circuit_breaker_min_delay",
+		"circuit_breaker_probe_batch_size", "circuit_breaker_threshold",
 		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
+		"data_format", "dead_letter", "delay", "drop", "drop_original",
 		"fielddrop", "fieldexclude", "fieldinclude", "fieldpass", "flush_interval", "flush_jitter",
 		"grace",
 		"interval",
@@ -1705,7 +1941,7 @@ func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 		"metric_batch_size", "metric_buffer_limit", "metricpass",
 		"name_override", "name_prefix", "name_suffix", "namedrop", "namedrop_separator", "namepass", "namepass_separator",
 		"order",
-		"pass", "period", "precision",
+		"pass", "period", "pipeline", "pipelines", "precision",
 		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior":
 
 	// Secret-store options to ignore
@@ -1722,6 +1958,19 @@ func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	return nil
 }
 
+// markUnusedField records the given option as unused if set in the table. This
+// is required for general options ignored by missingTomlField but only valid
+// for some plugin types, e.g. "pipeline" for inputs and "pipelines" for all
+// other plugins.
+func (c *Config) markUnusedField(tbl *ast.Table, key string) {
+	if _, found := tbl.Fields[key]; !found {
+		return
+	}
+	c.unusedFieldsMutex.Lock()
+	c.UnusedFields[key] = true
+	c.unusedFieldsMutex.Unlock()
+}
+
 func (c *Config) setLocalMissingTomlFieldTracker(counter map[string]int) {
 	f := func(t reflect.Type, key string) error {
 		// Check if we are in a root element that might share options among
@@ -1194,6 +1194,252 @@ func TestPersisterProcessorRegistration(t *testing.T) {
 	}
 }
 
+func TestReusePlugins(t *testing.T) {
+	previous := config.NewConfig()
+	require.NoError(t, previous.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  servers = ["a"]
+[[inputs.memcached]]
+  servers = ["b"]
+[[outputs.http]]
+  url = "http://a"
+[[outputs.http]]
+  url = "http://b"
+`), config.EmptySourcePath))
+
+	c := config.NewConfig()
+	c.ReusePlugins(previous)
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  servers = ["c"]
+[[inputs.memcached]]
+  servers = ["a"]
+[[outputs.http]]
+  url = "http://b"
+`), config.EmptySourcePath))
+
+	// Unchanged plugins are the running instances of the previous config
+	require.Len(t, c.Inputs, 2)
+	require.NotSame(t, previous.Inputs[0], c.Inputs[0])
+	require.NotSame(t, previous.Inputs[1], c.Inputs[0])
+	require.Same(t, previous.Inputs[0], c.Inputs[1])
+	require.Len(t, c.Outputs, 1)
+	require.Same(t, previous.Outputs[1], c.Outputs[0])
+	require.Empty(t, c.RestartReason(previous))
+}
+
+func TestDeadLetter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "telegraf.conf")
+	cfg := `
+[[outputs.http]]
+  url = "http://a"
+  [outputs.http.dead_letter]
+    output = "audit"
+[[outputs.http]]
+  alias = "audit"
+  url = "http://b"
+  [outputs.http.dead_letter]
+    file = "/tmp/dead_letter.out"
+    rotation_max_size = "1MB"
+    reason_tag = "reason"
+    data_format = "json"
+`
+	require.NoError(t, os.WriteFile(filename, []byte(cfg), 0600))
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadAll(filename))
+	require.Len(t, c.Outputs, 2)
+	require.Empty(t, c.UnusedFields)
+
+	dl := c.Outputs[0].Config.DeadLetter
+	require.NotNil(t, dl)
+	require.Equal(t, "audit", dl.Output)
+	require.Nil(t, dl.Serializer)
+
+	dl = c.Outputs[1].Config.DeadLetter
+	require.NotNil(t, dl)
+	require.Equal(t, "/tmp/dead_letter.out", dl.File)
+	require.Equal(t, int64(1000*1000), dl.RotationMaxSize)
+	require.Equal(t, -1, dl.RotationMaxArchives)
+	require.Equal(t, "reason", dl.ReasonTag)
+	serializer, ok := dl.Serializer.(*models.RunningSerializer)
+	require.True(t, ok)
+	require.Equal(t, "json", serializer.Config.DataFormat)
+}
+
+func TestDeadLetterOutputNotFound(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "telegraf.conf")
+	cfg := `
+[[outputs.http]]
+  url = "http://a"
+  [outputs.http.dead_letter]
+    output = "audit"
+`
+	require.NoError(t, os.WriteFile(filename, []byte(cfg), 0600))
+
+	c := config.NewConfig()
+	require.ErrorContains(t, c.LoadAll(filename), `dead-letter output "audit" of outputs.http not found`)
+}
+
+func TestCardinality(t *testing.T) {
+	cfg := `
+[agent]
+  cardinality_limit = 100
+  cardinality_window = "10m"
+  cardinality_action = "strip_tags"
+  cardinality_tags = ["request_id"]
+[[inputs.memcached]]
+[[inputs.procstat]]
+  cardinality_limit = 10
+  cardinality_action = "aggregate"
+[[outputs.http]]
+  url = "http://a"
+[[outputs.http]]
+  url = "http://b"
+  cardinality_limit = 1000
+`
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(cfg), config.EmptySourcePath))
+	require.Empty(t, c.UnusedFields)
+
+	// Inputs use the agent settings as defaults
+	expected := models.CardinalityConfig{
+		Limit:  100,
+		Window: 10 * time.Minute,
+		Action: "strip_tags",
+		Tags:   []string{"request_id"},
+	}
+	// The order of plugins with different names is not preserved
+	require.Len(t, c.Inputs, 2)
+	inputs := make(map[string]models.CardinalityConfig, len(c.Inputs))
+	for _, input := range c.Inputs {
+		inputs[input.Config.Name] = input.Config.Cardinality
+	}
+	require.Equal(t, expected, inputs["memcached"])
+	expected.Limit = 10
+	expected.Action = "aggregate"
+	require.Equal(t, expected, inputs["procstat"])
+
+	// Outputs are only limited if configured
+	require.Equal(t, models.CardinalityConfig{}, c.Outputs[0].Config.Cardinality)
+	require.Equal(t, models.CardinalityConfig{Limit: 1000}, c.Outputs[1].Config.Cardinality)
+}
+
+func TestPipelines(t *testing.T) {
+	cfg := `
+[[inputs.memcached]]
+[[inputs.procstat]]
+  pipeline = "system"
+[[processors.processor]]
+  pipelines = ["system", "default"]
+[[processors.parser_test]]
+  pipelines = ["system"]
+[[outputs.http]]
+  url = "http://a"
+[[outputs.http]]
+  url = "http://b"
+  pipelines = ["system", "default"]
+`
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(cfg), config.EmptySourcePath))
+	require.Empty(t, c.UnusedFields)
+	require.Equal(t, []string{"default", "system"}, c.Pipelines())
+
+	// The order of plugins with different names is not preserved
+	require.Len(t, c.Inputs, 2)
+	inputs := make(map[string]string, len(c.Inputs))
+	for _, input := range c.Inputs {
+		inputs[input.Config.Name] = input.Pipeline()
+	}
+	require.Equal(t, map[string]string{"memcached": "default", "procstat": "system"}, inputs)
+	require.Equal(t, []string{"default"}, c.Outputs[0].Pipelines())
+	require.Equal(t, []string{"system", "default"}, c.Outputs[1].Pipelines())
+
+	// Processors are instantiated per pipeline with a distinct ID except for
+	// the default pipeline
+	pipelines := make(map[string][]string)
+	ids := make(map[string]bool)
+	for _, p := range c.Processors {
+		pipelines[p.Config.Name] = append(pipelines[p.Config.Name], p.Pipeline())
+		ids[p.Config.ID] = true
+	}
+	require.Equal(t, []string{"default", "system"}, pipelines["processor"])
+	require.Equal(t, []string{"system"}, pipelines["parser_test"])
+	require.Len(t, ids, 3)
+	require.Len(t, c.AggProcessors, len(c.Processors))
+}
+
+func TestPipelinesInvalidKey(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+	}{
+		{
+			name: "input",
+			cfg:  "[[inputs.memcached]]\n  pipelines = [\"system\"]\n",
+		},
+		{
+			name: "processor",
+			cfg:  "[[processors.processor]]\n  pipeline = \"system\"\n",
+		},
+		{
+			name: "output",
+			cfg:  "[[outputs.http]]\n  url = \"http://a\"\n  pipeline = \"system\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewConfig()
+			require.ErrorContains(t, c.LoadConfigData([]byte(tt.cfg), config.EmptySourcePath), "but they were not used")
+		})
+	}
+}
+
+func TestRestartReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name:   "inputs and outputs changed",
+			config: "[[inputs.memcached]]\n[[outputs.http]]\n  url = \"http://b\"",
+		},
+		{
+			name:     "agent changed",
+			config:   "[agent]\n  interval = \"1s\"\n[[inputs.procstat]]\n[[outputs.http]]",
+			expected: "agent settings changed",
+		},
+		{
+			name:     "global tags changed",
+			config:   "[global_tags]\n  dc = \"a\"\n[[inputs.procstat]]\n[[outputs.http]]",
+			expected: "global tags changed",
+		},
+		{
+			name:     "processors changed",
+			config:   "[[inputs.procstat]]\n[[processors.processor]]\n[[outputs.http]]",
+			expected: "processors changed",
+		},
+		{
+			name:     "pipelines changed",
+			config:   "[[inputs.procstat]]\n  pipeline = \"other\"\n[[outputs.http]]\n  pipelines = [\"other\"]",
+			expected: "pipelines changed",
+		},
+	}
+
+	previous := config.NewConfig()
+	require.NoError(t, previous.LoadConfigData([]byte("[[inputs.procstat]]\n[[outputs.http]]"), config.EmptySourcePath))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewConfig()
+			require.NoError(t, c.LoadConfigData([]byte(tt.config), config.EmptySourcePath))
+			require.Equal(t, tt.expected, c.RestartReason(previous))
+		})
+	}
+}
+
 // Mockup INPUT plugin for (new) parser testing to avoid cyclic dependencies
 type MockupInputPluginParserNew struct {
 	Parser     telegraf.Parser
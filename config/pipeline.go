@@ -0,0 +1,76 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"slices"
+
+	"github.com/influxdata/toml/ast"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// Pipelines returns the sorted names of all pipelines used by the plugins
+// always including the default pipeline.
+func (c *Config) Pipelines() []string {
+	names := []string{models.DefaultPipeline}
+	for _, input := range c.Inputs {
+		names = append(names, input.Pipeline())
+	}
+	for _, processor := range c.Processors {
+		names = append(names, processor.Pipeline())
+	}
+	for _, processor := range c.AggProcessors {
+		names = append(names, processor.Pipeline())
+	}
+	for _, aggregator := range c.Aggregators {
+		names = append(names, aggregator.Pipeline())
+	}
+	for _, output := range c.Outputs {
+		names = append(names, output.Pipelines()...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// getPipelines returns the pipelines a processor or aggregator is part of,
+// defaulting to the default pipeline.
+func (c *Config) getPipelines(tbl *ast.Table) []string {
+	pipelines := c.getFieldStringSlice(tbl, "pipelines")
+	if len(pipelines) == 0 {
+		return []string{models.DefaultPipeline}
+	}
+	slices.Sort(pipelines)
+	return slices.Compact(pipelines)
+}
+
+// checkPipelines warns about pipelines whose metrics are not written to any
+// output.
+func (c *Config) checkPipelines() {
+	if len(c.Outputs) == 0 {
+		return
+	}
+
+	for _, input := range c.Inputs {
+		pipeline := input.Pipeline()
+		if !slices.ContainsFunc(c.Outputs, func(o *models.RunningOutput) bool { return o.InPipeline(pipeline) }) {
+			log.Printf("W! No output for pipeline %q of %s, metrics will be dropped", pipeline, input.LogName())
+		}
+	}
+}
+
+// pipelineID returns the ID of a plugin instance in the given pipeline. Plugins
+// in the default pipeline keep their ID to keep the state of existing
+// configurations.
+func pipelineID(id, pipeline string) string {
+	if pipeline == models.DefaultPipeline {
+		return id
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(id))
+	hash.Write([]byte{0})
+	hash.Write([]byte(pipeline))
+	return hex.EncodeToString(hash.Sum(nil))
+}
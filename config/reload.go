@@ -0,0 +1,143 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// ReusePlugins sets up the configuration to reuse the running input and
+// output instances of the given previous configuration for all plugins with
+// an unchanged ID instead of creating new instances. This allows to apply a
+// new configuration while keeping the connections of unchanged inputs and the
+// buffers of unchanged outputs. Must be called before loading the config.
+func (c *Config) ReusePlugins(previous *Config) {
+	c.reusableInputs = make(map[string][]*models.RunningInput, len(previous.Inputs))
+	for _, input := range previous.Inputs {
+		id := input.Config.ID
+		c.reusableInputs[id] = append(c.reusableInputs[id], input)
+	}
+
+	c.reusableOutputs = make(map[string][]*models.RunningOutput, len(previous.Outputs))
+	for _, output := range previous.Outputs {
+		id := output.Config.ID
+		c.reusableOutputs[id] = append(c.reusableOutputs[id], output)
+	}
+}
+
+// RestartReason returns why the configuration cannot be applied to an agent
+// running the given previous configuration by only replacing the changed
+// inputs and outputs. An empty reason means that a partial reload is possible.
+func (c *Config) RestartReason(previous *Config) string {
+	if !equalAgentConfig(c.Agent, previous.Agent) {
+		return "agent settings changed"
+	}
+	if !maps.Equal(c.Tags, previous.Tags) {
+		return "global tags changed"
+	}
+	if !maps.Equal(c.secretStoreHashes, previous.secretStoreHashes) {
+		return "secret-stores changed"
+	}
+	if !slices.Equal(c.Pipelines(), previous.Pipelines()) {
+		return "pipelines changed"
+	}
+
+	if !slices.Equal(processorIDs(c.Processors), processorIDs(previous.Processors)) {
+		return "processors changed"
+	}
+	if !slices.Equal(processorIDs(c.AggProcessors), processorIDs(previous.AggProcessors)) {
+		return "processors changed"
+	}
+
+	current := make([]string, 0, len(c.Aggregators))
+	for _, aggregator := range c.Aggregators {
+		current = append(current, aggregator.ID())
+	}
+	previousIDs := make([]string, 0, len(previous.Aggregators))
+	for _, aggregator := range previous.Aggregators {
+		previousIDs = append(previousIDs, aggregator.ID())
+	}
+	if !slices.Equal(current, previousIDs) {
+		return "aggregators changed"
+	}
+
+	return ""
+}
+
+// DiscardPlugins releases the given inputs and outputs not part of the
+// configuration. This must be called for the plugins of a configuration not
+// applied to the agent, e.g. on a failed reload, to close the output buffers
+// and to remove the statistics of the newly created instances.
+func (c *Config) DiscardPlugins(inputs []*models.RunningInput, outputs []*models.RunningOutput) {
+	ids := make(map[string]bool, len(c.Inputs)+len(c.Outputs))
+	for _, input := range c.Inputs {
+		ids[input.Config.ID] = true
+	}
+	for _, output := range c.Outputs {
+		ids[output.Config.ID] = true
+	}
+
+	for _, input := range inputs {
+		if slices.Contains(c.Inputs, input) || ids[input.Config.ID] {
+			// Statistics are shared with the running instance of the same ID
+			continue
+		}
+		input.Discard()
+	}
+	for _, output := range outputs {
+		if slices.Contains(c.Outputs, output) {
+			continue
+		}
+		if ids[output.Config.ID] {
+			// Statistics are shared with the running instance of the same ID
+			output.Close()
+			continue
+		}
+		output.Discard()
+	}
+}
+
+// reusableInput returns a running input of the previous configuration with
+// the given ID or nil if there is none left.
+func (c *Config) reusableInput(id string) *models.RunningInput {
+	candidates := c.reusableInputs[id]
+	if len(candidates) == 0 {
+		return nil
+	}
+	c.reusableInputs[id] = candidates[1:]
+	return candidates[0]
+}
+
+// reusableOutput returns a running output of the previous configuration with
+// the given ID or nil if there is none left.
+func (c *Config) reusableOutput(id string) *models.RunningOutput {
+	candidates := c.reusableOutputs[id]
+	if len(candidates) == 0 {
+		return nil
+	}
+	c.reusableOutputs[id] = candidates[1:]
+	return candidates[0]
+}
+
+func processorIDs(processors models.RunningProcessors) []string {
+	ids := make([]string, 0, len(processors))
+	for _, processor := range processors {
+		ids = append(ids, processor.ID())
+	}
+	return ids
+}
+
+// equalAgentConfig compares the agent settings ignoring defaults set by the
+// running agent.
+func equalAgentConfig(a, b *AgentConfig) bool {
+	x, y := *a, *b
+	if x.SkipProcessorsAfterAggregators == nil {
+		x.SkipProcessorsAfterAggregators = new(bool)
+	}
+	if y.SkipProcessorsAfterAggregators == nil {
+		y.SkipProcessorsAfterAggregators = new(bool)
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -26,6 +26,10 @@ type Process struct {
 	StopOnError  bool
 	Log          telegraf.Logger
 
+	// EnvironmentFn returns additional environment variables and is called
+	// on every (re)start of the process
+	EnvironmentFn func() []string
+
 	name       string
 	args       []string
 	envs       []string
@@ -105,8 +109,12 @@ func (p *Process) State() (state *os.ProcessState, running bool) {
 func (p *Process) cmdStart() error {
 	p.Cmd = exec.Command(p.name, p.args...)
 
-	if len(p.envs) > 0 {
-		p.Cmd.Env = append(os.Environ(), p.envs...)
+	envs := p.envs
+	if p.EnvironmentFn != nil {
+		envs = append(envs[:len(envs):len(envs)], p.EnvironmentFn()...)
+	}
+	if len(envs) > 0 {
+		p.Cmd.Env = append(os.Environ(), envs...)
 	}
 
 	var err error
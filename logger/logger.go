@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -28,7 +29,7 @@ type sink interface {
 
 // logger is the actual implementation of the telegraf logger interface
 type logger struct {
-	level    *telegraf.LogLevel
+	level    atomic.Pointer[telegraf.LogLevel]
 	category string
 	name     string
 	alias    string
@@ -72,8 +73,8 @@ func New(category, name, alias string) *logger {
 
 // Level returns the current log-level of the logger
 func (l *logger) Level() telegraf.LogLevel {
-	if l.level != nil {
-		return *l.level
+	if level := l.level.Load(); level != nil {
+		return *level
 	}
 	return instance.level
 }
@@ -143,7 +144,7 @@ func (l *logger) Print(level telegraf.LogLevel, ts time.Time, args ...interface{
 	}
 
 	// Skip all messages with insufficient log-levels
-	if l.level != nil && !l.level.Includes(level) || l.level == nil && !instance.level.Includes(level) {
+	if !l.Level().Includes(level) {
 		return
 	}
 	if instance.impl != nil {
@@ -156,7 +157,7 @@ func (l *logger) Print(level telegraf.LogLevel, ts time.Time, args ...interface{
 
 // SetLevel overrides the current log-level of the logger
 func (l *logger) SetLevel(level telegraf.LogLevel) {
-	l.level = &level
+	l.level.Store(&level)
 }
 
 // SetLevel changes the log-level to the given one
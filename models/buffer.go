@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
@@ -92,10 +93,14 @@ type BufferStats struct {
 	MetricsDropped  selfstat.Stat
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
+
+	// Handler called for dropped metrics, shared by all copies of the stats
+	dropHandler *atomic.Pointer[func(telegraf.Metric)]
 }
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name, id, alias string, capacity int, strategy, path string) (Buffer, error) {
+// NewBuffer returns a new empty Buffer with the given capacity. The limits
+// only apply to buffer strategies storing metrics on disk.
+func NewBuffer(name, id, alias string, capacity int, strategy, path string, limits DiskBufferLimits) (Buffer, error) {
 	registerGob()
 
 	tags := map[string]string{
@@ -111,7 +116,9 @@ func NewBuffer(name, id, alias string, capacity int, strategy, path string) (Buf
 	case "", "memory":
 		return NewMemoryBuffer(capacity, bs)
 	case "disk_write_through":
-		return NewDiskBuffer(id, path, bs)
+		return NewDiskBuffer(id, path, limits, bs)
+	case "memory_with_disk_overflow":
+		return NewHybridBuffer(id, path, capacity, limits, bs)
 	}
 	return nil, fmt.Errorf("invalid buffer strategy %q", strategy)
 }
@@ -151,9 +158,19 @@ func NewBufferStats(tags map[string]string, capacity int) BufferStats {
 	}
 	bs.BufferSize.Set(int64(0))
 	bs.BufferLimit.Set(int64(capacity))
+	bs.dropHandler = &atomic.Pointer[func(telegraf.Metric)]{}
 	return bs
 }
 
+// SetDropHandler sets a function called for each metric dropped from the
+// buffer before the metric is released. The function is called while the
+// buffer is locked.
+func (b BufferStats) SetDropHandler(f func(telegraf.Metric)) {
+	if b.dropHandler != nil {
+		b.dropHandler.Store(&f)
+	}
+}
+
 func (b *BufferStats) metricAdded() {
 	b.MetricsAdded.Incr(1)
 }
@@ -173,5 +190,10 @@ func (b *BufferStats) metricRejected(m telegraf.Metric) {
 func (b *BufferStats) metricDropped(m telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
+	if b.dropHandler != nil {
+		if f := b.dropHandler.Load(); f != nil {
+			(*f)(m)
+		}
+	}
 	m.Reject()
 }
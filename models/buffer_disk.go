@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log"
@@ -8,14 +9,34 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/tidwall/wal"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
+// DiskBufferLimits bounds the metrics kept on disk. When a limit is exceeded
+// the oldest metrics are dropped. Zero values disable the respective limit.
+type DiskBufferLimits struct {
+	// MaxSize is the maximum size of the WAL segments in bytes. Metrics are
+	// dropped segment by segment starting with the oldest one.
+	MaxSize int64
+	// MaxMetrics is the maximum number of metrics on disk.
+	MaxMetrics int
+	// MaxAge is the maximum time since a segment was last written to before
+	// the metrics of the segment are dropped.
+	MaxAge time.Duration
+}
+
+// Maximum fraction of the size limit used for a single WAL segment to avoid
+// dropping too many metrics at once
+const diskSegmentsPerSizeLimit = 10
+
 type DiskBuffer struct {
 	BufferStats
 	sync.Mutex
@@ -23,6 +44,21 @@ type DiskBuffer struct {
 	file *wal.Log
 	path string
 
+	limits      DiskBufferLimits
+	segmentSize int
+	diskSize    selfstat.Stat
+
+	// Segment files of the WAL and their total size, tracked on writes and
+	// truncation to avoid scanning the directory on every operation. Only
+	// tracked if a size or age limit is set.
+	trackSegments bool
+	segments      []walSegment
+	diskBytes     int64
+
+	// Whether a batch is in flight. Limits are not enforced while a batch is
+	// in flight as truncating the file invalidates the batch offsets.
+	inFlight bool
+
 	batchFirst uint64 // Index of the first metric in the batch
 	batchSize  uint64 // Number of metrics currently in the batch
 
@@ -41,21 +77,40 @@ type DiskBuffer struct {
 	mask []int
 }
 
-func NewDiskBuffer(id, path string, stats BufferStats) (*DiskBuffer, error) {
+func NewDiskBuffer(id, path string, limits DiskBufferLimits, stats BufferStats) (*DiskBuffer, error) {
+	opts := *wal.DefaultOptions
+	if limits.MaxSize > 0 {
+		opts.SegmentSize = int(min(int64(opts.SegmentSize), max(limits.MaxSize/diskSegmentsPerSizeLimit, 1)))
+	}
+
 	filePath := filepath.Join(path, id)
-	walFile, err := wal.Open(filePath, nil)
+	walFile, err := wal.Open(filePath, &opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open wal file: %w", err)
 	}
 
 	buf := &DiskBuffer{
-		BufferStats: stats,
-		file:        walFile,
-		path:        filePath,
+		BufferStats:   stats,
+		file:          walFile,
+		path:          filePath,
+		limits:        limits,
+		segmentSize:   opts.SegmentSize,
+		trackSegments: limits.MaxSize > 0 || limits.MaxAge > 0,
+	}
+	if buf.trackSegments {
+		buf.diskSize = selfstat.Register("write", "buffer_disk_bytes", stats.BufferSize.Tags())
+		buf.readSegments()
 	}
 	if buf.Len() > 0 {
 		buf.originalEnd = buf.writeIndex()
 	}
+
+	// Apply the limits to the metrics left over from a previous instance
+	buf.Lock()
+	buf.enforceLimits()
+	buf.BufferSize.Set(int64(buf.length()))
+	buf.Unlock()
+
 	return buf, nil
 }
 
@@ -110,11 +165,22 @@ func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
 		// as soon as a new metric is added, if this was empty, try to flush the "empty" metric out
 		b.handleEmptyFile()
 	}
+	if !b.inFlight {
+		dropped += b.enforceLimits()
+	}
 	b.BufferSize.Set(int64(b.length()))
 	return dropped
 }
 
 func (b *DiskBuffer) addSingleMetric(m telegraf.Metric) bool {
+	if !b.writeMetric(m) {
+		return false
+	}
+	b.metricAdded()
+	return true
+}
+
+func (b *DiskBuffer) writeMetric(m telegraf.Metric) bool {
 	data, err := metric.ToBytes(m)
 	if err != nil {
 		panic(err)
@@ -122,14 +188,55 @@ func (b *DiskBuffer) addSingleMetric(m telegraf.Metric) bool {
 	if err := b.file.Write(b.writeIndex(), data); err != nil {
 		return false
 	}
-	b.metricAdded()
+	if !b.trackSegments {
+		return true
+	}
+
+	// The WAL starts a new segment once the current one reaches the segment
+	// size, so re-read the segments in this case
+	if len(b.segments) == 0 {
+		b.readSegments()
+		return true
+	}
+	size := entrySize(data)
+	last := &b.segments[len(b.segments)-1]
+	last.size += size
+	last.modified = time.Now()
+	b.diskBytes += size
+	if last.size >= int64(b.segmentSize) {
+		b.readSegments()
+	}
+	b.diskSize.Set(b.diskBytes)
 	return true
 }
 
+// spill appends metrics already counted as added by another buffer, e.g. when
+// overflowing from memory, and returns the number of dropped metrics.
+func (b *DiskBuffer) spill(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if !b.writeMetric(m) {
+			b.metricDropped(m)
+			dropped++
+		}
+		b.handleEmptyFile()
+	}
+	if !b.inFlight {
+		dropped += b.enforceLimits()
+	}
+	return dropped
+}
+
 func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 	b.Lock()
 	defer b.Unlock()
 
+	b.enforceLimits()
+	b.BufferSize.Set(int64(b.length()))
+
 	if b.length() == 0 {
 		return &Transaction{}
 	}
@@ -185,6 +292,7 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 		b.batchSize++
 		batchSize--
 	}
+	b.inFlight = len(metrics) > 0
 	return &Transaction{Batch: metrics, valid: true, state: offsets}
 }
 
@@ -205,6 +313,8 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 	b.Lock()
 	defer b.Unlock()
 
+	b.inFlight = false
+
 	// Mark metrics which should be removed in the internal mask
 	remove := make([]int, 0, len(tx.Accept)+len(tx.Reject))
 	for _, idx := range tx.Accept {
@@ -247,7 +357,7 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 		// item to not throw an error
 		removeIdx--
 	}
-	if err := b.file.TruncateFront(b.batchFirst + uint64(removeIdx)); err != nil {
+	if err := b.truncateFront(b.batchFirst + uint64(removeIdx)); err != nil {
 		log.Printf("E! batch length: %d, first: %d, size: %d", len(tx.Batch), b.batchFirst, b.batchSize)
 		panic(err)
 	}
@@ -288,6 +398,204 @@ func (b *DiskBuffer) Close() error {
 	return nil
 }
 
+// enforceLimits drops the oldest metrics exceeding the configured limits and
+// returns the number of dropped metrics. It must not be called while a batch
+// is in flight.
+func (b *DiskBuffer) enforceLimits() int {
+	if b.limits == (DiskBufferLimits{}) || b.length() == 0 {
+		return 0
+	}
+
+	var dropped int
+	if b.limits.MaxSize > 0 || b.limits.MaxAge > 0 {
+		// Work on a copy as dropping entries modifies the tracked segments
+		segments := slices.Clone(b.segments)
+		size := b.diskBytes
+
+		// Drop whole segments starting with the oldest one while the size
+		// limit is exceeded or the segment is too old. The last segment is
+		// still written to so it can only be dropped by age.
+		for len(segments) > 0 {
+			var next uint64
+			if len(segments) > 1 {
+				next = segments[1].index
+			} else {
+				next = b.writeIndex()
+			}
+
+			tooLarge := b.limits.MaxSize > 0 && size > b.limits.MaxSize && len(segments) > 1
+			tooOld := b.limits.MaxAge > 0 && time.Since(segments[0].modified) > b.limits.MaxAge
+			if !tooLarge && !tooOld {
+				break
+			}
+
+			dropped += b.dropFront(int(next - b.readIndex()))
+			size -= segments[0].size
+			segments = segments[1:]
+		}
+	}
+
+	if b.limits.MaxMetrics > 0 && b.length() > b.limits.MaxMetrics {
+		// Skip masked entries as they are not counted as metrics
+		excess := b.length() - b.limits.MaxMetrics
+		var n int
+		for excess > 0 {
+			if !slices.Contains(b.mask, n) {
+				excess--
+			}
+			n++
+		}
+		dropped += b.dropFront(n)
+	}
+
+	return dropped
+}
+
+// dropFront removes the given number of entries from the front of the WAL file
+// and returns the number of dropped metrics. Masked entries are not counted as
+// they were already removed.
+func (b *DiskBuffer) dropFront(n int) int {
+	entries := b.entries()
+	if b.isEmpty || n <= 0 || entries == 0 {
+		return 0
+	}
+	n = min(n, entries)
+
+	first := b.readIndex()
+	var dropped int
+	for offset := 0; offset < n; offset++ {
+		if slices.Contains(b.mask, offset) {
+			continue
+		}
+		dropped++
+
+		data, err := b.file.Read(first + uint64(offset))
+		if err != nil {
+			panic(err) // can only occur with a corrupt wal file
+		}
+		m, err := metric.FromBytes(data)
+		if err != nil {
+			// The tracking information of metrics from a previous instance
+			// is gone so there is nothing to reject
+			AgentMetricsDropped.Incr(1)
+			b.MetricsDropped.Incr(1)
+			continue
+		}
+		b.metricDropped(m)
+	}
+
+	// WAL files cannot be fully empty but need to contain at least one item,
+	// so keep the last entry masked if all entries are dropped
+	remove := n
+	b.isEmpty = entries-n <= 0
+	if b.isEmpty {
+		remove--
+	}
+	if err := b.truncateFront(first + uint64(remove)); err != nil {
+		log.Printf("E! readIndex: %d, entries: %d, remove: %d", first, entries, remove)
+		panic(err)
+	}
+
+	// Update the relative offsets of the remaining masked entries
+	mask := make([]int, 0, len(b.mask))
+	for _, offset := range b.mask {
+		if offset >= n {
+			mask = append(mask, offset-remove)
+		}
+	}
+	if b.isEmpty {
+		mask = append(mask, 0)
+	}
+	b.mask = mask
+
+	if b.originalEnd < b.readIndex() {
+		b.originalEnd = 0
+	}
+
+	return dropped
+}
+
+// walSegment describes a segment file of the WAL
+type walSegment struct {
+	index    uint64
+	size     int64
+	modified time.Time
+}
+
+// readSegments reads the segment files of the WAL ordered by their first
+// index and updates the disk size
+func (b *DiskBuffer) readSegments() {
+	entries, err := os.ReadDir(b.path)
+	if err != nil {
+		log.Printf("E! Reading buffer directory failed: %v", err)
+		return
+	}
+
+	// Segment files are named by the zero-padded index of their first entry
+	segments := make([]walSegment, 0, len(entries))
+	var size int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || len(name) != 20 {
+			continue
+		}
+		index, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		segments = append(segments, walSegment{index: index, size: info.Size(), modified: info.ModTime()})
+		size += info.Size()
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].index < segments[j].index })
+
+	b.segments = segments
+	b.diskBytes = size
+	b.diskSize.Set(size)
+}
+
+// truncateFront removes all entries before the given index from the WAL and
+// updates the tracked segments. Segments in front are removed as a whole while
+// the remaining entries of the first segment are rewritten by the WAL to a new
+// file named by the given index.
+func (b *DiskBuffer) truncateFront(index uint64) error {
+	if err := b.file.TruncateFront(index); err != nil {
+		return err
+	}
+	if !b.trackSegments {
+		return nil
+	}
+
+	for len(b.segments) > 1 && b.segments[1].index <= index {
+		b.diskBytes -= b.segments[0].size
+		b.segments = b.segments[1:]
+	}
+	if len(b.segments) > 0 {
+		info, err := os.Stat(filepath.Join(b.path, fmt.Sprintf("%020d", index)))
+		if err != nil {
+			// Fall back to scanning the directory
+			b.readSegments()
+			return nil
+		}
+		first := &b.segments[0]
+		b.diskBytes += info.Size() - first.size
+		first.index = index
+		first.size = info.Size()
+	}
+	b.diskSize.Set(b.diskBytes)
+	return nil
+}
+
+// entrySize returns the size of the given entry in the WAL file consisting of
+// the length of the data as uvarint followed by the data
+func entrySize(data []byte) int64 {
+	var buf [binary.MaxVarintLen64]byte
+	return int64(binary.PutUvarint(buf[:], uint64(len(data))) + len(data))
+}
+
 func (b *DiskBuffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchSize = 0
@@ -301,7 +609,7 @@ func (b *DiskBuffer) handleEmptyFile() {
 	if !b.isEmpty {
 		return
 	}
-	if err := b.file.TruncateFront(b.readIndex() + 1); err != nil {
+	if err := b.truncateFront(b.readIndex() + 1); err != nil {
 		log.Printf("E! readIndex: %d, buffer len: %d", b.readIndex(), b.length())
 		panic(err)
 	}
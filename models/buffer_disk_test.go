@@ -1,6 +1,7 @@
 package models
 
 import (
+	"os"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -18,7 +19,7 @@ import (
 // https://github.com/influxdata/telegraf/issues/16696
 func TestDiskBufferTruncate(t *testing.T) {
 	// Create a disk buffer
-	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir())
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), DiskBufferLimits{})
 	require.NoError(t, err)
 	defer buf.Close()
 	diskBuf, ok := buf.(*DiskBuffer)
@@ -78,7 +79,7 @@ func TestDiskBufferTruncate(t *testing.T) {
 // https://github.com/influxdata/telegraf/issues/16981
 func TestDiskBufferEmptyReuse(t *testing.T) {
 	// Create a disk buffer
-	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir())
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), DiskBufferLimits{})
 	require.NoError(t, err)
 	defer buf.Close()
 	diskBuf, ok := buf.(*DiskBuffer)
@@ -131,7 +132,7 @@ func TestDiskBufferEmptyClose(t *testing.T) {
 	tmpdir := t.TempDir()
 
 	// Create a disk buffer
-	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", tmpdir)
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", tmpdir, DiskBufferLimits{})
 	require.NoError(t, err)
 	defer buf.Close()
 	diskBuf, ok := buf.(*DiskBuffer)
@@ -159,7 +160,7 @@ func TestDiskBufferEmptyClose(t *testing.T) {
 	require.NoError(t, diskBuf.Close())
 
 	// Reopen the buffer with the parameters above to see the same buffer
-	reopened, err := NewBuffer("test", "id123", "", 0, "disk_write_through", tmpdir)
+	reopened, err := NewBuffer("test", "id123", "", 0, "disk_write_through", tmpdir, DiskBufferLimits{})
 	require.NoError(t, err)
 	defer reopened.Close()
 	_, ok = reopened.(*DiskBuffer)
@@ -188,7 +189,7 @@ func TestDiskBufferRetainsTrackingInformation(t *testing.T) {
 	var delivered int
 	mm, _ := metric.WithTracking(m, func(telegraf.DeliveryInfo) { delivered++ })
 
-	buf, err := NewBuffer("test", "123", "", 0, "disk_write_through", t.TempDir())
+	buf, err := NewBuffer("test", "123", "", 0, "disk_write_through", t.TempDir(), DiskBufferLimits{})
 	require.NoError(t, err)
 	buf.Stats().MetricsAdded.Set(0)
 	buf.Stats().MetricsWritten.Set(0)
@@ -247,7 +248,7 @@ func TestDiskBufferTrackingDroppedFromOldWal(t *testing.T) {
 	walfile.Close()
 
 	// Create a buffer
-	buf, err := NewBuffer("123", "123", "", 0, "disk_write_through", path)
+	buf, err := NewBuffer("123", "123", "", 0, "disk_write_through", path, DiskBufferLimits{})
 	require.NoError(t, err)
 	buf.Stats().MetricsAdded.Set(0)
 	buf.Stats().MetricsWritten.Set(0)
@@ -302,7 +303,7 @@ func TestDiskBufferTrackingOnOutputOutage(t *testing.T) {
 	}
 
 	// Create a disk buffer
-	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir())
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), DiskBufferLimits{})
 	require.NoError(t, err)
 	defer buf.Close()
 	diskBuf, ok := buf.(*DiskBuffer)
@@ -363,3 +364,151 @@ func TestDiskBufferTrackingOnOutputOutage(t *testing.T) {
 	defer mu.Unlock()
 	require.ElementsMatch(t, created, delivered, "tracking information mismatch")
 }
+
+func TestDiskBufferMaxMetrics(t *testing.T) {
+	limits := DiskBufferLimits{MaxMetrics: 5}
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), limits)
+	require.NoError(t, err)
+	defer buf.Close()
+	resetBufferStats(buf)
+
+	expected := make([]telegraf.Metric, 0, 10)
+	for i := range 10 {
+		expected = append(expected, metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0)))
+	}
+
+	// The oldest metrics are dropped when exceeding the limit
+	require.Equal(t, 3, buf.Add(expected[:8]...))
+	require.Equal(t, 5, buf.Len())
+	require.Equal(t, int64(3), buf.Stats().MetricsDropped.Get())
+
+	// The limit is not enforced while a batch is in flight...
+	tx := buf.BeginTransaction(2)
+	testutil.RequireMetricsEqual(t, expected[3:5], tx.Batch)
+	require.Zero(t, buf.Add(expected[8:]...))
+	require.Equal(t, 7, buf.Len())
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	// ...but before the next batch is started
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[5:], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Equal(t, int64(3), buf.Stats().MetricsDropped.Get())
+	require.Equal(t, int64(7), buf.Stats().MetricsWritten.Get())
+}
+
+func TestDiskBufferMaxSize(t *testing.T) {
+	limits := DiskBufferLimits{MaxSize: 4096}
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), limits)
+	require.NoError(t, err)
+	defer buf.Close()
+	diskBuf, ok := buf.(*DiskBuffer)
+	require.True(t, ok, "buffer is not a disk buffer")
+	resetBufferStats(buf)
+
+	expected := make([]telegraf.Metric, 0, 200)
+	for i := range 200 {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0))
+		expected = append(expected, m)
+		buf.Add(m)
+	}
+
+	// Whole segments are dropped to stay within the size limit
+	require.LessOrEqual(t, diskBuf.diskSize.Get(), limits.MaxSize)
+	require.Positive(t, diskBuf.diskSize.Get())
+	require.Greater(t, len(diskBuf.segments), 1)
+	dropped := int(buf.Stats().MetricsDropped.Get())
+	require.Positive(t, dropped)
+	require.Equal(t, len(expected)-dropped, buf.Len())
+
+	// The newest metrics are kept
+	tx := buf.BeginTransaction(len(expected))
+	testutil.RequireMetricsEqual(t, expected[dropped:], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+}
+
+func TestDiskBufferMaxAge(t *testing.T) {
+	limits := DiskBufferLimits{MaxAge: time.Hour}
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), limits)
+	require.NoError(t, err)
+	defer buf.Close()
+	diskBuf, ok := buf.(*DiskBuffer)
+	require.True(t, ok, "buffer is not a disk buffer")
+	resetBufferStats(buf)
+
+	for i := range 5 {
+		buf.Add(metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0)))
+	}
+	require.Equal(t, 5, buf.Len())
+
+	// Age the segments beyond the limit
+	past := time.Now().Add(-2 * time.Hour)
+	for i := range diskBuf.segments {
+		diskBuf.segments[i].modified = past
+	}
+
+	tx := buf.BeginTransaction(10)
+	require.Empty(t, tx.Batch)
+	require.Equal(t, 0, buf.Len())
+	require.Equal(t, int64(5), buf.Stats().MetricsDropped.Get())
+
+	// The buffer is usable after dropping all metrics
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(42, 0))
+	buf.Add(m)
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+}
+
+func TestDiskBufferTrackedSize(t *testing.T) {
+	limits := DiskBufferLimits{MaxSize: 4096}
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), limits)
+	require.NoError(t, err)
+	defer buf.Close()
+	diskBuf, ok := buf.(*DiskBuffer)
+	require.True(t, ok, "buffer is not a disk buffer")
+
+	for i := range 100 {
+		buf.Add(metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0)))
+		if i%10 == 0 {
+			tx := buf.BeginTransaction(3)
+			tx.AcceptAll()
+			buf.EndTransaction(tx)
+		}
+	}
+
+	// The incrementally tracked size must match the files on disk
+	var size int64
+	entries, err := os.ReadDir(diskBuf.path)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	require.Equal(t, size, diskBuf.diskSize.Get())
+}
+
+func TestDiskBufferUntrackedSize(t *testing.T) {
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", t.TempDir(), DiskBufferLimits{MaxMetrics: 10})
+	require.NoError(t, err)
+	defer buf.Close()
+	diskBuf, ok := buf.(*DiskBuffer)
+	require.True(t, ok, "buffer is not a disk buffer")
+
+	// Without size or age limits the segments are not tracked
+	for i := range 20 {
+		buf.Add(metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0)))
+	}
+	tx := buf.BeginTransaction(3)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	require.Equal(t, 7, buf.Len())
+	require.Nil(t, diskBuf.diskSize)
+	require.Empty(t, diskBuf.segments)
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// HybridBuffer keeps metrics in memory and spills the oldest metrics to disk
+// once the memory is full or the buffer is closed. Metrics on disk are always
+// older than the ones in memory so transactions drain the disk first.
+type HybridBuffer struct {
+	sync.Mutex
+	BufferStats
+
+	mem  *MemoryBuffer
+	disk *DiskBuffer
+
+	// Metrics spilled while a batch from memory is in flight. Metrics kept
+	// from the batch are older so the spilled metrics are written to disk
+	// after the kept ones once the transaction ends.
+	pending     []telegraf.Metric
+	memoryBatch bool
+}
+
+type hybridTransaction struct {
+	tx     *Transaction
+	onDisk bool
+}
+
+func NewHybridBuffer(id, path string, capacity int, limits DiskBufferLimits, stats BufferStats) (*HybridBuffer, error) {
+	mem, err := NewMemoryBuffer(capacity, stats)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := NewDiskBuffer(id, path, limits, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &HybridBuffer{
+		BufferStats: stats,
+		mem:         mem,
+		disk:        disk,
+	}
+	buf.BufferSize.Set(int64(buf.length()))
+	return buf, nil
+}
+
+func (b *HybridBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *HybridBuffer) length() int {
+	return b.disk.Len() + len(b.pending) + b.mem.Len()
+}
+
+func (b *HybridBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if !b.memoryFull() {
+			dropped += b.mem.Add(m)
+			continue
+		}
+
+		// Make room by spilling the oldest metric in memory. If all metrics
+		// in memory are part of the current batch the new metric is the
+		// oldest one not in flight.
+		if oldest := b.mem.removeOldest(); oldest != nil {
+			dropped += b.spill(oldest)
+			dropped += b.mem.Add(m)
+		} else {
+			dropped += b.spill(m)
+			b.metricAdded()
+		}
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+	return dropped
+}
+
+func (b *HybridBuffer) BeginTransaction(batchSize int) *Transaction {
+	b.Lock()
+	defer b.Unlock()
+
+	// Drain the disk first as it contains the oldest metrics. The disk buffer
+	// shares the statistics and sets the buffer size to its own length, so
+	// restore the size of the whole buffer.
+	if b.disk.Len() > 0 {
+		tx := b.disk.BeginTransaction(batchSize)
+		b.BufferSize.Set(int64(b.length()))
+		if len(tx.Batch) > 0 {
+			return &Transaction{Batch: tx.Batch, valid: true, state: &hybridTransaction{tx: tx, onDisk: true}}
+		}
+	}
+
+	tx := b.mem.BeginTransaction(batchSize)
+	if len(tx.Batch) == 0 {
+		return &Transaction{}
+	}
+	b.memoryBatch = true
+	return &Transaction{Batch: tx.Batch, valid: true, state: &hybridTransaction{tx: tx}}
+}
+
+func (b *HybridBuffer) EndTransaction(tx *Transaction) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Ignore invalid transactions and make sure they can only be finished once
+	if !tx.valid {
+		return
+	}
+	tx.valid = false
+
+	state := tx.state.(*hybridTransaction)
+	state.tx.Accept = tx.Accept
+	state.tx.Reject = tx.Reject
+
+	if state.onDisk {
+		b.disk.EndTransaction(state.tx)
+		b.BufferSize.Set(int64(b.length()))
+		return
+	}
+
+	b.memoryBatch = false
+	if len(b.pending) == 0 {
+		b.mem.EndTransaction(state.tx)
+		b.BufferSize.Set(int64(b.length()))
+		return
+	}
+
+	// Metrics were spilled during the transaction, so the kept metrics cannot
+	// be restored to memory without breaking the ordering. Spill them to disk
+	// in front of the pending metrics instead.
+	keep := b.mem.endTransactionWithoutRestore(state.tx)
+	metrics := make([]telegraf.Metric, 0, len(keep)+len(b.pending))
+	for _, idx := range keep {
+		metrics = append(metrics, tx.Batch[idx])
+	}
+	metrics = append(metrics, b.pending...)
+	b.pending = nil
+
+	b.disk.spill(metrics...)
+	b.BufferSize.Set(int64(b.length()))
+}
+
+func (b *HybridBuffer) Stats() BufferStats {
+	return b.BufferStats
+}
+
+// Close persists all metrics in memory to disk before closing the disk buffer
+// so they are written first after a restart.
+func (b *HybridBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.disk.spill(b.pending...)
+	b.disk.spill(b.mem.removeAll()...)
+	b.pending = nil
+	return b.disk.Close()
+}
+
+// memoryFull returns true if the memory cannot take another metric without
+// dropping, accounting for the metrics of an in-flight batch.
+func (b *HybridBuffer) memoryFull() bool {
+	b.mem.Lock()
+	defer b.mem.Unlock()
+
+	return b.mem.size+b.mem.batchSize >= b.mem.cap
+}
+
+// spill writes a metric to disk or queues it while a batch from memory is in
+// flight, and returns the number of dropped metrics
+func (b *HybridBuffer) spill(m telegraf.Metric) int {
+	if b.memoryBatch {
+		b.pending = append(b.pending, m)
+		return 0
+	}
+	return b.disk.spill(m)
+}
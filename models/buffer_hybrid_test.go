@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestHybridBufferOverflowPreservesOrder(t *testing.T) {
+	buf, err := NewBuffer("test", "id123", "", 3, "memory_with_disk_overflow", t.TempDir(), DiskBufferLimits{})
+	require.NoError(t, err)
+	defer buf.Close()
+	hybridBuf, ok := buf.(*HybridBuffer)
+	require.True(t, ok, "buffer is not a hybrid buffer")
+	resetBufferStats(buf)
+
+	expected := newHybridTestMetrics(5)
+	require.Zero(t, buf.Add(expected...))
+	require.Equal(t, 5, buf.Len())
+
+	// The two oldest metrics overflow to disk
+	require.Equal(t, 2, hybridBuf.disk.Len())
+	require.Equal(t, 3, hybridBuf.mem.Len())
+
+	// The disk is drained first
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[:2], tx.Batch)
+	require.Equal(t, int64(5), buf.Stats().BufferSize.Get())
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[2:], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	require.Equal(t, 0, buf.Len())
+	require.Equal(t, int64(5), buf.Stats().MetricsAdded.Get())
+	require.Equal(t, int64(5), buf.Stats().MetricsWritten.Get())
+	require.Equal(t, int64(0), buf.Stats().MetricsDropped.Get())
+	require.Equal(t, int64(0), buf.Stats().BufferSize.Get())
+}
+
+func TestHybridBufferOverflowDuringBatch(t *testing.T) {
+	buf, err := NewBuffer("test", "id123", "", 2, "memory_with_disk_overflow", t.TempDir(), DiskBufferLimits{})
+	require.NoError(t, err)
+	defer buf.Close()
+	resetBufferStats(buf)
+
+	expected := newHybridTestMetrics(5)
+	buf.Add(expected[:2]...)
+
+	// Fill the buffer while the batch is in flight
+	tx := buf.BeginTransaction(2)
+	testutil.RequireMetricsEqual(t, expected[:2], tx.Batch)
+	buf.Add(expected[2:]...)
+	require.Equal(t, 5, buf.Len())
+	require.Equal(t, int64(5), buf.Stats().BufferSize.Get())
+
+	// The kept batch must be written before the metrics added in the meantime
+	tx.KeepAll()
+	buf.EndTransaction(tx)
+	require.Equal(t, 5, buf.Len())
+
+	actual := make([]telegraf.Metric, 0, len(expected))
+	for buf.Len() > 0 {
+		tx := buf.BeginTransaction(2)
+		actual = append(actual, tx.Batch...)
+		tx.AcceptAll()
+		buf.EndTransaction(tx)
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+	require.Equal(t, int64(5), buf.Stats().MetricsAdded.Get())
+	require.Equal(t, int64(5), buf.Stats().MetricsWritten.Get())
+}
+
+func TestHybridBufferPersistOnClose(t *testing.T) {
+	path := t.TempDir()
+
+	buf, err := NewBuffer("test", "id123", "", 5, "memory_with_disk_overflow", path, DiskBufferLimits{})
+	require.NoError(t, err)
+
+	expected := newHybridTestMetrics(3)
+	buf.Add(expected...)
+	require.NoError(t, buf.Close())
+
+	// Metrics in memory are restored from disk after a restart
+	buf, err = NewBuffer("test", "id123", "", 5, "memory_with_disk_overflow", path, DiskBufferLimits{})
+	require.NoError(t, err)
+	defer buf.Close()
+	require.Equal(t, 3, buf.Len())
+	require.Equal(t, int64(3), buf.Stats().BufferSize.Get())
+
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Equal(t, 0, buf.Len())
+}
+
+func newHybridTestMetrics(n int) []telegraf.Metric {
+	metrics := make([]telegraf.Metric, 0, n)
+	for i := range n {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0))
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func resetBufferStats(buf Buffer) {
+	buf.Stats().MetricsAdded.Set(0)
+	buf.Stats().MetricsWritten.Set(0)
+	buf.Stats().MetricsRejected.Set(0)
+	buf.Stats().MetricsDropped.Set(0)
+}
@@ -184,6 +184,61 @@ func (b *MemoryBuffer) prevby(index, count int) int {
 	return index
 }
 
+// removeOldest removes the oldest metric not part of the current batch and
+// returns nil if there is no such metric.
+func (b *MemoryBuffer) removeOldest() telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.size == 0 {
+		return nil
+	}
+
+	m := b.buf[b.first]
+	b.buf[b.first] = nil
+	b.first = b.next(b.first)
+	b.size--
+	return m
+}
+
+// removeAll removes all metrics not part of the current batch in order from
+// oldest to newest.
+func (b *MemoryBuffer) removeAll() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	metrics := make([]telegraf.Metric, 0, b.size)
+	for b.size > 0 {
+		metrics = append(metrics, b.buf[b.first])
+		b.buf[b.first] = nil
+		b.first = b.next(b.first)
+		b.size--
+	}
+	return metrics
+}
+
+// endTransactionWithoutRestore finishes the transaction like EndTransaction
+// but returns the indices of the kept metrics instead of restoring them.
+func (b *MemoryBuffer) endTransactionWithoutRestore(tx *Transaction) []int {
+	b.Lock()
+	defer b.Unlock()
+
+	if !tx.valid {
+		return nil
+	}
+	tx.valid = false
+
+	for _, idx := range tx.Accept {
+		b.metricWritten(tx.Batch[idx])
+	}
+	for _, idx := range tx.Reject {
+		b.metricRejected(tx.Batch[idx])
+	}
+
+	b.resetBatch()
+	return tx.InferKeep()
+}
+
 func (b *MemoryBuffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchSize = 0
@@ -0,0 +1,306 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// CardinalityConfig limits the number of distinct series of a plugin.
+type CardinalityConfig struct {
+	// Maximum number of distinct series within the window, zero disables the
+	// limit
+	Limit int
+	// Time after which a series not seen anymore is no longer counted
+	Window time.Duration
+	// Action for metrics of new series exceeding the limit, either "drop",
+	// "strip_tags" or "aggregate"
+	Action string
+	// Tag keys to strip or aggregate, defaults to the tag of the metric with
+	// the most distinct values
+	Tags []string
+}
+
+const (
+	defaultCardinalityWindow = time.Hour
+	maxCardinalityPrune      = 10 * time.Second
+
+	// Value replacing the offending tag values when aggregating series
+	cardinalityOverflowValue = "_overflow"
+)
+
+func (cfg *CardinalityConfig) validate() error {
+	if cfg.Limit < 0 {
+		return errors.New("'cardinality_limit' must not be negative")
+	}
+	if cfg.Window < 0 {
+		return errors.New("'cardinality_window' must not be negative")
+	}
+	switch cfg.Action {
+	case "", "drop", "strip_tags", "aggregate":
+	default:
+		return fmt.Errorf("invalid 'cardinality_action' setting %q", cfg.Action)
+	}
+	return nil
+}
+
+// cardinalitySeries is a series seen within the window
+type cardinalitySeries struct {
+	name     string
+	tags     []*telegraf.Tag
+	lastSeen time.Time
+}
+
+// cardinalityGuard tracks the distinct series, identified by the metric's
+// HashID, seen within a sliding window. Metrics of new series exceeding the
+// limit are dropped, stripped of the offending tags or aggregated into an
+// overflow series. All methods are safe to call on a nil guard, which accepts
+// all metrics.
+type cardinalityGuard struct {
+	sync.Mutex
+
+	cfg   CardinalityConfig
+	log   telegraf.Logger
+	now   func() time.Time
+	tags  map[string]string
+	strip *Filter
+	match filter.Filter
+
+	series    map[uint64]*cardinalitySeries
+	names     map[string]int
+	values    map[string]map[string]int
+	lastPrune time.Time
+	exceeded  bool
+	warned    bool
+
+	Series         selfstat.Stat
+	MetricsLimited selfstat.Stat
+	topMeasurement selfstat.Stat
+	topTagKey      selfstat.Stat
+}
+
+func newCardinalityGuard(cfg CardinalityConfig, log telegraf.Logger, tags map[string]string) *cardinalityGuard {
+	if cfg.Limit <= 0 {
+		return nil
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultCardinalityWindow
+	}
+	if cfg.Action == "" {
+		cfg.Action = "drop"
+	}
+
+	return &cardinalityGuard{
+		cfg:            cfg,
+		log:            log,
+		now:            time.Now,
+		tags:           tags,
+		series:         make(map[uint64]*cardinalitySeries),
+		names:          make(map[string]int),
+		values:         make(map[string]map[string]int),
+		Series:         selfstat.Register("cardinality", "series", tags),
+		MetricsLimited: selfstat.Register("cardinality", "metrics_limited", tags),
+	}
+}
+
+// init compiles the configured tag keys
+func (g *cardinalityGuard) init() error {
+	if g == nil || len(g.cfg.Tags) == 0 {
+		return nil
+	}
+
+	g.strip = &Filter{TagExclude: g.cfg.Tags}
+	if err := g.strip.Compile(); err != nil {
+		return fmt.Errorf("compiling 'cardinality_tags' failed: %w", err)
+	}
+	match, err := filter.Compile(g.cfg.Tags)
+	if err != nil {
+		return fmt.Errorf("compiling 'cardinality_tags' failed: %w", err)
+	}
+	g.match = match
+	return nil
+}
+
+// apply checks the series of the given metric against the limit and returns
+// false if the metric must be dropped. The metric might be modified depending
+// on the configured action.
+func (g *cardinalityGuard) apply(m telegraf.Metric) bool {
+	if g == nil {
+		return true
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	now := g.now()
+	g.prune(now)
+
+	if g.accept(m, now, false) {
+		return true
+	}
+
+	if !g.warned {
+		g.log.Warnf("Series limit of %d exceeded, applying action %q to new series", g.cfg.Limit, g.cfg.Action)
+		g.warned = true
+	}
+	g.exceeded = true
+	g.MetricsLimited.Incr(1)
+
+	switch g.cfg.Action {
+	case "strip_tags":
+		if g.strip != nil {
+			g.strip.Modify(m)
+		} else if key := g.offendingTag(m); key != "" {
+			m.RemoveTag(key)
+		}
+		return g.accept(m, now, false)
+	case "aggregate":
+		var keys []string
+		if g.match != nil {
+			for _, tag := range m.TagList() {
+				if g.match.Match(tag.Key) {
+					keys = append(keys, tag.Key)
+				}
+			}
+		} else if key := g.offendingTag(m); key != "" {
+			keys = append(keys, key)
+		}
+		// Drop metrics without any tag to aggregate as the series is unchanged
+		if len(keys) == 0 {
+			return false
+		}
+		for _, key := range keys {
+			m.AddTag(key, cardinalityOverflowValue)
+		}
+		// The overflow series are accepted even when exceeding the limit
+		return g.accept(m, now, true)
+	}
+	return false
+}
+
+// accept returns true if the series of the metric is known or can be added
+// without exceeding the limit.
+func (g *cardinalityGuard) accept(m telegraf.Metric, now time.Time, force bool) bool {
+	id := m.HashID()
+	if s, found := g.series[id]; found {
+		s.lastSeen = now
+		return true
+	}
+	if !force && len(g.series) >= g.cfg.Limit {
+		return false
+	}
+
+	s := &cardinalitySeries{
+		name:     m.Name(),
+		tags:     make([]*telegraf.Tag, 0, len(m.TagList())),
+		lastSeen: now,
+	}
+	for _, tag := range m.TagList() {
+		s.tags = append(s.tags, &telegraf.Tag{Key: tag.Key, Value: tag.Value})
+	}
+	g.series[id] = s
+	g.count(s, 1)
+	g.Series.Set(int64(len(g.series)))
+	return true
+}
+
+// count updates the number of series per measurement and tag value
+func (g *cardinalityGuard) count(s *cardinalitySeries, delta int) {
+	g.names[s.name] += delta
+	if g.names[s.name] <= 0 {
+		delete(g.names, s.name)
+	}
+	for _, tag := range s.tags {
+		values, found := g.values[tag.Key]
+		if !found {
+			values = make(map[string]int)
+			g.values[tag.Key] = values
+		}
+		values[tag.Value] += delta
+		if values[tag.Value] <= 0 {
+			delete(values, tag.Value)
+		}
+		if len(values) == 0 {
+			delete(g.values, tag.Key)
+		}
+	}
+}
+
+// offendingTag returns the tag key of the metric with the most distinct values
+// within the window.
+func (g *cardinalityGuard) offendingTag(m telegraf.Metric) string {
+	var key string
+	var most int
+	for _, tag := range m.TagList() {
+		if n := len(g.values[tag.Key]); n > most {
+			key, most = tag.Key, n
+		}
+	}
+	return key
+}
+
+// prune removes the series not seen within the window and updates the
+// statistics on the offending measurement and tag key.
+func (g *cardinalityGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < min(g.cfg.Window/10, maxCardinalityPrune) {
+		return
+	}
+	g.lastPrune = now
+
+	for id, s := range g.series {
+		if now.Sub(s.lastSeen) > g.cfg.Window {
+			delete(g.series, id)
+			g.count(s, -1)
+		}
+	}
+	g.Series.Set(int64(len(g.series)))
+
+	if !g.exceeded {
+		g.warned = false
+		g.topMeasurement = g.report(g.topMeasurement, "top_measurement_series", "measurement", "", 0)
+		g.topTagKey = g.report(g.topTagKey, "top_tag_key_values", "tag_key", "", 0)
+		return
+	}
+	g.exceeded = false
+
+	var measurement, key string
+	var series, values int
+	for name, n := range g.names {
+		if n > series || (n == series && name < measurement) {
+			measurement, series = name, n
+		}
+	}
+	for k, v := range g.values {
+		if len(v) > values || (len(v) == values && k < key) {
+			key, values = k, len(v)
+		}
+	}
+	g.topMeasurement = g.report(g.topMeasurement, "top_measurement_series", "measurement", measurement, series)
+	g.topTagKey = g.report(g.topTagKey, "top_tag_key_values", "tag_key", key, values)
+}
+
+// report sets the statistic of the top offender replacing the previous one if
+// the offender changed. An empty name removes the statistic.
+func (g *cardinalityGuard) report(stat selfstat.Stat, field, tag, name string, value int) selfstat.Stat {
+	if stat != nil && (name == "" || stat.Tags()[tag] != name) {
+		stat.Unregister()
+		stat = nil
+	}
+	if name == "" {
+		return nil
+	}
+
+	if stat == nil {
+		tags := maps.Clone(g.tags)
+		tags[tag] = name
+		stat = selfstat.Register("cardinality", field, tags)
+	}
+	stat.Set(int64(value))
+	return stat
+}
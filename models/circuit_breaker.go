@@ -0,0 +1,185 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// CircuitBreakerConfig configures the backoff of an output after consecutive
+// write errors.
+type CircuitBreakerConfig struct {
+	// Number of consecutive write errors opening the circuit, zero disables
+	// the circuit breaker
+	Threshold int
+	// Delay before probing the output after the circuit opened, doubled on
+	// each failed probe up to the maximum delay
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Maximum random time added to the delay
+	Jitter time.Duration
+	// Number of metrics written when probing the output
+	ProbeBatchSize int
+}
+
+const (
+	defaultCircuitBreakerMinDelay  = time.Second
+	defaultCircuitBreakerMaxDelay  = 5 * time.Minute
+	defaultCircuitBreakerProbeSize = 1
+)
+
+type circuitState int64
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops writing to an output after consecutive write errors.
+// Once the delay elapsed, a single small batch is written to probe the output
+// before resuming full writes. On failure the circuit opens again with an
+// exponentially increasing delay. All methods are safe to call on a nil
+// breaker, which always allows writing.
+type circuitBreaker struct {
+	sync.Mutex
+
+	cfg CircuitBreakerConfig
+	log telegraf.Logger
+	now func() time.Time
+
+	state     circuitState
+	failures  int
+	backoff   int
+	openUntil time.Time
+
+	State    selfstat.Stat
+	Trips    selfstat.Stat
+	Failures selfstat.Stat
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig, log telegraf.Logger, tags map[string]string) *circuitBreaker {
+	if cfg.Threshold <= 0 {
+		return nil
+	}
+	if cfg.MinDelay == 0 {
+		cfg.MinDelay = defaultCircuitBreakerMinDelay
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = max(defaultCircuitBreakerMaxDelay, cfg.MinDelay)
+	}
+	if cfg.ProbeBatchSize == 0 {
+		cfg.ProbeBatchSize = defaultCircuitBreakerProbeSize
+	}
+
+	return &circuitBreaker{
+		cfg:      cfg,
+		log:      log,
+		now:      time.Now,
+		State:    selfstat.Register("write", "circuit_breaker_state", tags),
+		Trips:    selfstat.Register("write", "circuit_breaker_trips", tags),
+		Failures: selfstat.Register("write", "consecutive_errors", tags),
+	}
+}
+
+func (cfg *CircuitBreakerConfig) validate() error {
+	if cfg.Threshold < 0 {
+		return errors.New("'circuit_breaker_threshold' must not be negative")
+	}
+	if cfg.MinDelay < 0 || cfg.MaxDelay < 0 || cfg.Jitter < 0 {
+		return errors.New("circuit breaker delays must not be negative")
+	}
+	if cfg.MaxDelay > 0 && cfg.MaxDelay < cfg.MinDelay {
+		return errors.New("'circuit_breaker_max_delay' must not be smaller than 'circuit_breaker_min_delay'")
+	}
+	if cfg.ProbeBatchSize < 0 {
+		return errors.New("'circuit_breaker_probe_batch_size' must not be negative")
+	}
+	return nil
+}
+
+// allow returns if writing is allowed and if so the maximum number of metrics
+// to write, zero meaning no limit.
+func (cb *circuitBreaker) allow() (bool, int) {
+	if cb == nil {
+		return true, 0
+	}
+
+	cb.Lock()
+	defer cb.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if cb.now().Before(cb.openUntil) {
+			return false, 0
+		}
+		cb.log.Debug("Circuit half-open, probing output")
+		cb.setState(circuitHalfOpen)
+		return true, cb.cfg.ProbeBatchSize
+	case circuitHalfOpen:
+		return true, cb.cfg.ProbeBatchSize
+	}
+	return true, 0
+}
+
+// success records a successful write closing the circuit.
+func (cb *circuitBreaker) success() {
+	if cb == nil {
+		return
+	}
+
+	cb.Lock()
+	defer cb.Unlock()
+
+	if cb.state != circuitClosed {
+		cb.log.Infof("Circuit closed after %d consecutive write errors, resuming writes", cb.failures)
+	}
+	cb.setState(circuitClosed)
+	cb.failures = 0
+	cb.backoff = 0
+	cb.Failures.Set(0)
+}
+
+// failure records a failed write opening the circuit if the threshold is
+// reached or probing the output failed.
+func (cb *circuitBreaker) failure() {
+	if cb == nil {
+		return
+	}
+
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures++
+	cb.Failures.Set(int64(cb.failures))
+	if cb.state == circuitHalfOpen || (cb.state == circuitClosed && cb.failures >= cb.cfg.Threshold) {
+		cb.open()
+	}
+}
+
+func (cb *circuitBreaker) open() {
+	delay := cb.cfg.MinDelay
+	for i := 0; i < cb.backoff && delay < cb.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, cb.cfg.MaxDelay)
+	if cb.cfg.Jitter > 0 {
+		//nolint:gosec // G404: not security critical
+		delay += time.Duration(rand.Int63n(int64(cb.cfg.Jitter)))
+	}
+	cb.backoff++
+
+	cb.log.Warnf("Circuit open after %d consecutive write errors, retrying in %s", cb.failures, delay)
+	cb.openUntil = cb.now().Add(delay)
+	cb.setState(circuitOpen)
+	cb.Trips.Incr(1)
+}
+
+func (cb *circuitBreaker) setState(state circuitState) {
+	cb.state = state
+	cb.State.Set(int64(state))
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/rotate"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// DefaultDeadLetterReasonTag is the tag containing the reason for sending a
+// metric to the dead-letter destination
+const DefaultDeadLetterReasonTag = "dead_letter_reason"
+
+// Reasons for metrics dropped by the output
+const (
+	deadLetterReasonDropped     = "dropped from buffer"
+	deadLetterReasonCardinality = "series limit exceeded"
+)
+
+// DeadLetterConfig configures where to send the metrics rejected by the output
+// or dropped from the output buffer. Exactly one of Output or File must be set.
+type DeadLetterConfig struct {
+	// Alias or name of the output receiving the metrics
+	Output string
+
+	// File receiving the metrics serialized with the given serializer
+	File                string
+	RotationInterval    time.Duration
+	RotationMaxSize     int64
+	RotationMaxArchives int
+	Serializer          telegraf.Serializer
+
+	// Tag containing the reason for rejecting or dropping the metric
+	ReasonTag string
+}
+
+// deadLetter forwards rejected and dropped metrics to another output or a
+// file. Metrics are queued and sent once the buffer of the output is
+// unlocked to avoid deadlocks between outputs sending to each other.
+type deadLetter struct {
+	sync.Mutex
+
+	cfg    *DeadLetterConfig
+	log    telegraf.Logger
+	output *RunningOutput
+	writer io.WriteCloser
+
+	pending []telegraf.Metric
+	sending sync.Mutex
+
+	MetricsDeadLettered     selfstat.Stat
+	MetricsDeadLetterFailed selfstat.Stat
+}
+
+func newDeadLetter(cfg *DeadLetterConfig, log telegraf.Logger, tags map[string]string) *deadLetter {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.ReasonTag == "" {
+		cfg.ReasonTag = DefaultDeadLetterReasonTag
+	}
+
+	return &deadLetter{
+		cfg:                 cfg,
+		log:                 log,
+		MetricsDeadLettered:     selfstat.Register("write", "metrics_dead_lettered", tags),
+		MetricsDeadLetterFailed: selfstat.Register("write", "metrics_dead_letter_failed", tags),
+	}
+}
+
+func (cfg *DeadLetterConfig) validate() error {
+	if cfg.Output == "" && cfg.File == "" {
+		return errors.New("dead-letter requires either 'output' or 'file'")
+	}
+	if cfg.Output != "" && cfg.File != "" {
+		return errors.New("dead-letter 'output' and 'file' are mutually exclusive")
+	}
+	if cfg.File != "" && cfg.Serializer == nil {
+		return errors.New("dead-letter file requires a serializer")
+	}
+	return nil
+}
+
+func (d *deadLetter) setOutput(output *RunningOutput) {
+	d.sending.Lock()
+	defer d.sending.Unlock()
+	d.output = output
+}
+
+// open creates the dead-letter file if configured
+func (d *deadLetter) open() error {
+	if d == nil || d.cfg.File == "" || d.writer != nil {
+		return nil
+	}
+
+	w, err := rotate.NewFileWriter(d.cfg.File, d.cfg.RotationInterval, d.cfg.RotationMaxSize, d.cfg.RotationMaxArchives)
+	if err != nil {
+		return fmt.Errorf("opening dead-letter file failed: %w", err)
+	}
+	d.writer = w
+	return nil
+}
+
+func (d *deadLetter) close() error {
+	if d == nil {
+		return nil
+	}
+	d.send()
+
+	d.sending.Lock()
+	defer d.sending.Unlock()
+	if d.writer == nil {
+		return nil
+	}
+	err := d.writer.Close()
+	d.writer = nil
+	return err
+}
+
+// add queues a copy of the given metric tagged with the reason. Metrics
+// already containing the reason tag are not sent again to avoid loops between
+// outputs.
+func (d *deadLetter) add(m telegraf.Metric, reason string) {
+	if d == nil || m.HasTag(d.cfg.ReasonTag) {
+		return
+	}
+
+	// Decouple the metric from tracking as the original metric is rejected
+	if wm, ok := m.(telegraf.UnwrappableMetric); ok {
+		m = wm.Unwrap()
+	}
+	m = m.Copy()
+	m.AddTag(d.cfg.ReasonTag, reason)
+
+	d.Lock()
+	d.pending = append(d.pending, m)
+	d.Unlock()
+}
+
+// send forwards all queued metrics to the dead-letter destination
+func (d *deadLetter) send() {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	metrics := d.pending
+	d.pending = nil
+	d.Unlock()
+	if len(metrics) == 0 {
+		return
+	}
+
+	// Do not hold the lock while sending to another output as the output
+	// might send its dropped metrics back to this output
+	d.sending.Lock()
+	output := d.output
+	d.sending.Unlock()
+	if output != nil {
+		for _, m := range metrics {
+			output.AddMetricNoCopy(m)
+		}
+		d.MetricsDeadLettered.Incr(int64(len(metrics)))
+		return
+	}
+
+	d.sending.Lock()
+	defer d.sending.Unlock()
+	if d.writer == nil {
+		d.log.Errorf("Dead-letter file not open, discarding %d metrics", len(metrics))
+		d.MetricsDeadLetterFailed.Incr(int64(len(metrics)))
+		return
+	}
+	for _, m := range metrics {
+		octets, err := d.cfg.Serializer.Serialize(m)
+		if err != nil {
+			d.log.Errorf("Serializing dead-letter metric failed: %v", err)
+			d.MetricsDeadLetterFailed.Incr(1)
+			continue
+		}
+		if _, err := d.writer.Write(octets); err != nil {
+			d.log.Errorf("Writing dead-letter metric failed: %v", err)
+			d.MetricsDeadLetterFailed.Incr(1)
+			continue
+		}
+		d.MetricsDeadLettered.Incr(1)
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "slices"
+
+// DefaultPipeline is the pipeline of all plugins not assigned to a pipeline
+const DefaultPipeline = "default"
+
+// Pipeline returns the pipeline the input sends its metrics to.
+func (r *RunningInput) Pipeline() string {
+	return pipelineOrDefault(r.Config.Pipeline)
+}
+
+// Pipeline returns the pipeline the processor instance is part of.
+func (rp *RunningProcessor) Pipeline() string {
+	return pipelineOrDefault(rp.Config.Pipeline)
+}
+
+// Pipeline returns the pipeline the aggregator instance is part of.
+func (r *RunningAggregator) Pipeline() string {
+	return pipelineOrDefault(r.Config.Pipeline)
+}
+
+// Pipelines returns the pipelines the output receives metrics from.
+func (r *RunningOutput) Pipelines() []string {
+	if len(r.Config.Pipelines) == 0 {
+		return []string{DefaultPipeline}
+	}
+	return r.Config.Pipelines
+}
+
+// InPipeline returns true if the output receives the metrics of the given
+// pipeline.
+func (r *RunningOutput) InPipeline(name string) bool {
+	return slices.Contains(r.Pipelines(), name)
+}
+
+func pipelineOrDefault(name string) string {
+	if name == "" {
+		return DefaultPipeline
+	}
+	return name
+}
@@ -81,6 +81,7 @@ type AggregatorConfig struct {
 	Delay        time.Duration
 	Grace        time.Duration
 	LogLevel     string
+	Pipeline     string
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -23,6 +23,7 @@ type RunningInput struct {
 
 	log         telegraf.Logger
 	defaultTags map[string]string
+	cardinality *cardinalityGuard
 
 	startAcc    telegraf.Accumulator
 	started     bool
@@ -30,6 +31,8 @@ type RunningInput struct {
 	gatherStart time.Time
 	gatherEnd   time.Time
 
+	tags map[string]string
+
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
 	GatherTimeouts  selfstat.Stat
@@ -59,6 +62,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 	return &RunningInput{
 		Input:  input,
 		Config: config,
+		tags:   tags,
 		MetricsGathered: selfstat.Register(
 			"gather",
 			"metrics_gathered",
@@ -79,7 +83,8 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"startup_errors",
 			tags,
 		),
-		log: logger,
+		cardinality: newCardinalityGuard(config.Cardinality, logger, tags),
+		log:         logger,
 	}
 }
 
@@ -96,6 +101,8 @@ type InputConfig struct {
 	TimeSource           string
 	StartupErrorBehavior string
 	LogLevel             string
+	Pipeline             string
+	Cardinality          CardinalityConfig
 
 	NameOverride            string
 	MeasurementPrefix       string
@@ -129,6 +136,13 @@ func (r *RunningInput) Init() error {
 		return fmt.Errorf("invalid 'time_source' setting %q", r.Config.TimeSource)
 	}
 
+	if err := r.Config.Cardinality.validate(); err != nil {
+		return err
+	}
+	if err := r.cardinality.init(); err != nil {
+		return err
+	}
+
 	if p, ok := r.Input.(telegraf.Initializer); ok {
 		return p.Init()
 	}
@@ -188,6 +202,12 @@ func (r *RunningInput) Stop() {
 	}
 }
 
+// Discard removes the statistics of an input never started by the agent,
+// e.g. when a configuration reload is aborted.
+func (r *RunningInput) Discard() {
+	selfstat.UnregisterAll(r.tags)
+}
+
 func (r *RunningInput) ID() string {
 	if p, ok := r.Input.(telegraf.PluginWithID); ok {
 		return p.ID()
@@ -237,6 +257,11 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	default:
 	}
 
+	if !r.cardinality.apply(metric) {
+		r.metricFiltered(metric)
+		return nil
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return metric
@@ -533,6 +533,170 @@ func TestRunningInputProbingSuccess(t *testing.T) {
 	}
 }
 
+func TestRunningInputCardinality(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		action   string
+		tags     []string
+		expected []telegraf.Metric
+	}{
+		{
+			name:   "drop",
+			action: "drop",
+			expected: []telegraf.Metric{
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "2"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+			},
+		},
+		{
+			name:   "strip offending tag",
+			action: "strip_tags",
+			expected: []telegraf.Metric{
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "2"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+			},
+		},
+		{
+			name:   "strip configured tags",
+			action: "strip_tags",
+			tags:   []string{"host"},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "2"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+			},
+		},
+		{
+			name:   "aggregate",
+			action: "aggregate",
+			expected: []telegraf.Metric{
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "2"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "_overflow"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "_overflow"}, map[string]interface{}{"value": 1}, now),
+				testutil.MustMetric("requests", map[string]string{"host": "a", "id": "1"}, map[string]interface{}{"value": 1}, now),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := NewRunningInput(&mockInput{}, &InputConfig{
+				Name:  "TestRunningInputCardinality",
+				Alias: tt.name,
+				Cardinality: CardinalityConfig{
+					Limit:  2,
+					Window: time.Minute,
+					Action: tt.action,
+					Tags:   tt.tags,
+				},
+			})
+			ri.log = testutil.Logger{}
+			ri.cardinality.log = testutil.Logger{}
+			ri.cardinality.now = func() time.Time { return now }
+			require.NoError(t, ri.Init())
+
+			actual := make([]telegraf.Metric, 0, 5)
+			for _, id := range []string{"1", "2", "3", "4", "1"} {
+				m := testutil.MustMetric("requests", map[string]string{"host": "a", "id": id}, map[string]interface{}{"value": 1}, now)
+				if m = ri.MakeMetric(m); m != nil {
+					actual = append(actual, m)
+				}
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+			require.EqualValues(t, 2, ri.cardinality.MetricsLimited.Get())
+		})
+	}
+}
+
+func TestRunningInputCardinalityAggregateWithoutTags(t *testing.T) {
+	now := time.Unix(0, 0)
+	ri := NewRunningInput(&mockInput{}, &InputConfig{
+		Name: "TestRunningInputCardinalityAggregateWithoutTags",
+		Cardinality: CardinalityConfig{
+			Limit:  1,
+			Window: time.Minute,
+			Action: "aggregate",
+			Tags:   []string{"id"},
+		},
+	})
+	ri.log = testutil.Logger{}
+	ri.cardinality.log = testutil.Logger{}
+	ri.cardinality.now = func() time.Time { return now }
+	require.NoError(t, ri.Init())
+
+	// New series without a tag to aggregate are dropped instead of exceeding
+	// the limit
+	require.NotNil(t, ri.MakeMetric(testutil.MustMetric("requests", map[string]string{"id": "1"}, map[string]interface{}{"value": 1}, now)))
+	require.Nil(t, ri.MakeMetric(testutil.MustMetric("untagged", map[string]string{}, map[string]interface{}{"value": 1}, now)))
+	require.Nil(t, ri.MakeMetric(testutil.MustMetric("requests", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now)))
+	require.EqualValues(t, 2, ri.cardinality.MetricsLimited.Get())
+	require.EqualValues(t, 1, ri.cardinality.Series.Get())
+
+	// Series with a tag to aggregate are still accepted as overflow series
+	m := ri.MakeMetric(testutil.MustMetric("requests", map[string]string{"id": "2"}, map[string]interface{}{"value": 1}, now))
+	require.NotNil(t, m)
+	require.Equal(t, map[string]string{"id": "_overflow"}, m.Tags())
+}
+
+func TestRunningInputCardinalityWindow(t *testing.T) {
+	ri := NewRunningInput(&mockInput{}, &InputConfig{
+		Name: "TestRunningInputCardinalityWindow",
+		Cardinality: CardinalityConfig{
+			Limit:  2,
+			Window: time.Minute,
+		},
+	})
+	ri.log = testutil.Logger{}
+	ri.cardinality.log = testutil.Logger{}
+	now := time.Unix(0, 0)
+	ri.cardinality.now = func() time.Time { return now }
+	require.NoError(t, ri.Init())
+
+	makeMetric := func(name, id string) telegraf.Metric {
+		return ri.MakeMetric(testutil.MustMetric(name, map[string]string{"id": id}, map[string]interface{}{"value": 1}, now))
+	}
+	require.NotNil(t, makeMetric("requests", "1"))
+	require.NotNil(t, makeMetric("requests", "2"))
+	require.Nil(t, makeMetric("requests", "3"))
+	require.EqualValues(t, 2, ri.cardinality.Series.Get())
+
+	// The offenders are reported once the statistics are updated
+	now = now.Add(10 * time.Second)
+	require.Nil(t, makeMetric("requests", "4"))
+	require.EqualValues(t, 2, ri.cardinality.topMeasurement.Get())
+	require.Equal(t, "requests", ri.cardinality.topMeasurement.Tags()["measurement"])
+	require.EqualValues(t, 2, ri.cardinality.topTagKey.Get())
+	require.Equal(t, "id", ri.cardinality.topTagKey.Tags()["tag_key"])
+
+	// Series not seen within the window expire
+	now = now.Add(45 * time.Second)
+	require.NotNil(t, makeMetric("requests", "2"))
+	now = now.Add(20 * time.Second)
+	require.NotNil(t, makeMetric("requests", "5"))
+	require.Nil(t, makeMetric("requests", "6"))
+	require.EqualValues(t, 2, ri.cardinality.Series.Get())
+
+	// The offenders are removed once the limit is no longer exceeded
+	now = now.Add(10 * time.Second)
+	require.NotNil(t, makeMetric("requests", "5"))
+	now = now.Add(10 * time.Second)
+	require.NotNil(t, makeMetric("requests", "5"))
+	require.Nil(t, ri.cardinality.topMeasurement)
+	require.Nil(t, ri.cardinality.topTagKey)
+}
+
+func TestRunningInputCardinalityInvalidAction(t *testing.T) {
+	ri := NewRunningInput(&mockInput{}, &InputConfig{
+		Name:        "TestRunningInputCardinalityInvalidAction",
+		Cardinality: CardinalityConfig{Limit: 1, Action: "sample"},
+	})
+	require.ErrorContains(t, ri.Init(), "invalid 'cardinality_action'")
+}
+
 type mockInput struct {
 	probeReturn error
 }
@@ -39,8 +39,15 @@ type OutputConfig struct {
 	NamePrefix   string
 	NameSuffix   string
 
-	BufferStrategy  string
-	BufferDirectory string
+	BufferStrategy   string
+	BufferDirectory  string
+	BufferDiskLimits DiskBufferLimits
+
+	CircuitBreaker CircuitBreakerConfig
+	DeadLetter     *DeadLetterConfig
+	Cardinality    CardinalityConfig
+
+	Pipelines []string
 
 	LogLevel string
 }
@@ -63,11 +70,16 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer Buffer
-	log    telegraf.Logger
+	buffer      Buffer
+	breaker     *circuitBreaker
+	deadLetter  *deadLetter
+	cardinality *cardinalityGuard
+	log         telegraf.Logger
 
 	started bool
 	retries uint64
+	tags    map[string]string
+	closed  sync.Once
 
 	aggMutex sync.Mutex
 }
@@ -105,11 +117,18 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		batchSize = DefaultMetricBatchSize
 	}
 
-	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory)
+	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory, config.BufferDiskLimits)
 	if err != nil {
 		panic(err)
 	}
 
+	dl := newDeadLetter(config.DeadLetter, logger, tags)
+	if dl != nil {
+		b.Stats().SetDropHandler(func(m telegraf.Metric) {
+			dl.add(m, deadLetterReasonDropped)
+		})
+	}
+
 	ro := &RunningOutput{
 		buffer:            b,
 		BatchReady:        make(chan time.Time, 1),
@@ -132,7 +151,11 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 			"startup_errors",
 			tags,
 		),
-		log: logger,
+		breaker:     newCircuitBreaker(config.CircuitBreaker, logger, tags),
+		deadLetter:  dl,
+		cardinality: newCardinalityGuard(config.Cardinality, logger, tags),
+		log:         logger,
+		tags:        tags,
 	}
 
 	return ro
@@ -160,6 +183,23 @@ func (r *RunningOutput) Init() error {
 	default:
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
+	if err := r.Config.CircuitBreaker.validate(); err != nil {
+		return err
+	}
+	if err := r.Config.Cardinality.validate(); err != nil {
+		return err
+	}
+	if err := r.cardinality.init(); err != nil {
+		return err
+	}
+	if r.Config.DeadLetter != nil {
+		if err := r.Config.DeadLetter.validate(); err != nil {
+			return err
+		}
+		if err := r.deadLetter.open(); err != nil {
+			return err
+		}
+	}
 
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -200,14 +240,35 @@ func (r *RunningOutput) Connect() error {
 	return err
 }
 
-// Close closes the output
+// Close closes the output, subsequent calls are no-ops
 func (r *RunningOutput) Close() {
-	if err := r.Output.Close(); err != nil {
-		r.log.Errorf("Error closing output: %v", err)
-	}
+	r.closed.Do(func() {
+		if err := r.Output.Close(); err != nil {
+			r.log.Errorf("Error closing output: %v", err)
+		}
+
+		if err := r.buffer.Close(); err != nil {
+			r.log.Errorf("Error closing output buffer: %v", err)
+		}
+
+		if err := r.deadLetter.close(); err != nil {
+			r.log.Errorf("Error closing dead-letter file: %v", err)
+		}
+	})
+}
 
-	if err := r.buffer.Close(); err != nil {
-		r.log.Errorf("Error closing output buffer: %v", err)
+// Discard closes an output never handed over to the agent, e.g. when a
+// configuration reload is aborted, and removes its statistics.
+func (r *RunningOutput) Discard() {
+	r.Close()
+	selfstat.UnregisterAll(r.tags)
+}
+
+// SetDeadLetterOutput sets the output receiving the metrics rejected or
+// dropped by this output.
+func (r *RunningOutput) SetDeadLetterOutput(output *RunningOutput) {
+	if r.deadLetter != nil {
+		r.deadLetter.setOutput(output)
 	}
 }
 
@@ -265,7 +326,15 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 		metric.AddSuffix(r.Config.NameSuffix)
 	}
 
+	if !r.cardinality.apply(metric) {
+		r.deadLetter.add(metric, deadLetterReasonCardinality)
+		r.deadLetter.send()
+		metric.Drop()
+		return
+	}
+
 	r.droppedMetrics.Add(int64(r.buffer.Add(metric)))
+	r.deadLetter.send()
 
 	r.triggerBatchCheck()
 }
@@ -291,6 +360,27 @@ func (r *RunningOutput) triggerBatchCheck() {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (r *RunningOutput) Write() error {
+	return r.write(false)
+}
+
+// WriteFinal writes all metrics to the output like Write but ignores an open
+// circuit to not lose the buffered metrics when stopping the output.
+func (r *RunningOutput) WriteFinal() error {
+	return r.write(true)
+}
+
+func (r *RunningOutput) write(final bool) error {
+	// Skip writing while the circuit is open except for the final write
+	allowed, probeSize := true, 0
+	if !final {
+		allowed, probeSize = r.breaker.allow()
+	}
+	if !allowed {
+		r.log.Debug("Circuit open, skipping write")
+		r.writeInFlight.Store(false)
+		return nil
+	}
+
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
@@ -298,6 +388,7 @@ func (r *RunningOutput) Write() error {
 			var serr *internal.StartupError
 			if !errors.As(err, &serr) || !serr.Retry || !serr.Partial {
 				r.StartupErrors.Incr(1)
+				r.breaker.failure()
 				return internal.ErrNotConnected
 			}
 			r.log.Debugf("Partially connected after %d attempts", r.retries)
@@ -323,13 +414,18 @@ func (r *RunningOutput) Write() error {
 		r.aggMutex.Unlock()
 	}
 
+	// Probe the output with a single small batch before resuming full writes
+	if probeSize > 0 {
+		return r.doTransaction(probeSize)
+	}
+
 	// Only process the metrics in the buffer now. Metrics added while we are
 	// writing will be sent on the next call. We can safely add one more write
 	// because 'doTransaction' will abort early for empty batches.
 	nBuffer := r.buffer.Len()
 	nBatches := nBuffer/r.MetricBatchSize + 1
 	for i := 0; i < nBatches; i++ {
-		if err := r.doTransaction(); err != nil {
+		if err := r.doTransaction(r.MetricBatchSize); err != nil {
 			return err
 		}
 	}
@@ -338,11 +434,20 @@ func (r *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (r *RunningOutput) WriteBatch() error {
+	// Skip writing while the circuit is open
+	allowed, probeSize := r.breaker.allow()
+	if !allowed {
+		r.log.Debug("Circuit open, skipping write")
+		r.writeInFlight.Store(false)
+		return nil
+	}
+
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
 		if err := r.Output.Connect(); err != nil {
 			r.StartupErrors.Incr(1)
+			r.breaker.failure()
 			return internal.ErrNotConnected
 		}
 		r.started = true
@@ -357,17 +462,29 @@ func (r *RunningOutput) WriteBatch() error {
 		r.triggerBatchCheck()
 	}()
 
-	return r.doTransaction()
+	if probeSize > 0 {
+		return r.doTransaction(probeSize)
+	}
+	return r.doTransaction(r.MetricBatchSize)
 }
 
-func (r *RunningOutput) doTransaction() error {
-	tx := r.buffer.BeginTransaction(r.MetricBatchSize)
+func (r *RunningOutput) doTransaction(batchSize int) error {
+	tx := r.buffer.BeginTransaction(batchSize)
 	if len(tx.Batch) == 0 {
 		return nil
 	}
 	err := r.writeMetrics(tx.Batch)
 	r.updateTransaction(tx, err)
+	r.deadLetterRejected(tx, err)
 	r.buffer.EndTransaction(tx)
+	r.deadLetter.send()
+
+	// Partial writes still indicate a working output
+	if r.lastWriteFailed.Load() {
+		r.breaker.failure()
+	} else {
+		r.breaker.success()
+	}
 
 	return err
 }
@@ -406,17 +523,42 @@ func (r *RunningOutput) updateTransaction(tx *Transaction, err error) {
 		return
 	}
 
-	// Transfer the accepted and rejected indices based on the write error values
-	r.lastWriteFailed.Store(false)
+	// Transfer the accepted and rejected indices based on the write error values.
+	// A partial write without any accepted or rejected metric is a failure as
+	// some outputs report a complete failure this way, e.g. on backpressure.
 	tx.Accept = writeErr.MetricsAccept
 	tx.Reject = writeErr.MetricsReject
+	r.lastWriteFailed.Store(len(tx.Accept) == 0 && len(tx.Reject) == 0)
+}
+
+// deadLetterRejected queues the metrics rejected by the output for the
+// dead-letter destination using the write error as reason
+func (r *RunningOutput) deadLetterRejected(tx *Transaction, err error) {
+	if r.deadLetter == nil || len(tx.Reject) == 0 {
+		return
+	}
+
+	var writeErr *internal.PartialWriteError
+	if !errors.As(err, &writeErr) {
+		return
+	}
+	for i, idx := range tx.Reject {
+		reason := "rejected"
+		if i < len(writeErr.MetricsRejectErrors) && writeErr.MetricsRejectErrors[i] != nil {
+			reason = writeErr.MetricsRejectErrors[i].Error()
+		} else if writeErr.Err != nil {
+			reason = writeErr.Err.Error()
+		}
+		r.deadLetter.add(tx.Batch[idx], reason)
+	}
 }
 
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
-	if r.Config.BufferStrategy == "disk_write_through" {
+	switch r.Config.BufferStrategy {
+	case "disk_write_through", "memory_with_disk_overflow":
 		r.log.Debugf("Buffer fullness: %d metrics", nBuffer)
-	} else {
+	default:
 		r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
 	}
 }
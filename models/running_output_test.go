@@ -3,6 +3,8 @@ package models
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -1161,6 +1163,333 @@ func TestRunningOutputWriteBatchPartialSuccessAndLoss(t *testing.T) {
 	require.Zero(t, model.buffer.Len())
 }
 
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	plugin := &mockOutput{batchAcceptSize: -1}
+	model := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Filter: Filter{},
+			Name:   "circuit",
+			CircuitBreaker: CircuitBreakerConfig{
+				Threshold: 2,
+				MinDelay:  time.Minute,
+				MaxDelay:  3 * time.Minute,
+			},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, model.Init())
+
+	now := time.Unix(0, 0)
+	model.breaker.now = func() time.Time { return now }
+
+	for _, m := range append(first5, next5...) {
+		model.AddMetric(m)
+	}
+
+	// The circuit opens after reaching the threshold of consecutive errors
+	require.Error(t, model.Write())
+	require.Error(t, model.Write())
+	require.EqualValues(t, 2, plugin.writes.Load())
+	require.EqualValues(t, circuitOpen, model.breaker.State.Get())
+	require.EqualValues(t, 2, model.breaker.Failures.Get())
+
+	// Writes are skipped while the circuit is open
+	require.NoError(t, model.Write())
+	require.NoError(t, model.WriteBatch())
+	require.EqualValues(t, 2, plugin.writes.Load())
+
+	// A failed probe opens the circuit again with twice the delay
+	now = now.Add(time.Minute)
+	require.Error(t, model.Write())
+	require.EqualValues(t, 3, plugin.writes.Load())
+	require.EqualValues(t, circuitOpen, model.breaker.State.Get())
+	now = now.Add(time.Minute)
+	require.NoError(t, model.Write())
+	require.EqualValues(t, 3, plugin.writes.Load())
+
+	// A successful probe writes a single metric and closes the circuit
+	now = now.Add(time.Minute)
+	plugin.batchAcceptSize = 0
+	require.NoError(t, model.Write())
+	testutil.RequireMetricsEqual(t, first5[:1], plugin.Metrics())
+	require.EqualValues(t, circuitClosed, model.breaker.State.Get())
+	require.EqualValues(t, 2, model.breaker.Trips.Get())
+	require.Zero(t, model.breaker.Failures.Get())
+
+	// Full writes resume afterwards
+	require.NoError(t, model.Write())
+	testutil.RequireMetricsEqual(t, append(first5, next5...), plugin.Metrics())
+}
+
+func TestRunningOutputCircuitBreakerFinalWrite(t *testing.T) {
+	plugin := &mockOutput{batchAcceptSize: -1}
+	model := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Filter: Filter{},
+			Name:   "circuit_final",
+			CircuitBreaker: CircuitBreakerConfig{
+				Threshold: 1,
+				MinDelay:  time.Minute,
+			},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, model.Init())
+
+	for _, m := range append(first5, next5...) {
+		model.AddMetric(m)
+	}
+	require.Error(t, model.Write())
+	require.EqualValues(t, circuitOpen, model.breaker.State.Get())
+
+	// The final write on shutdown ignores the open circuit
+	plugin.batchAcceptSize = 0
+	require.NoError(t, model.Write())
+	require.EqualValues(t, 1, plugin.writes.Load())
+	require.NoError(t, model.WriteFinal())
+	testutil.RequireMetricsEqual(t, append(first5, next5...), plugin.Metrics())
+	require.Zero(t, model.buffer.Len())
+}
+
+func TestRunningOutputCircuitBreakerEmptyPartialWrite(t *testing.T) {
+	plugin := &mockOutput{
+		preWriteHook: func([]telegraf.Metric) error {
+			return &internal.PartialWriteError{Err: errors.New("backpressure")}
+		},
+	}
+	model := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Filter: Filter{},
+			Name:   "circuit_empty_partial",
+			CircuitBreaker: CircuitBreakerConfig{
+				Threshold: 1,
+				MinDelay:  time.Minute,
+			},
+		},
+		5,
+		10,
+	)
+	require.NoError(t, model.Init())
+
+	// A partial write neither accepting nor rejecting metrics is a failure
+	model.AddMetric(testutil.TestMetric(101, "metric1"))
+	require.Error(t, model.Write())
+	require.True(t, model.lastWriteFailed.Load())
+	require.EqualValues(t, circuitOpen, model.breaker.State.Get())
+	require.Equal(t, 1, model.buffer.Len())
+}
+
+func TestRunningOutputCircuitBreakerMaxDelay(t *testing.T) {
+	model := NewRunningOutput(
+		&mockOutput{batchAcceptSize: -1},
+		&OutputConfig{
+			Filter: Filter{},
+			Name:   "circuit_max_delay",
+			CircuitBreaker: CircuitBreakerConfig{
+				Threshold: 1,
+				MinDelay:  time.Minute,
+				MaxDelay:  3 * time.Minute,
+			},
+		},
+		5,
+		10,
+	)
+
+	now := time.Unix(0, 0)
+	model.breaker.now = func() time.Time { return now }
+	model.AddMetric(testutil.TestMetric(101, "metric1"))
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	for _, delay := range expected {
+		require.Error(t, model.Write())
+		require.Equal(t, now.Add(delay), model.breaker.openUntil)
+		now = model.breaker.openUntil
+	}
+}
+
+func TestRunningOutputCircuitBreakerInvalid(t *testing.T) {
+	model := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Filter: Filter{},
+			Name:   "circuit_invalid",
+			CircuitBreaker: CircuitBreakerConfig{
+				Threshold: 1,
+				MinDelay:  time.Minute,
+				MaxDelay:  time.Second,
+			},
+		},
+		5,
+		10,
+	)
+	require.ErrorContains(t, model.Init(), "must not be smaller")
+}
+
+func TestRunningOutputDeadLetterOutput(t *testing.T) {
+	audit := NewRunningOutput(&mockOutput{}, &OutputConfig{Name: "audit"}, 5, 10)
+	require.NoError(t, audit.Init())
+	require.NoError(t, audit.Connect())
+	defer audit.Close()
+
+	fatal := 2
+	plugin := &mockOutput{
+		batchAcceptSize:  5,
+		metricFatalIndex: &fatal,
+	}
+	model := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Name:       "dead_letter_output",
+			DeadLetter: &DeadLetterConfig{Output: "audit"},
+		},
+		10,
+		10,
+	)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+	model.SetDeadLetterOutput(audit)
+
+	for _, m := range append(first5, next5...) {
+		model.AddMetric(m)
+	}
+
+	// The rejected metric is sent to the dead-letter output with the reason
+	require.ErrorIs(t, model.Write(), internal.ErrSizeLimitReached)
+	require.NoError(t, audit.Write())
+
+	expected := first5[2].Copy()
+	expected.AddTag(DefaultDeadLetterReasonTag, internal.ErrSizeLimitReached.Error())
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, audit.Output.(*mockOutput).Metrics())
+	require.EqualValues(t, 1, model.deadLetter.MetricsDeadLettered.Get())
+}
+
+func TestRunningOutputCardinality(t *testing.T) {
+	audit := NewRunningOutput(&mockOutput{}, &OutputConfig{Name: "audit"}, 5, 10)
+	require.NoError(t, audit.Init())
+	require.NoError(t, audit.Connect())
+	defer audit.Close()
+
+	plugin := &mockOutput{}
+	model := NewRunningOutput(
+		plugin,
+		&OutputConfig{
+			Name:        "cardinality_output",
+			DeadLetter:  &DeadLetterConfig{Output: "audit"},
+			Cardinality: CardinalityConfig{Limit: 3},
+		},
+		10,
+		10,
+	)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+	model.SetDeadLetterOutput(audit)
+
+	// Metrics of new series exceeding the limit are dead-lettered
+	for _, m := range first5 {
+		model.AddMetric(m)
+	}
+	require.NoError(t, model.Write())
+	require.NoError(t, audit.Write())
+	testutil.RequireMetricsEqual(t, first5[:3], plugin.Metrics())
+
+	expected := make([]telegraf.Metric, 0, 2)
+	for _, m := range first5[3:] {
+		m = m.Copy()
+		m.AddTag(DefaultDeadLetterReasonTag, deadLetterReasonCardinality)
+		expected = append(expected, m)
+	}
+	testutil.RequireMetricsEqual(t, expected, audit.Output.(*mockOutput).Metrics())
+	require.EqualValues(t, 2, model.cardinality.MetricsLimited.Get())
+}
+
+func TestRunningOutputDeadLetterFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dead_letter.out")
+	model := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Name: "dead_letter_file",
+			DeadLetter: &DeadLetterConfig{
+				File:       filename,
+				Serializer: &reasonSerializer{tag: "reason"},
+				ReasonTag:  "reason",
+			},
+		},
+		5,
+		5,
+	)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+
+	// Overflow the buffer so the oldest metrics are dropped
+	for _, m := range append(first5, next5[:2]...) {
+		model.AddMetric(m)
+	}
+	model.Close()
+
+	actual, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "metric1 dropped from buffer\nmetric2 dropped from buffer\n", string(actual))
+	require.EqualValues(t, 2, model.deadLetter.MetricsDeadLettered.Get())
+	require.Zero(t, model.deadLetter.MetricsDeadLetterFailed.Get())
+}
+
+func TestRunningOutputDeadLetterFileFailed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dead_letter.out")
+	model := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Name: "dead_letter_file_failed",
+			DeadLetter: &DeadLetterConfig{
+				File:       filename,
+				Serializer: &reasonSerializer{tag: "reason", fail: "metric1"},
+				ReasonTag:  "reason",
+			},
+		},
+		5,
+		5,
+	)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+
+	// Only metrics actually written are counted as dead-lettered
+	for _, m := range append(first5, next5[:2]...) {
+		model.AddMetric(m)
+	}
+	require.EqualValues(t, 1, model.deadLetter.MetricsDeadLettered.Get())
+	require.EqualValues(t, 1, model.deadLetter.MetricsDeadLetterFailed.Get())
+
+	// Metrics are discarded once the file is closed
+	model.Close()
+	model.AddMetric(next5[2])
+	model.AddMetric(next5[3])
+	require.EqualValues(t, 1, model.deadLetter.MetricsDeadLettered.Get())
+	require.EqualValues(t, 3, model.deadLetter.MetricsDeadLetterFailed.Get())
+
+	actual, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "metric2 dropped from buffer\n", string(actual))
+}
+
+func TestRunningOutputDeadLetterInvalid(t *testing.T) {
+	model := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Name:       "dead_letter_invalid",
+			DeadLetter: &DeadLetterConfig{File: "dead_letter.out"},
+		},
+		5,
+		5,
+	)
+	require.ErrorContains(t, model.Init(), "requires a serializer")
+}
+
 // Benchmark adding metrics.
 func BenchmarkRunningOutputAddWrite(b *testing.B) {
 	conf := &OutputConfig{
@@ -1293,6 +1622,31 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+type reasonSerializer struct {
+	tag  string
+	fail string
+}
+
+func (s *reasonSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	if m.Name() == s.fail {
+		return nil, errors.New("serialization failed")
+	}
+	reason, _ := m.GetTag(s.tag)
+	return []byte(m.Name() + " " + reason + "\n"), nil
+}
+
+func (s *reasonSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var out []byte
+	for _, m := range metrics {
+		octets, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, octets...)
+	}
+	return out, nil
+}
+
 type perfOutput struct {
 	// if true, mock write failure
 	failWrite bool
@@ -30,6 +30,7 @@ type ProcessorConfig struct {
 	Order    int64
 	Filter   Filter
 	LogLevel string
+	Pipeline string
 }
 
 func NewRunningProcessor(processor telegraf.StreamingProcessor, config *ProcessorConfig) *RunningProcessor {
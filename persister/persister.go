@@ -1,10 +1,16 @@
 package persister
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 )
@@ -12,25 +18,82 @@ import (
 type Persister struct {
 	Filename string
 
-	register map[string]telegraf.StatefulPlugin
+	// Interval for periodically storing the states, zero disables
+	// checkpointing and only stores the states on shutdown
+	Interval time.Duration
+
+	// Flush the states file and its directory to disk after writing
+	Fsync bool
+
+	register     map[string]telegraf.StatefulPlugin
+	registerLock sync.Mutex
+	requests     chan struct{}
+
+	// Serialize the writes and skip writing unchanged states
+	storeLock sync.Mutex
+	stored    []byte
 }
 
 func (p *Persister) Init() error {
 	p.register = make(map[string]telegraf.StatefulPlugin)
+	p.requests = make(chan struct{}, 1)
 
 	return nil
 }
 
 func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
+	p.registerLock.Lock()
+	defer p.registerLock.Unlock()
+
 	if _, found := p.register[id]; found {
 		return fmt.Errorf("plugin with ID %q already registered", id)
 	}
 	p.register[id] = plugin
+	p.setPersisterOnPlugin(plugin)
 
 	return nil
 }
 
+// Unregister removes the plugin with the given ID, e.g. when the plugin is
+// stopped on a configuration reload, so its state is not stored anymore.
+func (p *Persister) Unregister(id string) {
+	p.registerLock.Lock()
+	defer p.registerLock.Unlock()
+
+	delete(p.register, id)
+}
+
+// Checkpoint requests storing the states as soon as possible. The call does
+// not block and multiple pending requests are merged into one.
+func (p *Persister) Checkpoint() {
+	select {
+	case p.requests <- struct{}{}:
+	default:
+	}
+}
+
+// Requests returns the channel signaling checkpoint requests by the plugins
+func (p *Persister) Requests() <-chan struct{} {
+	return p.requests
+}
+
 func (p *Persister) Load() error {
+	return p.load(nil)
+}
+
+// LoadPlugins restores the states of the registered plugins with the given
+// IDs only, e.g. for plugins added on a configuration reload while the states
+// of the running plugins must be kept.
+func (p *Persister) LoadPlugins(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return p.load(ids)
+}
+
+// load restores the states of the registered plugins with the given IDs or of
+// all registered plugins if no IDs are given
+func (p *Persister) load(ids []string) error {
 	// Read the states from disk
 	in, err := os.ReadFile(p.Filename)
 	if err != nil {
@@ -43,11 +106,14 @@ func (p *Persister) Load() error {
 		return fmt.Errorf("unmarshalling states failed: %w", err)
 	}
 
+	p.registerLock.Lock()
+	defer p.registerLock.Unlock()
+
 	// Get the initialized state as blueprint for unmarshalling
 	for id, serialized := range states {
 		// Check if we have a plugin with that ID
 		plugin, found := p.register[id]
-		if !found {
+		if !found || (ids != nil && !slices.Contains(ids, id)) {
 			continue
 		}
 
@@ -70,17 +136,23 @@ func (p *Persister) Load() error {
 }
 
 func (p *Persister) Store() error {
+	p.storeLock.Lock()
+	defer p.storeLock.Unlock()
+
 	states := make(map[string][]byte)
 
 	// Collect the states and serialize the individual data chunks
 	// to later serialize all items in the id / serialized-states map
+	p.registerLock.Lock()
 	for id, plugin := range p.register {
 		state, err := json.Marshal(plugin.GetState())
 		if err != nil {
+			p.registerLock.Unlock()
 			return fmt.Errorf("marshalling state for id %q failed: %w", id, err)
 		}
 		states[id] = state
 	}
+	p.registerLock.Unlock()
 
 	// Serialize the states
 	serialized, err := json.Marshal(states)
@@ -88,16 +160,76 @@ func (p *Persister) Store() error {
 		return fmt.Errorf("marshalling states failed: %w", err)
 	}
 
-	// Write the states to disk
-	f, err := os.Create(p.Filename)
+	// Avoid rewriting the file if nothing changed since the last checkpoint
+	if p.stored != nil && bytes.Equal(serialized, p.stored) {
+		return nil
+	}
+
+	if err := p.write(serialized); err != nil {
+		return err
+	}
+	p.stored = serialized
+
+	return nil
+}
+
+// write replaces the states file atomically by writing to a temporary file
+// and renaming it, so a crash during the write leaves the previous states
+func (p *Persister) write(serialized []byte) error {
+	tmpfile := p.Filename + ".tmp"
+	f, err := os.Create(tmpfile)
 	if err != nil {
-		return fmt.Errorf("creating states file %q failed: %w", p.Filename, err)
+		return fmt.Errorf("creating states file %q failed: %w", tmpfile, err)
 	}
-	defer f.Close()
+	defer os.Remove(tmpfile)
 
 	if _, err := f.Write(serialized); err != nil {
+		f.Close()
 		return fmt.Errorf("writing states failed: %w", err)
 	}
+	if p.Fsync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return fmt.Errorf("syncing states file %q failed: %w", tmpfile, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing states file %q failed: %w", tmpfile, err)
+	}
+
+	if err := os.Rename(tmpfile, p.Filename); err != nil {
+		return fmt.Errorf("replacing states file %q failed: %w", p.Filename, err)
+	}
+
+	// Persist the rename, directories cannot be synced on Windows
+	if p.Fsync && runtime.GOOS != "windows" {
+		dir, err := os.Open(filepath.Dir(p.Filename))
+		if err != nil {
+			return fmt.Errorf("opening states directory failed: %w", err)
+		}
+		defer dir.Close()
+		if err := dir.Sync(); err != nil {
+			return fmt.Errorf("syncing states directory failed: %w", err)
+		}
+	}
 
 	return nil
 }
+
+// setPersisterOnPlugin sets the persister to the 'Persister' field of the
+// plugin if any, so the plugin can request checkpoints
+func (p *Persister) setPersisterOnPlugin(plugin telegraf.StatefulPlugin) {
+	instance := reflect.Indirect(reflect.ValueOf(plugin))
+	if instance.Kind() != reflect.Struct {
+		return
+	}
+
+	field := instance.FieldByName("Persister")
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+	if field.Type() != reflect.TypeOf((*telegraf.StatePersister)(nil)).Elem() {
+		return
+	}
+	field.Set(reflect.ValueOf(p))
+}
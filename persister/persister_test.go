@@ -0,0 +1,95 @@
+package persister
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+func TestStoreReplacesAtomically(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(filename, []byte("previous"), 0o600))
+
+	plugin := &mockupPlugin{state: "running"}
+	p := &Persister{Filename: filename, Fsync: true}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("id", plugin))
+	require.NoError(t, p.Store())
+
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"InJ1bm5pbmci"}`, string(buf))
+	require.NoFileExists(t, filename+".tmp")
+
+	// A failing write must keep the previous states
+	plugin.state = "stopped"
+	require.NoError(t, os.Mkdir(filename+".tmp", 0o750))
+	require.Error(t, p.Store())
+	actual, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, buf, actual)
+}
+
+func TestStoreSkipsUnchanged(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+
+	p := &Persister{Filename: filename}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("id", &mockupPlugin{state: "running"}))
+	require.NoError(t, p.Store())
+
+	// Remove the file to detect a write
+	require.NoError(t, os.Remove(filename))
+	require.NoError(t, p.Store())
+	require.NoFileExists(t, filename)
+}
+
+func TestCheckpoint(t *testing.T) {
+	p := &Persister{Filename: filepath.Join(t.TempDir(), "state.json")}
+	require.NoError(t, p.Init())
+
+	plugin := &mockupPlugin{}
+	require.NoError(t, p.Register("id", plugin))
+	require.Equal(t, p, plugin.Persister)
+
+	// Multiple requests are merged
+	plugin.Persister.Checkpoint()
+	plugin.Persister.Checkpoint()
+	require.Len(t, p.Requests(), 1)
+}
+
+func TestLoadPlugins(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"running":"InN0b3JlZCI=","added":"InN0b3JlZCI="}`), 0o600))
+
+	p := &Persister{Filename: filename}
+	require.NoError(t, p.Init())
+	running := &mockupPlugin{state: "running"}
+	added := &mockupPlugin{state: "initial"}
+	require.NoError(t, p.Register("running", running))
+	require.NoError(t, p.Register("added", added))
+
+	// Only the state of the given plugins is restored
+	require.NoError(t, p.LoadPlugins([]string{"added"}))
+	require.Equal(t, "running", running.state)
+	require.Equal(t, "stored", added.state)
+}
+
+type mockupPlugin struct {
+	Persister telegraf.StatePersister `toml:"-"`
+
+	state string
+}
+
+func (m *mockupPlugin) GetState() interface{} {
+	return m.state
+}
+
+func (m *mockupPlugin) SetState(state interface{}) error {
+	m.state = state.(string)
+	return nil
+}
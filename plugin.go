@@ -57,6 +57,15 @@ type StatefulPlugin interface {
 	SetState(state interface{}) error
 }
 
+// StatePersister is set by Telegraf on the 'Persister' field of stateful
+// plugins to allow requesting a checkpoint of the states, e.g. after the
+// plugin made important progress that should survive an unclean shutdown.
+type StatePersister interface {
+	// Checkpoint requests storing the states of all stateful plugins as
+	// soon as possible. The call does not block.
+	Checkpoint()
+}
+
 // ProbePlugin is an interface that all input/output plugins need to
 // implement in order to support the `probe` value of `startup_error_behavior`
 type ProbePlugin interface {
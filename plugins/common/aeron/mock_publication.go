@@ -0,0 +1,60 @@
+package aeron
+
+import (
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+	"github.com/lirm/aeron-go/aeron/logbuffer/term"
+)
+
+// MockPublication is a publication for testing purposes recording the offered
+// messages. The given results are returned for the consecutive offers which
+// succeed once the results are exhausted. Returning aeron.PublicationClosed
+// marks the publication as closed.
+type MockPublication struct {
+	Results []int64
+	Offered [][]byte
+	Closed  bool
+}
+
+// Offer records the message or returns the next result if negative.
+func (p *MockPublication) Offer(buffer *atomic.Buffer, offset, length int32, _ term.ReservedValueSupplier) int64 {
+	if len(p.Results) > 0 {
+		result := p.Results[0]
+		p.Results = p.Results[1:]
+		if result == aeron.PublicationClosed {
+			p.Closed = true
+		}
+		if result < 0 {
+			return result
+		}
+	}
+	p.Offered = append(p.Offered, buffer.GetBytesArray(offset, length))
+	return int64(len(p.Offered)) * 1024
+}
+
+// TryClaim always reports backpressure so messages are offered instead.
+func (*MockPublication) TryClaim(int32, *logbuffer.Claim) int64 {
+	return aeron.BackPressured
+}
+
+// IsConnected always returns true.
+func (*MockPublication) IsConnected() bool {
+	return true
+}
+
+// IsClosed returns true if the publication was closed.
+func (p *MockPublication) IsClosed() bool {
+	return p.Closed
+}
+
+// RegistrationID returns a fixed registration ID.
+func (*MockPublication) RegistrationID() int64 {
+	return 1
+}
+
+// Close marks the publication as closed.
+func (p *MockPublication) Close() error {
+	p.Closed = true
+	return nil
+}
@@ -0,0 +1,101 @@
+// Package aeron contains the publishing logic shared by the Aeron output
+// plugins.
+package aeron
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+	"github.com/lirm/aeron-go/aeron/logbuffer/term"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+var (
+	// ErrNotConnected is returned if the publication has no subscribers
+	ErrNotConnected = errors.New("publication not connected")
+	// ErrClosed is returned if the publication was closed, e.g. due to a
+	// restart of the media driver, and must be recreated
+	ErrClosed = errors.New("publication closed")
+	// ErrBackPressured is returned if the subscribers cannot keep up
+	ErrBackPressured = errors.New("backpressure: publication buffer full")
+)
+
+// Publication is the part of an Aeron publication used for publishing
+type Publication interface {
+	Offer(buffer *atomic.Buffer, offset, length int32, reservedValueSupplier term.ReservedValueSupplier) int64
+	TryClaim(length int32, bufferClaim *logbuffer.Claim) int64
+	IsConnected() bool
+	IsClosed() bool
+	Close() error
+}
+
+// Retry defines how often and how fast offering a message to a publication is
+// retried on transient failures like backpressure
+type Retry struct {
+	MaxRetries        int
+	Delay             time.Duration
+	BackoffMultiplier float64
+	MaxDelay          time.Duration
+
+	// Optional statistics of the retries and failures
+	Attempts      selfstat.Stat
+	BackPressured selfstat.Stat
+	NotConnected  selfstat.Stat
+	Closed        selfstat.Stat
+}
+
+// Offer calls the given function offering a message to a publication until
+// the message is published or the retries are exhausted. The function must
+// return the result of the Offer or TryClaim call of the publication. A closed
+// publication is not retried and results in ErrClosed.
+func (r *Retry) Offer(offer func() int64) error {
+	var lastErr error
+	delay := r.Delay
+
+	for attempt := 0; attempt <= r.MaxRetries; attempt++ {
+		if attempt > 0 {
+			incr(r.Attempts)
+			time.Sleep(delay)
+			if r.BackoffMultiplier > 1 {
+				delay = time.Duration(float64(delay) * r.BackoffMultiplier)
+			}
+			if r.MaxDelay > 0 && delay > r.MaxDelay {
+				delay = r.MaxDelay
+			}
+		}
+
+		result := offer()
+		switch result {
+		case aeron.BackPressured:
+			incr(r.BackPressured)
+			lastErr = ErrBackPressured
+		case aeron.NotConnected:
+			incr(r.NotConnected)
+			lastErr = ErrNotConnected
+		case aeron.AdminAction:
+			lastErr = errors.New("admin action required")
+		case aeron.PublicationClosed:
+			incr(r.Closed)
+			return ErrClosed
+		default:
+			if result > 0 {
+				// Success, the result is the new stream position
+				return nil
+			}
+			lastErr = fmt.Errorf("unknown result code: %d", result)
+		}
+	}
+
+	return fmt.Errorf("failed after %d retries: %w", r.MaxRetries, lastErr)
+}
+
+func incr(stat selfstat.Stat) {
+	if stat != nil {
+		stat.Incr(1)
+	}
+}
@@ -0,0 +1,75 @@
+package aeron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+func TestOffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []int64
+		calls    int
+		expected error
+	}{
+		{
+			name:    "success",
+			results: []int64{128},
+			calls:   1,
+		},
+		{
+			name:    "success after backpressure",
+			results: []int64{aeron.BackPressured, aeron.AdminAction, 128},
+			calls:   3,
+		},
+		{
+			name:     "backpressure",
+			results:  []int64{aeron.BackPressured, aeron.BackPressured, aeron.BackPressured, aeron.BackPressured},
+			calls:    4,
+			expected: ErrBackPressured,
+		},
+		{
+			name:     "not connected",
+			results:  []int64{aeron.NotConnected, aeron.NotConnected, aeron.NotConnected, aeron.NotConnected},
+			calls:    4,
+			expected: ErrNotConnected,
+		},
+		{
+			name:     "closed",
+			results:  []int64{aeron.BackPressured, aeron.PublicationClosed, 128},
+			calls:    2,
+			expected: ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry := &Retry{
+				MaxRetries:        3,
+				Delay:             time.Microsecond,
+				BackoffMultiplier: 2,
+				MaxDelay:          10 * time.Microsecond,
+				Attempts:          selfstat.Register("test", "attempts", map[string]string{"test": tt.name}),
+			}
+
+			var calls int
+			err := retry.Offer(func() int64 {
+				result := tt.results[calls]
+				calls++
+				return result
+			})
+			if tt.expected == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.expected)
+			}
+			require.Equal(t, tt.calls, calls)
+			require.Equal(t, int64(tt.calls-1), retry.Attempts.Get())
+		})
+	}
+}
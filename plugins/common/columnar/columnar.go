@@ -0,0 +1,176 @@
+// Package columnar contains the conversion of metric values from and to
+// Apache Arrow columns shared by the columnar data formats.
+package columnar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// TagType is the type of dictionary-encoded tag columns
+var TagType = &arrow.DictionaryType{
+	IndexType: arrow.PrimitiveTypes.Int32,
+	ValueType: arrow.BinaryTypes.String,
+}
+
+// DataType returns the Arrow data type for the given field value
+func DataType(value interface{}) (arrow.DataType, error) {
+	switch value.(type) {
+	case int8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case int16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case int32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case int64, int:
+		return arrow.PrimitiveTypes.Int64, nil
+	case uint8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case uint16:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case uint32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case uint64, uint:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case float32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case float64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case string:
+		return arrow.BinaryTypes.String, nil
+	case bool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", value)
+	}
+}
+
+// MergeTypes returns a type able to hold the values of both types. Numbers of
+// different types are widened to float64, all other combinations to string.
+func MergeTypes(a, b arrow.DataType) arrow.DataType {
+	if arrow.TypeEqual(a, b) {
+		return a
+	}
+	if isNumeric(a) && isNumeric(b) {
+		return arrow.PrimitiveTypes.Float64
+	}
+	return arrow.BinaryTypes.String
+}
+
+func isNumeric(t arrow.DataType) bool {
+	return arrow.IsInteger(t.ID()) || arrow.IsFloating(t.ID())
+}
+
+// Append appends the value to the builder converting it to the type of the
+// builder. Nil values are appended as null.
+func Append(builder array.Builder, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+
+	switch b := builder.(type) {
+	case *array.Int8Builder:
+		return appendConverted(b, value, internal.ToInt8)
+	case *array.Int16Builder:
+		return appendConverted(b, value, internal.ToInt16)
+	case *array.Int32Builder:
+		return appendConverted(b, value, internal.ToInt32)
+	case *array.Int64Builder:
+		return appendConverted(b, value, internal.ToInt64)
+	case *array.Uint8Builder:
+		return appendConverted(b, value, internal.ToUint8)
+	case *array.Uint16Builder:
+		return appendConverted(b, value, internal.ToUint16)
+	case *array.Uint32Builder:
+		return appendConverted(b, value, internal.ToUint32)
+	case *array.Uint64Builder:
+		return appendConverted(b, value, internal.ToUint64)
+	case *array.Float32Builder:
+		return appendConverted(b, value, internal.ToFloat32)
+	case *array.Float64Builder:
+		return appendConverted(b, value, internal.ToFloat64)
+	case *array.StringBuilder:
+		return appendConverted(b, value, internal.ToString)
+	case *array.BooleanBuilder:
+		return appendConverted(b, value, internal.ToBool)
+	case *array.TimestampBuilder:
+		if t, ok := value.(time.Time); ok {
+			b.AppendTime(t)
+			return nil
+		}
+		v, err := internal.ToInt64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(arrow.Timestamp(v))
+		return nil
+	case *array.BinaryDictionaryBuilder:
+		v, err := internal.ToString(value)
+		if err != nil {
+			return err
+		}
+		return b.AppendString(v)
+	}
+	return fmt.Errorf("unsupported type: %s", builder.Type())
+}
+
+func appendConverted[T any](b interface{ Append(T) }, value interface{}, convert func(interface{}) (T, error)) error {
+	v, err := convert(value)
+	if err != nil {
+		return err
+	}
+	b.Append(v)
+	return nil
+}
+
+// Value returns the value at the given index of the array. Integers are
+// returned as int64 or uint64, floating-point numbers as float64 and
+// timestamps as time.Time. Null values are returned as nil.
+func Value(arr arrow.Array, i int) (interface{}, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+
+	switch a := arr.(type) {
+	case *array.Int8:
+		return int64(a.Value(i)), nil
+	case *array.Int16:
+		return int64(a.Value(i)), nil
+	case *array.Int32:
+		return int64(a.Value(i)), nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Uint8:
+		return uint64(a.Value(i)), nil
+	case *array.Uint16:
+		return uint64(a.Value(i)), nil
+	case *array.Uint32:
+		return uint64(a.Value(i)), nil
+	case *array.Uint64:
+		return a.Value(i), nil
+	case *array.Float32:
+		return float64(a.Value(i)), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.String:
+		return a.Value(i), nil
+	case *array.LargeString:
+		return a.Value(i), nil
+	case *array.Binary:
+		return string(a.Value(i)), nil
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Timestamp:
+		unit := a.DataType().(*arrow.TimestampType).Unit
+		return a.Value(i).ToTime(unit), nil
+	case *array.Dictionary:
+		return Value(a.Dictionary(), a.GetValueIndex(i))
+	}
+	return nil, fmt.Errorf("unsupported type: %s", arr.DataType())
+}
@@ -0,0 +1,85 @@
+package mediadriver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron/counters"
+	rb "github.com/lirm/aeron-go/aeron/ringbuffer"
+	"github.com/lirm/aeron-go/aeron/util"
+)
+
+// ErrCncNotReady is returned if the media driver did not finish writing the
+// CnC file yet
+var ErrCncNotReady = errors.New("CnC file not ready")
+
+// checkHeartbeat checks if the media driver in the given directory updated its
+// heartbeat within the given timeout
+func checkHeartbeat(dir string, timeout time.Duration) error {
+	heartbeat, err := readHeartbeat(dir)
+	if err != nil {
+		return err
+	}
+
+	if age := time.Since(heartbeat); age > timeout {
+		return fmt.Errorf("heartbeat is stale since %s", age.Truncate(time.Millisecond))
+	}
+	return nil
+}
+
+// readHeartbeat returns the last heartbeat of the media driver in the given
+// directory. The CnC version is checked before mapping the file as the
+// mapping cannot be released for incompatible versions.
+func readHeartbeat(dir string) (time.Time, error) {
+	cncFileName := filepath.Join(dir, counters.CncFile)
+
+	version, err := ReadCncVersion(cncFileName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if version == 0 {
+		return time.Time{}, ErrCncNotReady
+	}
+	if version != counters.CurrentCncVersion {
+		return time.Time{}, fmt.Errorf(
+			"incompatible CnC version %s, expected %s",
+			util.SemanticVersionToString(uint32(version)),
+			util.SemanticVersionToString(uint32(counters.CurrentCncVersion)),
+		)
+	}
+
+	counterFile, cncFile, err := counters.MapFile(cncFileName)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to map CnC file %s: %w", cncFileName, err)
+	}
+	defer cncFile.Close()
+
+	var toDriver rb.ManyToOne
+	toDriver.Init(counterFile.ToDriverBuf.Get())
+
+	return time.UnixMilli(toDriver.ConsumerHeartbeatTime()), nil
+}
+
+// ReadCncVersion reads the version of the CnC file without mapping the file.
+// The version is zero while the media driver is initializing the file.
+func ReadCncVersion(filename string) (int32, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var buf [4]byte
+	if _, err := io.ReadFull(f, buf[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(buf[:])), nil
+}
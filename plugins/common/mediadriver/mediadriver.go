@@ -0,0 +1,297 @@
+// Package mediadriver launches and supervises Aeron media driver processes
+// shared by the Aeron plugins using the same Aeron directory.
+package mediadriver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/process"
+)
+
+// Interval for checking the driver heartbeat while supervising the driver
+var checkInterval = time.Second
+
+// Interval for polling the driver heartbeat while waiting for the driver
+var pollInterval = 100 * time.Millisecond
+
+var (
+	drivers     = make(map[string]*driver)
+	driversLock sync.Mutex
+)
+
+// Config is the configuration of a media driver launched by Telegraf
+type Config struct {
+	Command          []string          `toml:"command"`
+	Environment      []string          `toml:"environment"`
+	Properties       map[string]string `toml:"properties"`
+	RestartDelay     config.Duration   `toml:"restart_delay"`
+	HeartbeatTimeout config.Duration   `toml:"heartbeat_timeout"`
+}
+
+// Init sets the defaults and validates the configuration
+func (c *Config) Init() error {
+	if c.RestartDelay == 0 {
+		c.RestartDelay = config.Duration(5 * time.Second)
+	}
+	if c.HeartbeatTimeout == 0 {
+		c.HeartbeatTimeout = config.Duration(10 * time.Second)
+	}
+
+	if len(c.Command) == 0 {
+		return errors.New("media driver command is required")
+	}
+	if _, found := c.Properties["aeron.dir"]; found {
+		return errors.New("media driver property aeron.dir must not be set, use aeron_dir instead")
+	}
+	return nil
+}
+
+// Dir returns the Aeron directory used by the clients for the given setting
+func Dir(dir string) string {
+	if dir == "" {
+		dir = aeron.DefaultAeronDir + "/aeron-" + aeron.UserName
+	}
+	return filepath.Clean(dir)
+}
+
+// Register declares the media driver of the given Aeron directory to be
+// launched by Telegraf. The driver is launched by the first plugin acquiring
+// the directory and stopped once all plugins released it. Plugins sharing the
+// directory must not use different configurations.
+func Register(dir string, cfg *Config, log telegraf.Logger) error {
+	if err := cfg.Init(); err != nil {
+		return err
+	}
+	dir = Dir(dir)
+
+	driversLock.Lock()
+	defer driversLock.Unlock()
+
+	if d, found := drivers[dir]; found {
+		d.Lock()
+		defer d.Unlock()
+
+		if reflect.DeepEqual(d.cfg, *cfg) {
+			return nil
+		}
+		if d.refs > 0 {
+			return fmt.Errorf("conflicting media driver configuration for %q", dir)
+		}
+		log.Warnf("Replacing media driver configuration for %q", dir)
+		d.cfg = *cfg
+		d.log = log
+		return nil
+	}
+
+	drivers[dir] = &driver{dir: dir, cfg: *cfg, log: log}
+	return nil
+}
+
+// Acquire waits for the media driver of the given Aeron directory to become
+// healthy, launching the driver first if it is registered and not running
+// yet. The returned function releases the driver and must be called once the
+// plugin stops using the directory. For directories without a registered
+// driver, Acquire returns immediately and the clients are expected to connect
+// to an externally managed driver.
+func Acquire(dir string, timeout time.Duration) (func(), error) {
+	dir = Dir(dir)
+
+	driversLock.Lock()
+	d, found := drivers[dir]
+	driversLock.Unlock()
+	if !found {
+		return func() {}, nil
+	}
+
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	release := func() { once.Do(d.release) }
+	if err := waitHealthy(dir, timeout, time.Duration(d.cfg.HeartbeatTimeout)); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// driver is a media driver process shared by the plugins using the directory
+type driver struct {
+	dir string
+	cfg Config
+	log telegraf.Logger
+
+	sync.Mutex
+	refs    int
+	process *process.Process
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// acquire launches the driver process if this is the first reference
+func (d *driver) acquire() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.refs == 0 {
+		if err := d.start(); err != nil {
+			return err
+		}
+	}
+	d.refs++
+	return nil
+}
+
+// release stops the driver process if this was the last reference
+func (d *driver) release() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.refs--
+	if d.refs > 0 {
+		return
+	}
+	d.stop()
+}
+
+func (d *driver) start() error {
+	p, err := process.New(d.command(), d.cfg.Environment)
+	if err != nil {
+		return fmt.Errorf("creating media driver process failed: %w", err)
+	}
+	p.RestartDelay = time.Duration(d.cfg.RestartDelay)
+	p.ReadStdoutFn = readOutput(d.log.Debug)
+	p.ReadStderrFn = readOutput(d.log.Warn)
+	p.Log = d.log
+
+	if err := p.Start(); err != nil {
+		return fmt.Errorf("starting media driver %s failed: %w", d.cfg.Command, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.process = p
+	d.cancel = cancel
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.supervise(ctx, p)
+	}()
+
+	return nil
+}
+
+func (d *driver) stop() {
+	if d.process == nil {
+		return
+	}
+
+	d.cancel()
+	d.wg.Wait()
+	d.process.Stop()
+	d.process = nil
+}
+
+// command returns the driver command with the Aeron directory and the
+// configured properties passed as system properties
+func (d *driver) command() []string {
+	keys := make([]string, 0, len(d.cfg.Properties))
+	for key := range d.cfg.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cmd := make([]string, 0, len(d.cfg.Command)+len(keys)+1)
+	cmd = append(cmd, d.cfg.Command[0], "-Daeron.dir="+d.dir)
+	for _, key := range keys {
+		cmd = append(cmd, "-D"+key+"="+d.cfg.Properties[key])
+	}
+	return append(cmd, d.cfg.Command[1:]...)
+}
+
+// supervise kills the driver process if the driver stops updating its
+// heartbeat so the process is restarted. A restarted process is given the
+// heartbeat timeout to become healthy.
+func (d *driver) supervise(ctx context.Context, p *process.Process) {
+	timeout := time.Duration(d.cfg.HeartbeatTimeout)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	pid := p.Pid()
+	lastHealthy := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if current := p.Pid(); current != pid {
+			pid = current
+			lastHealthy = time.Now()
+			continue
+		}
+
+		err := checkHeartbeat(d.dir, timeout)
+		if err == nil {
+			lastHealthy = time.Now()
+			continue
+		}
+		if time.Since(lastHealthy) < timeout {
+			continue
+		}
+
+		d.log.Errorf("Media driver with PID %d is unhealthy, killing process: %v", pid, err)
+		if proc, err := os.FindProcess(pid); err == nil {
+			if err := proc.Kill(); err != nil {
+				d.log.Errorf("Killing media driver failed: %v", err)
+			}
+		}
+		lastHealthy = time.Now()
+	}
+}
+
+// waitHealthy polls the heartbeat of the driver in the given directory until
+// the driver is healthy or the timeout elapsed
+func waitHealthy(dir string, timeout, heartbeatTimeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := checkHeartbeat(dir, heartbeatTimeout)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("media driver in %q not available within %s: %w", dir, timeout, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// readOutput returns a function logging each line of the process output
+func readOutput(logf func(args ...interface{})) func(io.Reader) {
+	return func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			logf(scanner.Text())
+		}
+		// Keep draining the pipe on overlong lines to not block the process
+		//nolint:errcheck // Discarding the data, no need to handle an error
+		io.Copy(io.Discard, r)
+	}
+}
@@ -0,0 +1,191 @@
+package mediadriver
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron/counters"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestConfig_Init(t *testing.T) {
+	cfg := &Config{Command: []string{"aeronmd"}}
+	require.NoError(t, cfg.Init())
+	require.Equal(t, config.Duration(5*time.Second), cfg.RestartDelay)
+	require.Equal(t, config.Duration(10*time.Second), cfg.HeartbeatTimeout)
+
+	require.ErrorContains(t, (&Config{}).Init(), "command is required")
+
+	cfg = &Config{
+		Command:    []string{"aeronmd"},
+		Properties: map[string]string{"aeron.dir": "/tmp/aeron"},
+	}
+	require.ErrorContains(t, cfg.Init(), "use aeron_dir instead")
+}
+
+func TestCommand(t *testing.T) {
+	d := &driver{
+		dir: "/dev/shm/aeron",
+		cfg: Config{
+			Command: []string{"java", "-cp", "aeron-all.jar", "io.aeron.driver.MediaDriver"},
+			Properties: map[string]string{
+				"aeron.threading.mode":      "SHARED",
+				"aeron.dir.delete.on.start": "true",
+			},
+		},
+	}
+
+	expected := []string{
+		"java",
+		"-Daeron.dir=/dev/shm/aeron",
+		"-Daeron.dir.delete.on.start=true",
+		"-Daeron.threading.mode=SHARED",
+		"-cp", "aeron-all.jar", "io.aeron.driver.MediaDriver",
+	}
+	require.Equal(t, expected, d.command())
+}
+
+func TestRegister_Conflict(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, Register(dir, &Config{Command: []string{"aeronmd"}}, testutil.Logger{}))
+	require.NoError(t, Register(dir, &Config{Command: []string{"aeronmd"}}, testutil.Logger{}))
+
+	drivers[dir].refs++
+	defer func() { drivers[dir].refs-- }()
+	require.ErrorContains(t, Register(dir, &Config{Command: []string{"java"}}, testutil.Logger{}), "conflicting")
+}
+
+func TestCheckHeartbeat(t *testing.T) {
+	dir := t.TempDir()
+	require.ErrorIs(t, checkHeartbeat(dir, time.Second), os.ErrNotExist)
+
+	writeTestCncFile(t, dir, 0, time.Now())
+	require.ErrorIs(t, checkHeartbeat(dir, time.Second), ErrCncNotReady)
+
+	writeTestCncFile(t, dir, 1<<16, time.Now())
+	require.ErrorContains(t, checkHeartbeat(dir, time.Second), "incompatible CnC version 1.0.0")
+
+	writeTestCncFile(t, dir, counters.CurrentCncVersion, time.Now().Add(-time.Minute))
+	require.ErrorContains(t, checkHeartbeat(dir, time.Second), "heartbeat is stale")
+
+	writeTestCncFile(t, dir, counters.CurrentCncVersion, time.Now())
+	require.NoError(t, checkHeartbeat(dir, time.Second))
+}
+
+func TestAcquire_Unmanaged(t *testing.T) {
+	release, err := Acquire(t.TempDir(), time.Second)
+	require.NoError(t, err)
+	release()
+}
+
+func TestAcquire_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows as the driver is a shell script")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, Register(dir, &Config{Command: []string{testDriver(t)}}, testutil.Logger{}))
+
+	_, err := Acquire(dir, 200*time.Millisecond)
+	require.ErrorContains(t, err, "not available within")
+	require.Zero(t, drivers[dir].refs)
+	require.Nil(t, drivers[dir].process)
+}
+
+func TestAcquire_Shared(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows as the driver is a shell script")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, Register(dir, &Config{Command: []string{testDriver(t)}}, testutil.Logger{}))
+	writeTestCncFile(t, dir, counters.CurrentCncVersion, time.Now())
+
+	release1, err := Acquire(dir, time.Second)
+	require.NoError(t, err)
+	pid := drivers[dir].process.Pid()
+	require.NotZero(t, pid)
+
+	// A second plugin shares the running driver
+	release2, err := Acquire(dir, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, pid, drivers[dir].process.Pid())
+
+	release1()
+	release1()
+	require.NotNil(t, drivers[dir].process)
+
+	release2()
+	require.Nil(t, drivers[dir].process)
+}
+
+func TestSupervise_RestartHungDriver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows as the driver is a shell script")
+	}
+
+	interval := checkInterval
+	checkInterval = 10 * time.Millisecond
+	defer func() { checkInterval = interval }()
+
+	dir := t.TempDir()
+	cfg := &Config{
+		Command:          []string{testDriver(t)},
+		RestartDelay:     config.Duration(10 * time.Millisecond),
+		HeartbeatTimeout: config.Duration(100 * time.Millisecond),
+	}
+	require.NoError(t, Register(dir, cfg, testutil.Logger{}))
+	writeTestCncFile(t, dir, counters.CurrentCncVersion, time.Now())
+
+	release, err := Acquire(dir, time.Second)
+	require.NoError(t, err)
+	defer release()
+
+	// The driver stops updating its heartbeat and is restarted
+	p := drivers[dir].process
+	pid := p.Pid()
+	require.Eventually(t, func() bool {
+		return p.Pid() != pid
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// testDriver creates a script standing in for the media driver
+func testDriver(t *testing.T) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "driver.sh")
+	require.NoError(t, os.WriteFile(filename, []byte("#!/bin/sh\nexec cat >/dev/null\n"), 0o700)) //nolint:gosec // script must be executable
+	return filename
+}
+
+// writeTestCncFile writes a CnC file with the given version and driver
+// heartbeat. The file is renamed into place to avoid partial reads.
+func writeTestCncFile(t *testing.T, dir string, version int32, heartbeat time.Time) {
+	t.Helper()
+
+	const (
+		headerLength   = 128
+		toDriverLength = 1024 + 768 // capacity plus ring buffer trailer
+		heartbeatIndex = headerLength + 1024 + 640
+	)
+
+	buf := make([]byte, headerLength+toDriverLength)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(version))
+	binary.LittleEndian.PutUint32(buf[4:], toDriverLength)
+	binary.LittleEndian.PutUint64(buf[24:], uint64(10*time.Second/time.Millisecond))
+	binary.LittleEndian.PutUint64(buf[32:], uint64(time.Now().UnixMilli()))
+	binary.LittleEndian.PutUint64(buf[40:], uint64(os.Getpid()))
+	binary.LittleEndian.PutUint64(buf[heartbeatIndex:], uint64(heartbeat.UnixMilli()))
+
+	tmp := filepath.Join(dir, counters.CncFile+".tmp")
+	require.NoError(t, os.WriteFile(tmp, buf, 0o600))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, counters.CncFile)))
+}
@@ -0,0 +1,295 @@
+package sbe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Record contains the decoded values of a message or group entry keyed by the
+// field name. Composite members, set choices and array elements are flattened
+// using the separator.
+type Record struct {
+	Values map[string]interface{}
+	Groups map[string][]*Record
+}
+
+// Flatten returns all values of the record including the group entries keyed
+// by "<group><separator><index><separator><name>".
+func (r *Record) Flatten(separator string) map[string]interface{} {
+	values := make(map[string]interface{}, len(r.Values))
+	for k, v := range r.Values {
+		values[k] = v
+	}
+	for name, entries := range r.Groups {
+		for i, entry := range entries {
+			prefix := name + separator + strconv.Itoa(i) + separator
+			for k, v := range entry.Flatten(separator) {
+				values[prefix+k] = v
+			}
+		}
+	}
+	return values
+}
+
+// Decoder decodes SBE messages of a schema
+type Decoder struct {
+	Schema    *Schema
+	Separator string
+}
+
+// Decode decodes the message at the start of the buffer and returns the
+// message template, the decoded values and the number of bytes consumed.
+func (d *Decoder) Decode(buf []byte) (*Message, *Record, int, error) {
+	s := d.Schema
+	if len(buf) < s.Header.Size() {
+		return nil, nil, 0, fmt.Errorf("message of %d bytes too short for header", len(buf))
+	}
+
+	header := make(map[string]uint64, len(s.Header.Members))
+	for _, member := range s.Header.Members {
+		if member.Kind != KindPrimitive || member.Presence == PresenceConstant {
+			continue
+		}
+		v := d.primitive(member.Primitive, buf[member.Offset:])
+		if u, ok := v.(uint64); ok {
+			header[member.Name] = u
+		}
+	}
+	if id, found := header["schemaId"]; found && id != s.ID {
+		return nil, nil, 0, fmt.Errorf("schema ID %d does not match %d", id, s.ID)
+	}
+	msg, found := s.Message(header["templateId"])
+	if !found {
+		return nil, nil, 0, fmt.Errorf("unknown template ID %d", header["templateId"])
+	}
+	version, found := header["version"]
+	if !found {
+		version = s.Version
+	}
+
+	rec, n, err := d.block(&msg.Block, buf, s.Header.Size(), int(header["blockLength"]), version)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("decoding message %q: %w", msg.Name, err)
+	}
+	return msg, rec, n, nil
+}
+
+// block decodes the fixed-length part of the given length starting at the
+// position followed by the groups and data. It returns the record and the
+// position after the block.
+func (d *Decoder) block(b *Block, buf []byte, pos, blockLength int, version uint64) (*Record, int, error) {
+	if pos+blockLength > len(buf) {
+		return nil, 0, fmt.Errorf("block of %d bytes exceeds the message", blockLength)
+	}
+	rec := &Record{Values: make(map[string]interface{})}
+
+	// Fields outside of the block length were added in a newer version than
+	// the encoder uses
+	fixed := buf[pos : pos+blockLength]
+	for _, f := range b.Fields {
+		if f.SinceVersion > version {
+			continue
+		}
+		if f.Presence == PresenceConstant {
+			if v, err := constant(f.Type, f.Constant); err == nil {
+				rec.Values[f.Name] = v
+			}
+			continue
+		}
+		if f.Offset+f.Type.Size() > len(fixed) {
+			continue
+		}
+		d.value(f.Type, f.Presence, fixed[f.Offset:], f.Name, rec.Values)
+	}
+	pos += blockLength
+
+	for _, g := range b.Groups {
+		if g.SinceVersion > version {
+			continue
+		}
+		dim := g.Dimension
+		if pos+dim.Size() > len(buf) {
+			return nil, 0, fmt.Errorf("group %q exceeds the message", g.Name)
+		}
+		entryLength, err := d.unsigned(dim.Member("blockLength"), buf[pos:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("group %q: %w", g.Name, err)
+		}
+		count, err := d.unsigned(dim.Member("numInGroup"), buf[pos:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("group %q: %w", g.Name, err)
+		}
+		pos += dim.Size()
+
+		// Avoid allocating huge groups for malformed messages
+		if count > uint64(len(buf)-pos) && entryLength > 0 {
+			return nil, 0, fmt.Errorf("group %q with %d entries exceeds the message", g.Name, count)
+		}
+		entries := make([]*Record, 0, min(count, uint64(len(buf))))
+		for range count {
+			var entry *Record
+			entry, pos, err = d.block(&g.Block, buf, pos, int(entryLength), version)
+			if err != nil {
+				return nil, 0, fmt.Errorf("group %q: %w", g.Name, err)
+			}
+			entries = append(entries, entry)
+		}
+		if rec.Groups == nil {
+			rec.Groups = make(map[string][]*Record)
+		}
+		rec.Groups[g.Name] = entries
+	}
+
+	for _, data := range b.Data {
+		if data.SinceVersion > version {
+			continue
+		}
+		length := data.Type.Member("length")
+		if pos+length.Offset+length.Size() > len(buf) {
+			return nil, 0, fmt.Errorf("data %q exceeds the message", data.Name)
+		}
+		n, err := d.unsigned(length, buf[pos:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("data %q: %w", data.Name, err)
+		}
+		start := pos + data.Type.Member("varData").Offset
+		if n > uint64(len(buf)-start) {
+			return nil, 0, fmt.Errorf("data %q of %d bytes exceeds the message", data.Name, n)
+		}
+		rec.Values[data.Name] = string(buf[start : start+int(n)])
+		pos = start + int(n)
+	}
+
+	return rec, pos, nil
+}
+
+// value decodes the type at the start of the buffer into the values
+func (d *Decoder) value(t *Type, presence string, buf []byte, name string, values map[string]interface{}) {
+	switch t.Kind {
+	case KindPrimitive:
+		size := primitiveSize(t.Primitive)
+		if t.Primitive == "char" {
+			v := string(buf[:t.Length])
+			if i := bytes.IndexByte(buf[:t.Length], 0); i >= 0 {
+				v = v[:i]
+			}
+			if presence != PresenceOptional || v != "" {
+				values[name] = v
+			}
+			return
+		}
+		if t.Length == 1 {
+			v := d.primitive(t.Primitive, buf)
+			if presence != PresenceOptional || !isNull(t, v) {
+				values[name] = v
+			}
+			return
+		}
+		for i := range t.Length {
+			v := d.primitive(t.Primitive, buf[i*size:])
+			if presence != PresenceOptional || !isNull(t, v) {
+				values[name+d.Separator+strconv.Itoa(i)] = v
+			}
+		}
+	case KindComposite:
+		for _, m := range t.Members {
+			member := name + d.Separator + m.Name
+			if m.Presence == PresenceConstant {
+				if v, err := constant(m, m.Constant); err == nil {
+					values[member] = v
+				}
+				continue
+			}
+			p := m.Presence
+			if presence == PresenceOptional {
+				p = PresenceOptional
+			}
+			d.value(m, p, buf[m.Offset:], member, values)
+		}
+	case KindEnum:
+		raw := d.primitive(t.Primitive, buf)
+		if isNull(t, raw) {
+			if presence != PresenceOptional {
+				values[name] = raw
+			}
+			return
+		}
+		for _, ev := range t.Values {
+			if v, err := parseValue(t.Primitive, ev.Value); err == nil && v == raw {
+				values[name] = ev.Name
+				return
+			}
+		}
+		values[name] = raw
+	case KindSet:
+		raw, _ := d.primitive(t.Primitive, buf).(uint64)
+		for _, c := range t.Choices {
+			values[name+d.Separator+c.Name] = raw&(1<<c.Bit) != 0
+		}
+	}
+}
+
+// unsigned decodes an unsigned integer member of a composite
+func (d *Decoder) unsigned(t *Type, buf []byte) (uint64, error) {
+	if t.Kind != KindPrimitive {
+		return 0, fmt.Errorf("%q is not a primitive type", t.Name)
+	}
+	switch v := d.primitive(t.Primitive, buf[t.Offset:]).(type) {
+	case uint64:
+		return v, nil
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("negative %q", t.Name)
+		}
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("%q is not an integer", t.Name)
+}
+
+// primitive decodes a single primitive value, integers are returned as int64
+// or uint64 and floating-point numbers as float64
+func (d *Decoder) primitive(primitive string, buf []byte) interface{} {
+	order := d.Schema.ByteOrder
+	switch primitive {
+	case "char":
+		if buf[0] == 0 {
+			return ""
+		}
+		return string(buf[:1])
+	case "int8":
+		return int64(int8(buf[0]))
+	case "uint8":
+		return uint64(buf[0])
+	case "int16":
+		return int64(int16(order.Uint16(buf)))
+	case "uint16":
+		return uint64(order.Uint16(buf))
+	case "int32":
+		return int64(int32(order.Uint32(buf)))
+	case "uint32":
+		return uint64(order.Uint32(buf))
+	case "int64":
+		return int64(order.Uint64(buf))
+	case "uint64":
+		return order.Uint64(buf)
+	case "float":
+		return float64(math.Float32frombits(order.Uint32(buf)))
+	case "double":
+		return math.Float64frombits(order.Uint64(buf))
+	}
+	return nil
+}
+
+// constant returns the value of a constant of the given type
+func constant(t *Type, value string) (interface{}, error) {
+	if value == "" {
+		return nil, errors.New("empty constant")
+	}
+	if t.Kind == KindEnum {
+		return value, nil
+	}
+	return parseValue(t.Primitive, value)
+}
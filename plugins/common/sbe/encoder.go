@@ -0,0 +1,339 @@
+package sbe
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Encoder encodes SBE messages of a schema
+type Encoder struct {
+	Schema    *Schema
+	Separator string
+}
+
+// Encode encodes the given values as the message including the header. The
+// values are keyed the same way as the flattened record returned by the
+// decoder. Missing optional values are encoded as null.
+func (e *Encoder) Encode(msg *Message, values map[string]interface{}) ([]byte, error) {
+	s := e.Schema
+	buf := make([]byte, s.Header.Size())
+	for _, member := range s.Header.Members {
+		if member.Kind != KindPrimitive || member.Presence == PresenceConstant {
+			continue
+		}
+		var v uint64
+		switch member.Name {
+		case "blockLength":
+			v = uint64(msg.BlockLength)
+		case "templateId":
+			v = msg.ID
+		case "schemaId":
+			v = s.ID
+		case "version":
+			v = s.Version
+		case "numGroups":
+			v = uint64(len(msg.Groups))
+		case "numVarDataFields":
+			v = uint64(len(msg.Data))
+		}
+		if err := e.primitive(member.Primitive, buf[member.Offset:], v); err != nil {
+			return nil, fmt.Errorf("encoding header %q: %w", member.Name, err)
+		}
+	}
+
+	buf, err := e.block(&msg.Block, buf, values)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message %q: %w", msg.Name, err)
+	}
+	return buf, nil
+}
+
+// block appends the encoded block, groups and data to the buffer
+func (e *Encoder) block(b *Block, buf []byte, values map[string]interface{}) ([]byte, error) {
+	pos := len(buf)
+	buf = append(buf, make([]byte, b.BlockLength)...)
+	fixed := buf[pos:]
+	for _, f := range b.Fields {
+		if f.Presence == PresenceConstant {
+			continue
+		}
+		if err := e.value(f.Type, f.Presence, fixed[f.Offset:], f.Name, values); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, g := range b.Groups {
+		entries := e.entries(g.Name, values)
+
+		dim := make([]byte, g.Dimension.Size())
+		for _, member := range g.Dimension.Members {
+			if member.Kind != KindPrimitive || member.Presence == PresenceConstant {
+				continue
+			}
+			var v uint64
+			switch member.Name {
+			case "blockLength":
+				v = uint64(g.BlockLength)
+			case "numInGroup":
+				v = uint64(len(entries))
+			case "numGroups":
+				v = uint64(len(g.Groups))
+			case "numVarDataFields":
+				v = uint64(len(g.Data))
+			}
+			if err := e.primitive(member.Primitive, dim[member.Offset:], v); err != nil {
+				return nil, fmt.Errorf("group %q: %w", g.Name, err)
+			}
+		}
+		buf = append(buf, dim...)
+
+		for i, entry := range entries {
+			var err error
+			buf, err = e.block(&g.Block, buf, entry)
+			if err != nil {
+				return nil, fmt.Errorf("group %q entry %d: %w", g.Name, i, err)
+			}
+		}
+	}
+
+	for _, data := range b.Data {
+		var raw []byte
+		switch v := values[data.Name].(type) {
+		case nil:
+		case []byte:
+			raw = v
+		default:
+			str, err := internal.ToString(v)
+			if err != nil {
+				return nil, fmt.Errorf("data %q: %w", data.Name, err)
+			}
+			raw = []byte(str)
+		}
+
+		length := data.Type.Member("length")
+		header := make([]byte, data.Type.Member("varData").Offset)
+		if err := e.primitive(length.Primitive, header[length.Offset:], uint64(len(raw))); err != nil {
+			return nil, fmt.Errorf("data %q: %w", data.Name, err)
+		}
+		buf = append(buf, header...)
+		buf = append(buf, raw...)
+	}
+
+	return buf, nil
+}
+
+// entries collects the values of the group entries keyed by
+// "<group><separator><index><separator><name>" ordered by index. Missing
+// indices result in empty entries.
+func (e *Encoder) entries(group string, values map[string]interface{}) []map[string]interface{} {
+	prefix := group + e.Separator
+	var entries []map[string]interface{}
+	for k, v := range values {
+		key, found := strings.CutPrefix(k, prefix)
+		if !found {
+			continue
+		}
+		index, name, found := strings.Cut(key, e.Separator)
+		if !found {
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			continue
+		}
+		for len(entries) <= i {
+			entries = append(entries, make(map[string]interface{}))
+		}
+		entries[i][name] = v
+	}
+	return entries
+}
+
+// value encodes the named value of the type to the start of the buffer
+func (e *Encoder) value(t *Type, presence string, buf []byte, name string, values map[string]interface{}) error {
+	switch t.Kind {
+	case KindPrimitive:
+		if t.Primitive == "char" {
+			v, found := values[name]
+			if !found {
+				if presence != PresenceOptional && t.Length == 1 {
+					return fmt.Errorf("missing required value %q", name)
+				}
+				return nil
+			}
+			str, err := internal.ToString(v)
+			if err != nil {
+				return fmt.Errorf("value %q: %w", name, err)
+			}
+			if len(str) > t.Length {
+				return fmt.Errorf("value %q exceeds %d characters", name, t.Length)
+			}
+			copy(buf[:t.Length], str)
+			return nil
+		}
+		if t.Length == 1 {
+			return e.single(t, presence, buf, name, values)
+		}
+		size := primitiveSize(t.Primitive)
+		for i := range t.Length {
+			if err := e.single(t, presence, buf[i*size:], name+e.Separator+strconv.Itoa(i), values); err != nil {
+				return err
+			}
+		}
+	case KindComposite:
+		for _, m := range t.Members {
+			if m.Presence == PresenceConstant {
+				continue
+			}
+			p := m.Presence
+			if presence == PresenceOptional {
+				p = PresenceOptional
+			}
+			if err := e.value(m, p, buf[m.Offset:], name+e.Separator+m.Name, values); err != nil {
+				return err
+			}
+		}
+	case KindEnum:
+		v, found := values[name]
+		if !found {
+			if presence != PresenceOptional {
+				return fmt.Errorf("missing required value %q", name)
+			}
+			return e.primitive(t.Primitive, buf, nullValue(t))
+		}
+		if str, ok := v.(string); ok {
+			for _, ev := range t.Values {
+				if ev.Name == str {
+					raw, err := parseValue(t.Primitive, ev.Value)
+					if err != nil {
+						return fmt.Errorf("value %q: %w", name, err)
+					}
+					return e.primitive(t.Primitive, buf, raw)
+				}
+			}
+			if t.Primitive == "char" {
+				return fmt.Errorf("value %q: unknown enum value %q", name, str)
+			}
+		}
+		if err := e.primitive(t.Primitive, buf, v); err != nil {
+			return fmt.Errorf("value %q: %w", name, err)
+		}
+	case KindSet:
+		var raw uint64
+		for _, c := range t.Choices {
+			v, found := values[name+e.Separator+c.Name]
+			if !found {
+				continue
+			}
+			set, err := internal.ToBool(v)
+			if err != nil {
+				return fmt.Errorf("value %q: %w", name+e.Separator+c.Name, err)
+			}
+			if set {
+				raw |= 1 << c.Bit
+			}
+		}
+		return e.primitive(t.Primitive, buf, raw)
+	}
+	return nil
+}
+
+// single encodes a single primitive value writing null for missing optional
+// values
+func (e *Encoder) single(t *Type, presence string, buf []byte, name string, values map[string]interface{}) error {
+	v, found := values[name]
+	if !found {
+		if presence != PresenceOptional {
+			return fmt.Errorf("missing required value %q", name)
+		}
+		v = nullValue(t)
+	}
+	if err := e.primitive(t.Primitive, buf, v); err != nil {
+		return fmt.Errorf("value %q: %w", name, err)
+	}
+	return nil
+}
+
+// primitive encodes a single primitive value to the start of the buffer
+func (e *Encoder) primitive(primitive string, buf []byte, v interface{}) error {
+	order := e.Schema.ByteOrder
+	switch primitive {
+	case "char":
+		str, err := internal.ToString(v)
+		if err != nil {
+			return err
+		}
+		if len(str) > 1 {
+			return fmt.Errorf("%q exceeds a single character", str)
+		}
+		buf[0] = 0
+		copy(buf[:1], str)
+	case "int8":
+		x, err := internal.ToInt8(v)
+		if err != nil {
+			return err
+		}
+		buf[0] = byte(x)
+	case "uint8":
+		x, err := internal.ToUint8(v)
+		if err != nil {
+			return err
+		}
+		buf[0] = x
+	case "int16":
+		x, err := internal.ToInt16(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint16(buf, uint16(x))
+	case "uint16":
+		x, err := internal.ToUint16(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint16(buf, x)
+	case "int32":
+		x, err := internal.ToInt32(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint32(buf, uint32(x))
+	case "uint32":
+		x, err := internal.ToUint32(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint32(buf, x)
+	case "int64":
+		x, err := internal.ToInt64(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint64(buf, uint64(x))
+	case "uint64":
+		x, err := internal.ToUint64(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint64(buf, x)
+	case "float":
+		x, err := internal.ToFloat32(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint32(buf, math.Float32bits(x))
+	case "double":
+		x, err := internal.ToFloat64(v)
+		if err != nil {
+			return err
+		}
+		order.PutUint64(buf, math.Float64bits(x))
+	default:
+		return fmt.Errorf("unknown primitive type %q", primitive)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package sbe
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSchema(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	require.Equal(t, "telemetry", s.Package)
+	require.Equal(t, uint64(42), s.ID)
+	require.Equal(t, uint64(1), s.Version)
+	require.Equal(t, binary.LittleEndian, s.ByteOrder)
+	require.Equal(t, 8, s.Header.Size())
+
+	msg, found := s.MessageByName("Sensor")
+	require.True(t, found)
+	require.Equal(t, uint64(1), msg.ID)
+	// timestamp(8) + host(8) + state(1) + flags(1) + temperature(4) +
+	// position(16) + humidity(8)
+	require.Equal(t, 46, msg.BlockLength)
+	require.Len(t, msg.Groups, 1)
+	require.Equal(t, 5, msg.Groups[0].BlockLength)
+	require.Len(t, msg.Data, 1)
+
+	require.Len(t, s.Messages(), 2)
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{
+			name:     "invalid root",
+			schema:   `<types/>`,
+			expected: `unexpected root element "types"`,
+		},
+		{
+			name:     "missing header",
+			schema:   `<messageSchema><types/><message name="A" id="1"/></messageSchema>`,
+			expected: `unknown type "messageHeader"`,
+		},
+		{
+			name: "header without template",
+			schema: `<messageSchema><types>
+				<composite name="messageHeader"><type name="blockLength" primitiveType="uint16"/></composite>
+			</types><message name="A" id="1"/></messageSchema>`,
+			expected: `misses "templateId"`,
+		},
+		{
+			name: "circular type",
+			schema: `<messageSchema><types>
+				<composite name="messageHeader">
+					<type name="blockLength" primitiveType="uint16"/>
+					<type name="templateId" primitiveType="uint16"/>
+					<ref name="self" type="messageHeader"/>
+				</composite>
+			</types><message name="A" id="1"/></messageSchema>`,
+			expected: `circular reference of type "messageHeader"`,
+		},
+		{
+			name: "unknown field type",
+			schema: `<messageSchema><types>
+				<composite name="messageHeader">
+					<type name="blockLength" primitiveType="uint16"/>
+					<type name="templateId" primitiveType="uint16"/>
+				</composite>
+			</types><message name="A" id="1"><field name="f" id="1" type="missing"/></message></messageSchema>`,
+			expected: `unknown type "missing"`,
+		},
+		{
+			name: "no messages",
+			schema: `<messageSchema><types>
+				<composite name="messageHeader">
+					<type name="blockLength" primitiveType="uint16"/>
+					<type name="templateId" primitiveType="uint16"/>
+				</composite>
+			</types></messageSchema>`,
+			expected: "schema does not contain any message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(strings.NewReader(tt.schema))
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	msg, found := s.MessageByName("Sensor")
+	require.True(t, found)
+
+	values := map[string]interface{}{
+		"timestamp":           int64(1700000000000000000),
+		"host":                "node-1",
+		"state":               "Running",
+		"flags_calibrated":    true,
+		"flags_simulated":     false,
+		"temperature":         float64(21.5),
+		"position_x":          float64(1.25),
+		"position_y":          float64(-3.5),
+		"humidity":            float64(48.0),
+		"channels_0_index":    uint64(0),
+		"channels_0_value":    int64(-12),
+		"channels_1_index":    uint64(1),
+		"channels_1_value":    int64(34),
+		"location":            "rack 7",
+		"ignored_extra_value": "not in the schema",
+	}
+
+	encoder := &Encoder{Schema: s, Separator: "_"}
+	buf, err := encoder.Encode(msg, values)
+	require.NoError(t, err)
+	require.Len(t, buf, 8+46+4+2*5+4+6)
+
+	decoder := &Decoder{Schema: s, Separator: "_"}
+	decoded, rec, n, err := decoder.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+	require.Equal(t, len(buf), n)
+	require.Len(t, rec.Groups["channels"], 2)
+
+	expected := map[string]interface{}{
+		"timestamp":        int64(1700000000000000000),
+		"host":             "node-1",
+		"state":            "Running",
+		"flags_calibrated": true,
+		"flags_simulated":  false,
+		"temperature":      float64(21.5),
+		"position_x":       float64(1.25),
+		"position_y":       float64(-3.5),
+		"unit":             "C",
+		"humidity":         float64(48.0),
+		"channels_0_index": uint64(0),
+		"channels_0_value": int64(-12),
+		"channels_1_index": uint64(1),
+		"channels_1_value": int64(34),
+		"location":         "rack 7",
+	}
+	require.Equal(t, expected, rec.Flatten("_"))
+}
+
+func TestDecodeOptionalNull(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	msg, found := s.MessageByName("Sensor")
+	require.True(t, found)
+
+	// The optional temperature is encoded as null if missing
+	values := map[string]interface{}{
+		"timestamp":  int64(1),
+		"host":       "a",
+		"state":      "Idle",
+		"position_x": 0,
+		"position_y": 0,
+		"humidity":   0,
+	}
+	encoder := &Encoder{Schema: s, Separator: "_"}
+	buf, err := encoder.Encode(msg, values)
+	require.NoError(t, err)
+
+	decoder := &Decoder{Schema: s, Separator: "_"}
+	_, rec, _, err := decoder.Decode(buf)
+	require.NoError(t, err)
+	require.NotContains(t, rec.Values, "temperature")
+	require.Empty(t, rec.Groups["channels"])
+	require.Equal(t, "", rec.Values["location"])
+}
+
+func TestEncodeMissingRequired(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	msg, found := s.MessageByName("Heartbeat")
+	require.True(t, found)
+
+	encoder := &Encoder{Schema: s, Separator: "_"}
+	_, err = encoder.Encode(msg, map[string]interface{}{"timestamp": 1})
+	require.ErrorContains(t, err, `missing required value "sequence"`)
+}
+
+func TestDecodeForwardCompatibility(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	msg, found := s.MessageByName("Heartbeat")
+	require.True(t, found)
+
+	// A newer encoder appended a field to the block which must be skipped
+	buf := binary.LittleEndian.AppendUint16(nil, 16) // blockLength
+	buf = binary.LittleEndian.AppendUint16(buf, 2)   // templateId
+	buf = binary.LittleEndian.AppendUint16(buf, 42)  // schemaId
+	buf = binary.LittleEndian.AppendUint16(buf, 2)   // version
+	buf = binary.LittleEndian.AppendUint64(buf, 123) // timestamp
+	buf = binary.LittleEndian.AppendUint32(buf, 7)   // sequence
+	buf = binary.LittleEndian.AppendUint32(buf, 99)  // unknown field
+	buf = append(buf, 0xff)                          // next message
+
+	decoder := &Decoder{Schema: s, Separator: "_"}
+	decoded, rec, n, err := decoder.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+	require.Equal(t, len(buf)-1, n)
+	require.Equal(t, map[string]interface{}{"timestamp": int64(123), "sequence": uint64(7)}, rec.Values)
+}
+
+func TestDecodeOlderVersion(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	msg, found := s.MessageByName("Sensor")
+	require.True(t, found)
+
+	// Encode a message of version 0 without the humidity field
+	encoder := &Encoder{Schema: s, Separator: "_"}
+	buf, err := encoder.Encode(msg, map[string]interface{}{
+		"timestamp":  int64(1),
+		"host":       "a",
+		"state":      "Fault",
+		"position_x": 0,
+		"position_y": 0,
+		"humidity":   0,
+	})
+	require.NoError(t, err)
+	binary.LittleEndian.PutUint16(buf[0:], 38)
+	binary.LittleEndian.PutUint16(buf[6:], 0)
+	buf = append(buf[:8+38], buf[8+46:]...)
+
+	decoder := &Decoder{Schema: s, Separator: "_"}
+	_, rec, n, err := decoder.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+	require.Equal(t, "Fault", rec.Values["state"])
+	require.NotContains(t, rec.Values, "humidity")
+}
+
+func TestDecodeErrors(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.xml")
+	require.NoError(t, err)
+	decoder := &Decoder{Schema: s, Separator: "_"}
+
+	_, _, _, err = decoder.Decode([]byte{0x01, 0x02})
+	require.ErrorContains(t, err, "too short for header")
+
+	header := []byte{12, 0, 9, 0, 42, 0, 1, 0}
+	_, _, _, err = decoder.Decode(header)
+	require.ErrorContains(t, err, "unknown template ID 9")
+
+	header = []byte{12, 0, 2, 0, 7, 0, 1, 0}
+	_, _, _, err = decoder.Decode(header)
+	require.ErrorContains(t, err, "schema ID 7 does not match 42")
+
+	header = []byte{12, 0, 2, 0, 42, 0, 1, 0, 1, 2, 3}
+	_, _, _, err = decoder.Decode(header)
+	require.ErrorContains(t, err, "exceeds the message")
+}
@@ -0,0 +1,632 @@
+// Package sbe implements decoding and encoding of Simple Binary Encoding (SBE)
+// messages described by an SBE XML message schema.
+package sbe
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Kind of an encoded type
+type Kind int
+
+const (
+	KindPrimitive Kind = iota
+	KindComposite
+	KindEnum
+	KindSet
+)
+
+// Presence of a value
+const (
+	PresenceRequired = "required"
+	PresenceOptional = "optional"
+	PresenceConstant = "constant"
+)
+
+// Schema is a resolved SBE message schema
+type Schema struct {
+	Package   string
+	ID        uint64
+	Version   uint64
+	ByteOrder binary.ByteOrder
+	Header    *Type
+
+	messages map[uint64]*Message
+	names    map[string]*Message
+}
+
+// Message is a message template of the schema
+type Message struct {
+	Name string
+	ID   uint64
+	Block
+}
+
+// Block is the fixed-length part of a message or group entry followed by the
+// repeating groups and variable-length data.
+type Block struct {
+	BlockLength int
+	Fields      []*Field
+	Groups      []*Group
+	Data        []*Data
+}
+
+// Group is a repeating group
+type Group struct {
+	Name         string
+	ID           uint64
+	Dimension    *Type
+	SinceVersion uint64
+	Block
+}
+
+// Data is a variable-length data element
+type Data struct {
+	Name         string
+	ID           uint64
+	Type         *Type
+	SinceVersion uint64
+}
+
+// Field is a fixed-length field of a message or group
+type Field struct {
+	Name         string
+	ID           uint64
+	Offset       int
+	Type         *Type
+	Presence     string
+	Constant     string
+	SinceVersion uint64
+}
+
+// Type is an encoded type, either a primitive type, a composite, an enum or a
+// set of choices.
+type Type struct {
+	Name              string
+	Kind              Kind
+	Primitive         string
+	Length            int
+	Presence          string
+	NullValue         string
+	Constant          string
+	CharacterEncoding string
+	Offset            int
+
+	// Members of composites in encoding order
+	Members []*Type
+	// Valid values of enums
+	Values []EnumValue
+	// Choices of sets
+	Choices []SetChoice
+
+	size int
+}
+
+// EnumValue is a valid value of an enum
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// SetChoice is a choice of a set, identified by the bit position
+type SetChoice struct {
+	Name string
+	Bit  uint
+}
+
+// Size returns the encoded size of the type in bytes
+func (t *Type) Size() int {
+	return t.size
+}
+
+// Member returns the composite member with the given name
+func (t *Type) Member(name string) *Type {
+	for _, m := range t.Members {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Message returns the message with the given template ID
+func (s *Schema) Message(id uint64) (*Message, bool) {
+	m, found := s.messages[id]
+	return m, found
+}
+
+// MessageByName returns the message with the given name
+func (s *Schema) MessageByName(name string) (*Message, bool) {
+	m, found := s.names[name]
+	return m, found
+}
+
+// Messages returns all messages of the schema
+func (s *Schema) Messages() []*Message {
+	messages := make([]*Message, 0, len(s.messages))
+	for _, m := range s.messages {
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// LoadSchema reads the SBE message schema from the given file
+func LoadSchema(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseSchema(f)
+}
+
+// node is a generic XML element keeping the order of the children
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []node     `xml:",any"`
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) uintAttr(name string, fallback uint64) (uint64, error) {
+	v := n.attr(name)
+	if v == "" {
+		return fallback, nil
+	}
+	u, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %q attribute of %q: %w", name, n.attr("name"), err)
+	}
+	return u, nil
+}
+
+func (n *node) intAttr(name string, fallback int) (int, error) {
+	u, err := n.uintAttr(name, uint64(fallback))
+	return int(u), err
+}
+
+// resolver resolves the type references of a schema
+type resolver struct {
+	nodes    map[string]*node
+	types    map[string]*Type
+	resolved map[string]bool
+}
+
+// ParseSchema parses the SBE message schema from the given reader
+func ParseSchema(r io.Reader) (*Schema, error) {
+	var root node
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("parsing schema failed: %w", err)
+	}
+	if root.XMLName.Local != "messageSchema" {
+		return nil, fmt.Errorf("unexpected root element %q", root.XMLName.Local)
+	}
+
+	s := &Schema{
+		Package:  root.attr("package"),
+		messages: make(map[uint64]*Message),
+		names:    make(map[string]*Message),
+	}
+	var err error
+	if s.ID, err = root.uintAttr("id", 0); err != nil {
+		return nil, err
+	}
+	if s.Version, err = root.uintAttr("version", 0); err != nil {
+		return nil, err
+	}
+	switch root.attr("byteOrder") {
+	case "", "littleEndian":
+		s.ByteOrder = binary.LittleEndian
+	case "bigEndian":
+		s.ByteOrder = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %q", root.attr("byteOrder"))
+	}
+
+	res := &resolver{
+		nodes:    make(map[string]*node),
+		types:    make(map[string]*Type),
+		resolved: make(map[string]bool),
+	}
+	for i := range root.Children {
+		if root.Children[i].XMLName.Local != "types" {
+			continue
+		}
+		for j := range root.Children[i].Children {
+			n := &root.Children[i].Children[j]
+			name := n.attr("name")
+			if name == "" {
+				return nil, fmt.Errorf("unnamed %q type", n.XMLName.Local)
+			}
+			if _, found := res.nodes[name]; found {
+				return nil, fmt.Errorf("duplicate type %q", name)
+			}
+			res.nodes[name] = n
+		}
+	}
+
+	headerType := root.attr("headerType")
+	if headerType == "" {
+		headerType = "messageHeader"
+	}
+	if s.Header, err = res.resolve(headerType); err != nil {
+		return nil, fmt.Errorf("resolving header: %w", err)
+	}
+	if s.Header.Kind != KindComposite {
+		return nil, fmt.Errorf("header type %q is not a composite", headerType)
+	}
+	for _, member := range []string{"blockLength", "templateId"} {
+		if s.Header.Member(member) == nil {
+			return nil, fmt.Errorf("header type %q misses %q", headerType, member)
+		}
+	}
+
+	for i := range root.Children {
+		n := &root.Children[i]
+		if n.XMLName.Local != "message" {
+			continue
+		}
+		m := &Message{Name: n.attr("name")}
+		if m.ID, err = n.uintAttr("id", 0); err != nil {
+			return nil, err
+		}
+		if m.Block, err = res.block(n); err != nil {
+			return nil, fmt.Errorf("message %q: %w", m.Name, err)
+		}
+		if _, found := s.messages[m.ID]; found {
+			return nil, fmt.Errorf("duplicate message ID %d", m.ID)
+		}
+		s.messages[m.ID] = m
+		s.names[m.Name] = m
+	}
+	if len(s.messages) == 0 {
+		return nil, errors.New("schema does not contain any message")
+	}
+
+	return s, nil
+}
+
+// block resolves the fields, groups and data of a message or group
+func (r *resolver) block(n *node) (Block, error) {
+	var b Block
+	var offset int
+	for i := range n.Children {
+		c := &n.Children[i]
+		switch c.XMLName.Local {
+		case "field":
+			f, err := r.field(c, offset)
+			if err != nil {
+				return b, err
+			}
+			b.Fields = append(b.Fields, f)
+			if f.Presence != PresenceConstant {
+				offset = f.Offset + f.Type.Size()
+			}
+		case "group":
+			g, err := r.group(c)
+			if err != nil {
+				return b, err
+			}
+			b.Groups = append(b.Groups, g)
+		case "data":
+			d, err := r.data(c)
+			if err != nil {
+				return b, err
+			}
+			b.Data = append(b.Data, d)
+		}
+	}
+
+	blockLength, err := n.intAttr("blockLength", 0)
+	if err != nil {
+		return b, err
+	}
+	b.BlockLength = max(blockLength, offset)
+	return b, nil
+}
+
+func (r *resolver) field(n *node, offset int) (*Field, error) {
+	f := &Field{Name: n.attr("name")}
+	var err error
+	if f.ID, err = n.uintAttr("id", 0); err != nil {
+		return nil, err
+	}
+	if f.Offset, err = n.intAttr("offset", offset); err != nil {
+		return nil, err
+	}
+	if f.SinceVersion, err = n.uintAttr("sinceVersion", 0); err != nil {
+		return nil, err
+	}
+	if f.Type, err = r.resolve(n.attr("type")); err != nil {
+		return nil, fmt.Errorf("field %q: %w", f.Name, err)
+	}
+
+	f.Presence = n.attr("presence")
+	if f.Presence == "" {
+		f.Presence = f.Type.Presence
+	}
+	if f.Presence == PresenceConstant {
+		f.Constant = f.Type.Constant
+		if ref := n.attr("valueRef"); ref != "" {
+			// The value refers to an enum value in the form "enum.value"
+			_, value, found := strings.Cut(ref, ".")
+			if !found {
+				return nil, fmt.Errorf("field %q: invalid value reference %q", f.Name, ref)
+			}
+			f.Constant = value
+		}
+	}
+	return f, nil
+}
+
+func (r *resolver) group(n *node) (*Group, error) {
+	g := &Group{Name: n.attr("name")}
+	var err error
+	if g.ID, err = n.uintAttr("id", 0); err != nil {
+		return nil, err
+	}
+	if g.SinceVersion, err = n.uintAttr("sinceVersion", 0); err != nil {
+		return nil, err
+	}
+
+	dimension := n.attr("dimensionType")
+	if dimension == "" {
+		dimension = "groupSizeEncoding"
+	}
+	if g.Dimension, err = r.resolve(dimension); err != nil {
+		return nil, fmt.Errorf("group %q: %w", g.Name, err)
+	}
+	if g.Dimension.Member("blockLength") == nil || g.Dimension.Member("numInGroup") == nil {
+		return nil, fmt.Errorf("group %q: dimension type %q requires 'blockLength' and 'numInGroup'", g.Name, dimension)
+	}
+
+	if g.Block, err = r.block(n); err != nil {
+		return nil, fmt.Errorf("group %q: %w", g.Name, err)
+	}
+	return g, nil
+}
+
+func (r *resolver) data(n *node) (*Data, error) {
+	d := &Data{Name: n.attr("name")}
+	var err error
+	if d.ID, err = n.uintAttr("id", 0); err != nil {
+		return nil, err
+	}
+	if d.SinceVersion, err = n.uintAttr("sinceVersion", 0); err != nil {
+		return nil, err
+	}
+	if d.Type, err = r.resolve(n.attr("type")); err != nil {
+		return nil, fmt.Errorf("data %q: %w", d.Name, err)
+	}
+	if d.Type.Member("length") == nil || d.Type.Member("varData") == nil {
+		return nil, fmt.Errorf("data %q: type requires 'length' and 'varData'", d.Name)
+	}
+	return d, nil
+}
+
+// resolve returns the type with the given name, either a primitive type or a
+// type defined in the schema
+func (r *resolver) resolve(name string) (*Type, error) {
+	if size := primitiveSize(name); size > 0 {
+		return &Type{
+			Name:      name,
+			Kind:      KindPrimitive,
+			Primitive: name,
+			Length:    1,
+			Presence:  PresenceRequired,
+			size:      size,
+		}, nil
+	}
+
+	if t, found := r.types[name]; found {
+		return t, nil
+	}
+	n, found := r.nodes[name]
+	if !found {
+		return nil, fmt.Errorf("unknown type %q", name)
+	}
+	if r.resolved[name] {
+		return nil, fmt.Errorf("circular reference of type %q", name)
+	}
+	r.resolved[name] = true
+
+	t, err := r.define(n)
+	if err != nil {
+		return nil, fmt.Errorf("type %q: %w", name, err)
+	}
+	r.types[name] = t
+	return t, nil
+}
+
+// define creates a type from its definition
+func (r *resolver) define(n *node) (*Type, error) {
+	t := &Type{
+		Name:              n.attr("name"),
+		Presence:          n.attr("presence"),
+		NullValue:         n.attr("nullValue"),
+		CharacterEncoding: n.attr("characterEncoding"),
+	}
+	if t.Presence == "" {
+		t.Presence = PresenceRequired
+	}
+
+	var err error
+	switch n.XMLName.Local {
+	case "type":
+		t.Kind = KindPrimitive
+		t.Primitive = n.attr("primitiveType")
+		if primitiveSize(t.Primitive) == 0 {
+			return nil, fmt.Errorf("unknown primitive type %q", t.Primitive)
+		}
+		if t.Length, err = n.intAttr("length", 1); err != nil {
+			return nil, err
+		}
+		if t.Presence == PresenceConstant {
+			t.Constant = strings.TrimSpace(n.Content)
+			t.size = 0
+		} else {
+			t.size = primitiveSize(t.Primitive) * t.Length
+		}
+	case "composite":
+		t.Kind = KindComposite
+		var offset int
+		for i := range n.Children {
+			c := &n.Children[i]
+			var member *Type
+			if c.XMLName.Local == "ref" {
+				ref, err := r.resolve(c.attr("type"))
+				if err != nil {
+					return nil, err
+				}
+				clone := *ref
+				member = &clone
+				member.Name = c.attr("name")
+			} else {
+				if member, err = r.define(c); err != nil {
+					return nil, err
+				}
+			}
+			if member.Offset, err = c.intAttr("offset", offset); err != nil {
+				return nil, err
+			}
+			offset = member.Offset + member.size
+			t.Members = append(t.Members, member)
+		}
+		t.size = offset
+	case "enum":
+		t.Kind = KindEnum
+		encoding, err := r.resolve(n.attr("encodingType"))
+		if err != nil {
+			return nil, err
+		}
+		if encoding.Kind != KindPrimitive || encoding.Length != 1 {
+			return nil, fmt.Errorf("invalid encoding type %q", encoding.Name)
+		}
+		t.Primitive = encoding.Primitive
+		if t.NullValue == "" {
+			t.NullValue = encoding.NullValue
+		}
+		t.size = encoding.size
+		for i := range n.Children {
+			c := &n.Children[i]
+			if c.XMLName.Local == "validValue" {
+				t.Values = append(t.Values, EnumValue{Name: c.attr("name"), Value: strings.TrimSpace(c.Content)})
+			}
+		}
+	case "set":
+		t.Kind = KindSet
+		encoding, err := r.resolve(n.attr("encodingType"))
+		if err != nil {
+			return nil, err
+		}
+		if encoding.Kind != KindPrimitive || !strings.HasPrefix(encoding.Primitive, "uint") {
+			return nil, fmt.Errorf("invalid encoding type %q", encoding.Name)
+		}
+		t.Primitive = encoding.Primitive
+		t.size = encoding.size
+		for i := range n.Children {
+			c := &n.Children[i]
+			if c.XMLName.Local != "choice" {
+				continue
+			}
+			bit, err := strconv.ParseUint(strings.TrimSpace(c.Content), 10, 8)
+			if err != nil || int(bit) >= 8*t.size {
+				return nil, fmt.Errorf("invalid bit of choice %q", c.attr("name"))
+			}
+			t.Choices = append(t.Choices, SetChoice{Name: c.attr("name"), Bit: uint(bit)})
+		}
+	default:
+		return nil, fmt.Errorf("unknown type definition %q", n.XMLName.Local)
+	}
+	return t, nil
+}
+
+func primitiveSize(primitive string) int {
+	switch primitive {
+	case "char", "int8", "uint8":
+		return 1
+	case "int16", "uint16":
+		return 2
+	case "int32", "uint32", "float":
+		return 4
+	case "int64", "uint64", "double":
+		return 8
+	}
+	return 0
+}
+
+// nullValue returns the value representing null for the given type
+func nullValue(t *Type) interface{} {
+	if t.NullValue != "" {
+		v, err := parseValue(t.Primitive, t.NullValue)
+		if err == nil {
+			return v
+		}
+	}
+
+	switch t.Primitive {
+	case "char":
+		return ""
+	case "int8":
+		return int64(math.MinInt8)
+	case "int16":
+		return int64(math.MinInt16)
+	case "int32":
+		return int64(math.MinInt32)
+	case "int64":
+		return int64(math.MinInt64)
+	case "uint8":
+		return uint64(math.MaxUint8)
+	case "uint16":
+		return uint64(math.MaxUint16)
+	case "uint32":
+		return uint64(math.MaxUint32)
+	case "uint64":
+		return uint64(math.MaxUint64)
+	}
+	return math.NaN()
+}
+
+// isNull checks if the decoded value represents null for the given type
+func isNull(t *Type, v interface{}) bool {
+	null := nullValue(t)
+	if f, ok := v.(float64); ok {
+		if n, ok := null.(float64); ok && math.IsNaN(n) {
+			return math.IsNaN(f)
+		}
+	}
+	return v == null
+}
+
+// parseValue parses the textual representation of a value in the schema
+func parseValue(primitive, s string) (interface{}, error) {
+	switch primitive {
+	case "char":
+		return s, nil
+	case "int8", "int16", "int32", "int64":
+		return strconv.ParseInt(s, 0, 64)
+	case "uint8", "uint16", "uint32", "uint64":
+		return strconv.ParseUint(s, 0, 64)
+	case "float", "double":
+		return strconv.ParseFloat(s, 64)
+	}
+	return nil, fmt.Errorf("unknown primitive type %q", primitive)
+}
@@ -30,6 +30,9 @@ const (
 	// PollIntervalDisabled is used to indicate that you want to disable polling,
 	// as opposed to duration 0 meaning poll constantly.
 	PollIntervalDisabled = time.Duration(0)
+
+	// Environment variable the execd input plugin uses to pass the state
+	stateEnvVar = "TELEGRAF_EXECD_STATE"
 )
 
 // Shim allows you to wrap your inputs and run them as if they were part of Telegraf,
@@ -3,7 +3,11 @@ package shim
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"os"
+	"reflect"
 	"sync"
 	"time"
 
@@ -22,6 +26,10 @@ func (s *Shim) AddInput(input telegraf.Input) error {
 		}
 	}
 
+	if err := restoreState(input); err != nil {
+		return fmt.Errorf("failed to restore input state: %w", err)
+	}
+
 	s.Input = input
 	return nil
 }
@@ -48,6 +56,7 @@ func (s *Shim) RunInput(pollInterval time.Duration) error {
 		if serviceInput, ok := s.Input.(telegraf.ServiceInput); ok {
 			serviceInput.Stop()
 		}
+		s.reportState(s.Input)
 		// closing the metric channel gracefully stops writing to stdout
 		close(s.metricCh)
 	}()
@@ -95,10 +104,12 @@ func (s *Shim) startGathering(ctx context.Context, input telegraf.Input, acc tel
 			if err := input.Gather(acc); err != nil {
 				fmt.Fprintf(s.stderr, "failed to gather metrics: %s\n", err)
 			}
+			s.reportState(input)
 		case <-t.C:
 			if err := input.Gather(acc); err != nil {
 				fmt.Fprintf(s.stderr, "failed to gather metrics: %s\n", err)
 			}
+			s.reportState(input)
 		}
 	}
 }
@@ -114,3 +125,44 @@ func (s *Shim) pushCollectMetricsRequest() {
 	default:
 	}
 }
+
+// restoreState sets the state of stateful inputs handed over by the execd
+// input plugin on start
+func restoreState(input telegraf.Input) error {
+	plugin, ok := input.(telegraf.StatefulPlugin)
+	if !ok {
+		return nil
+	}
+	encoded := os.Getenv(stateEnvVar)
+	if encoded == "" {
+		return nil
+	}
+
+	serialized, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decoding state failed: %w", err)
+	}
+
+	// Use the initial state as blueprint for unmarshalling
+	nstate := reflect.New(reflect.TypeOf(plugin.GetState())).Interface()
+	if err := json.Unmarshal(serialized, &nstate); err != nil {
+		return fmt.Errorf("unmarshalling state failed: %w", err)
+	}
+	return plugin.SetState(reflect.ValueOf(nstate).Elem().Interface())
+}
+
+// reportState hands the state of stateful inputs to the execd input plugin
+// via stderr to be persisted by Telegraf
+func (s *Shim) reportState(input telegraf.Input) {
+	plugin, ok := input.(telegraf.StatefulPlugin)
+	if !ok {
+		return
+	}
+
+	serialized, err := json.Marshal(plugin.GetState())
+	if err != nil {
+		s.log.Errorf("Marshalling state failed: %v", err)
+		return
+	}
+	fmt.Fprintf(s.stderr, "S! %s\n", base64.StdEncoding.EncodeToString(serialized))
+}
@@ -2,6 +2,7 @@ package shim
 
 import (
 	"bufio"
+	"encoding/base64"
 	"io"
 	"strings"
 	"testing"
@@ -55,6 +56,34 @@ func TestInputShimStdinSignalingWorks(t *testing.T) {
 	<-exited
 }
 
+func TestInputShimState(t *testing.T) {
+	t.Setenv("TELEGRAF_EXECD_STATE", base64.StdEncoding.EncodeToString([]byte("41")))
+
+	stdin, _ := io.Pipe() // hold the stdin pipe open
+	stderrReader, stderrWriter := io.Pipe()
+
+	// The state passed by the execd plugin is restored
+	inp := &statefulInput{}
+	shim := New()
+	shim.stdin = stdin
+	shim.stdout = io.Discard
+	shim.stderr = stderrWriter
+	require.NoError(t, shim.AddInput(inp))
+	require.Equal(t, 41, inp.count)
+
+	go func() {
+		if err := shim.Run(10 * time.Millisecond); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// The state is reported after gathering
+	r := bufio.NewReader(stderrReader)
+	out, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "S! "+base64.StdEncoding.EncodeToString([]byte("42"))+"\n", out)
+}
+
 func runInputPlugin(t *testing.T, interval time.Duration, stdin io.Reader, stdout, stderr io.Writer) (processed, exited chan bool) {
 	processed = make(chan bool, 1)
 	exited = make(chan bool, 1)
@@ -110,6 +139,29 @@ func (*testInput) Start(telegraf.Accumulator) error {
 func (*testInput) Stop() {
 }
 
+type statefulInput struct {
+	count int
+}
+
+func (*statefulInput) SampleConfig() string {
+	return ""
+}
+
+func (i *statefulInput) Gather(acc telegraf.Accumulator) error {
+	i.count++
+	acc.AddFields("measurement", map[string]interface{}{"count": i.count}, nil)
+	return nil
+}
+
+func (i *statefulInput) GetState() interface{} {
+	return i.count
+}
+
+func (i *statefulInput) SetState(state interface{}) error {
+	i.count = state.(int)
+	return nil
+}
+
 type serviceInput struct {
 	ServiceName string `toml:"service_name"`
 	SecretToken string `toml:"secret_token"`
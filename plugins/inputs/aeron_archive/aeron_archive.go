@@ -0,0 +1,543 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package aeron_archive
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/idlestrategy"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+	"github.com/lirm/aeron-go/aeron/logbuffer/term"
+	"github.com/lirm/aeron-go/archive"
+	"github.com/lirm/aeron-go/archive/codecs"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	defaultMaxUndeliveredMessages = 1000
+
+	// Number of recording descriptors to request at once when searching
+	// for the recording of the channel and stream
+	listRecordingsPageSize = 100
+)
+
+type empty struct{}
+type semaphore chan empty
+
+// AeronArchive replays a recorded stream of an Aeron Archive
+type AeronArchive struct {
+	AeronDir                string              `toml:"aeron_dir"`
+	DriverTimeout           config.Duration     `toml:"driver_timeout"`
+	ControlRequestChannel   string              `toml:"control_request_channel"`
+	ControlRequestStreamID  int32               `toml:"control_request_stream_id"`
+	ControlResponseChannel  string              `toml:"control_response_channel"`
+	ControlResponseStreamID int32               `toml:"control_response_stream_id"`
+	ControlTimeout          config.Duration     `toml:"control_timeout"`
+	Channel                 string              `toml:"channel"`
+	StreamID                int32               `toml:"stream_id"`
+	RecordingID             int64               `toml:"recording_id"`
+	StartPosition           int64               `toml:"start_position"`
+	StartTime               string              `toml:"start_time"`
+	Follow                  bool                `toml:"follow"`
+	ReplayChannel           string              `toml:"replay_channel"`
+	ReplayStreamID          int32               `toml:"replay_stream_id"`
+	FragmentLimit           int                 `toml:"fragment_limit"`
+	MaxUndeliveredMessages  int                 `toml:"max_undelivered_messages"`
+	MediaDriver             *mediadriver.Config `toml:"media_driver"`
+	Log                     telegraf.Logger     `toml:"-"`
+
+	// Internal state
+	parser          telegraf.Parser
+	startTime       time.Time
+	releaseDriver   func()
+	archive         *archive.Archive
+	subscription    *aeron.Subscription
+	assembler       *aeron.FragmentAssembler
+	replaySessionID int64
+	replaying       bool
+	done            chan empty
+	doneOnce        sync.Once
+	tracking        telegraf.TrackingAccumulator
+	sem             semaphore
+	cancel          context.CancelFunc
+	wg              *sync.WaitGroup
+
+	// Replay position of the delivered messages
+	positions positionTracker
+}
+
+// SampleConfig returns the sample configuration for the plugin
+func (*AeronArchive) SampleConfig() string {
+	return sampleConfig
+}
+
+// Init initializes the plugin and validates configuration
+func (a *AeronArchive) Init() error {
+	// Set defaults
+	if a.DriverTimeout == 0 {
+		a.DriverTimeout = config.Duration(30 * time.Second)
+	}
+
+	if a.ControlRequestChannel == "" {
+		a.ControlRequestChannel = "aeron:udp?endpoint=localhost:8010"
+	}
+
+	if a.ControlRequestStreamID == 0 {
+		a.ControlRequestStreamID = 10
+	}
+
+	if a.ControlResponseChannel == "" {
+		a.ControlResponseChannel = "aeron:udp?endpoint=localhost:0"
+	}
+
+	if a.ControlResponseStreamID == 0 {
+		a.ControlResponseStreamID = 20
+	}
+
+	if a.ControlTimeout == 0 {
+		a.ControlTimeout = config.Duration(5 * time.Second)
+	}
+
+	if a.ReplayChannel == "" {
+		a.ReplayChannel = "aeron:ipc"
+	}
+
+	if a.ReplayStreamID == 0 {
+		a.ReplayStreamID = 1001
+	}
+
+	if a.FragmentLimit == 0 {
+		a.FragmentLimit = 10
+	}
+
+	if a.MaxUndeliveredMessages == 0 {
+		a.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
+	}
+
+	// Validate configuration
+	if a.RecordingID < 0 && a.Channel == "" {
+		return errors.New("either recording_id or channel is required")
+	}
+
+	if a.StreamID < 0 {
+		return errors.New("stream_id must be non-negative")
+	}
+
+	if a.StartPosition < 0 {
+		return errors.New("start_position must be non-negative")
+	}
+
+	if a.MaxUndeliveredMessages < 0 {
+		return errors.New("max_undelivered_messages must be non-negative")
+	}
+
+	if a.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, a.StartTime)
+		if err != nil {
+			return fmt.Errorf("invalid start_time: %w", err)
+		}
+		a.startTime = t
+	}
+
+	if a.MediaDriver != nil {
+		if err := mediadriver.Register(a.AeronDir, a.MediaDriver, a.Log); err != nil {
+			return fmt.Errorf("registering media driver failed: %w", err)
+		}
+	}
+
+	a.positions = positionTracker{recordingID: -1, position: -1}
+	a.done = make(chan empty)
+
+	return nil
+}
+
+// SetParser sets the parser for the replayed messages
+func (a *AeronArchive) SetParser(parser telegraf.Parser) {
+	a.parser = parser
+}
+
+// GetState returns the replay position of the delivered messages
+func (a *AeronArchive) GetState() interface{} {
+	return a.positions.state()
+}
+
+// SetState restores the replay position to resume the replay
+func (a *AeronArchive) SetState(s interface{}) error {
+	st, ok := s.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", s)
+	}
+	a.positions.restore(st)
+	return nil
+}
+
+// Start connects to the archive and starts replaying the recording
+func (a *AeronArchive) Start(acc telegraf.Accumulator) error {
+	a.Log.Info("Starting Aeron archive plugin")
+
+	// Use tracking metrics to only persist the position of messages written
+	// by the outputs
+	a.tracking = acc.WithTracking(a.MaxUndeliveredMessages)
+	a.sem = make(semaphore, a.MaxUndeliveredMessages)
+
+	if err := a.connect(); err != nil {
+		return fmt.Errorf("failed to connect to Aeron archive: %w", err)
+	}
+
+	if err := a.startReplay(); err != nil {
+		a.close()
+		return fmt.Errorf("failed to start replay: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	a.wg = &sync.WaitGroup{}
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case info := <-a.tracking.Delivered():
+				a.positions.delivered(info.ID())
+				<-a.sem
+			}
+		}
+	}()
+	go func() {
+		defer a.wg.Done()
+		a.consume(ctx)
+	}()
+
+	return nil
+}
+
+// connect establishes the connection to the archive after waiting for the
+// media driver if it is launched by Telegraf
+func (a *AeronArchive) connect() error {
+	release, err := mediadriver.Acquire(a.AeronDir, time.Duration(a.DriverTimeout))
+	if err != nil {
+		return err
+	}
+
+	ctx := aeron.NewContext()
+	if a.AeronDir != "" {
+		ctx.AeronDir(a.AeronDir)
+	}
+	ctx.MediaDriverTimeout(time.Duration(a.DriverTimeout))
+
+	options := archive.DefaultOptions()
+	options.RequestChannel = a.ControlRequestChannel
+	options.RequestStream = a.ControlRequestStreamID
+	options.ResponseChannel = a.ControlResponseChannel
+	options.ResponseStream = a.ControlResponseStreamID
+	options.Timeout = time.Duration(a.ControlTimeout)
+
+	arch, err := archive.NewArchive(options, ctx)
+	if err != nil {
+		release()
+		return err
+	}
+	a.archive = arch
+	a.releaseDriver = release
+
+	a.Log.Debugf("Connected to Aeron archive with control session %d", arch.SessionID)
+	return nil
+}
+
+// startReplay looks up the recording, determines the position to start from
+// and subscribes to the replay
+func (a *AeronArchive) startReplay() error {
+	recording, err := a.findRecording()
+	if err != nil {
+		return err
+	}
+
+	// Resume from the persisted position if replaying the same recording
+	position := a.StartPosition
+	if st := a.positions.state(); st.RecordingID == recording.RecordingId && st.Position >= 0 {
+		position = st.Position
+		a.Log.Infof("Resuming replay of recording %d at position %d", recording.RecordingId, position)
+	}
+	position = max(position, recording.StartPosition)
+
+	stopPosition, err := a.stopPosition(recording)
+	if err != nil {
+		return err
+	}
+	if stopPosition >= 0 {
+		position = min(position, stopPosition)
+	}
+	a.positions.reset(recording.RecordingId, position)
+
+	// Replay up to the current end of the recording or follow the recording
+	length := int64(math.MaxInt64)
+	if !a.Follow {
+		length = stopPosition - position
+		if length <= 0 {
+			a.Log.Infof("Nothing to replay for recording %d at position %d", recording.RecordingId, position)
+			a.doneOnce.Do(func() { close(a.done) })
+			return nil
+		}
+	}
+
+	a.replaySessionID, err = a.archive.StartReplay(recording.RecordingId, position, length, a.ReplayChannel, a.ReplayStreamID)
+	if err != nil {
+		return fmt.Errorf("replaying recording %d failed: %w", recording.RecordingId, err)
+	}
+	a.replaying = true
+
+	// Only subscribe to the replay session started by us
+	channel, err := archive.AddSessionIdToChannel(a.ReplayChannel, archive.ReplaySessionIdToSessionId(a.replaySessionID))
+	if err != nil {
+		return fmt.Errorf("invalid replay channel: %w", err)
+	}
+	a.subscription, err = a.archive.Aeron().AddSubscriptionWithHandlers(channel, a.ReplayStreamID, a.onAvailableImage, a.onUnavailableImage)
+	if err != nil {
+		return fmt.Errorf("failed to add replay subscription: %w", err)
+	}
+	a.assembler = aeron.NewFragmentAssembler(a.createFragmentHandler(), aeron.DefaultFragmentAssemblyBufferLength)
+
+	a.Log.Infof("Replaying recording %d from position %d", recording.RecordingId, position)
+	return nil
+}
+
+// findRecording returns the configured recording or the recording of the
+// channel and stream to replay
+func (a *AeronArchive) findRecording() (*codecs.RecordingDescriptor, error) {
+	if a.RecordingID >= 0 {
+		recording, err := a.archive.ListRecording(a.RecordingID)
+		if err != nil {
+			return nil, fmt.Errorf("listing recording %d failed: %w", a.RecordingID, err)
+		}
+		if recording == nil {
+			return nil, fmt.Errorf("unknown recording %d", a.RecordingID)
+		}
+		return recording, nil
+	}
+
+	var recordings []*codecs.RecordingDescriptor
+	for from := int64(0); ; {
+		page, err := a.archive.ListRecordingsForUri(from, listRecordingsPageSize, a.Channel, a.StreamID)
+		if err != nil {
+			return nil, fmt.Errorf("listing recordings failed: %w", err)
+		}
+		recordings = append(recordings, page...)
+		if len(page) < listRecordingsPageSize {
+			break
+		}
+		from = page[len(page)-1].RecordingId + 1
+	}
+
+	recording := selectRecording(recordings, a.startTime)
+	if recording == nil {
+		return nil, fmt.Errorf("no recording found for channel %q and stream %d", a.Channel, a.StreamID)
+	}
+	return recording, nil
+}
+
+// stopPosition returns the position the recording stopped at or the current
+// position for an active recording
+func (a *AeronArchive) stopPosition(recording *codecs.RecordingDescriptor) (int64, error) {
+	if recording.StopPosition != archive.RecordingPositionNull {
+		return recording.StopPosition, nil
+	}
+
+	position, err := a.archive.GetRecordingPosition(recording.RecordingId)
+	if err != nil {
+		return 0, fmt.Errorf("getting position of recording %d failed: %w", recording.RecordingId, err)
+	}
+	if position != archive.RecordingPositionNull {
+		return position, nil
+	}
+
+	// The recording stopped in the meantime
+	updated, err := a.archive.ListRecording(recording.RecordingId)
+	if err != nil {
+		return 0, fmt.Errorf("listing recording %d failed: %w", recording.RecordingId, err)
+	}
+	if updated == nil {
+		return 0, fmt.Errorf("recording %d vanished", recording.RecordingId)
+	}
+	return updated.StopPosition, nil
+}
+
+// selectRecording returns the most recent of the given recordings. If a start
+// time is given the most recent recording started at or before that time is
+// used, falling back to the oldest recording.
+func selectRecording(recordings []*codecs.RecordingDescriptor, startTime time.Time) *codecs.RecordingDescriptor {
+	var latest, oldest *codecs.RecordingDescriptor
+	for _, r := range recordings {
+		if oldest == nil || r.StartTimestamp < oldest.StartTimestamp {
+			oldest = r
+		}
+		if !startTime.IsZero() && r.StartTimestamp > startTime.UnixMilli() {
+			continue
+		}
+		if latest == nil || r.RecordingId > latest.RecordingId {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return oldest
+	}
+	return latest
+}
+
+// createFragmentHandler creates a fragment handler that processes the
+// replayed messages
+func (a *AeronArchive) createFragmentHandler() term.FragmentHandler {
+	return func(buffer *atomic.Buffer, offset int32, length int32, header *logbuffer.Header) {
+		data := buffer.GetBytesArray(offset, length)
+
+		// Position in the recording after this message
+		position := header.Position()
+
+		metrics, err := a.parser.Parse(data)
+		if err != nil {
+			a.Log.Errorf("Failed to parse message at position %d: %v", position, err)
+			a.positions.skip(position)
+			return
+		}
+
+		// Skip metrics recorded before the requested start time
+		if !a.startTime.IsZero() {
+			filtered := metrics[:0]
+			for _, m := range metrics {
+				if !m.Time().Before(a.startTime) {
+					filtered = append(filtered, m)
+				}
+			}
+			metrics = filtered
+		}
+
+		if len(metrics) == 0 {
+			a.positions.skip(position)
+			return
+		}
+
+		// The consume loop makes sure to only poll as many fragments as there
+		// are free slots so this never blocks
+		a.sem <- empty{}
+		a.positions.add(position, func() telegraf.TrackingID {
+			return a.tracking.AddTrackingMetricGroup(metrics)
+		})
+	}
+}
+
+// onAvailableImage is called when the replay starts
+func (a *AeronArchive) onAvailableImage(image aeron.Image) {
+	a.Log.Debugf("Replay image available: sessionId=%d", image.SessionID())
+}
+
+// onUnavailableImage is called when the replay ends
+func (a *AeronArchive) onUnavailableImage(image aeron.Image) {
+	if image == nil {
+		return
+	}
+	a.Log.Debugf("Replay image unavailable: sessionId=%d", image.SessionID())
+	a.doneOnce.Do(func() { close(a.done) })
+}
+
+// consume polls the replay subscription until the replay ends
+func (a *AeronArchive) consume(ctx context.Context) {
+	idleStrategy := idlestrategy.NewDefaultBackoffIdleStrategy()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.done:
+			st := a.positions.state()
+			a.Log.Infof("Replay of recording %d finished", st.RecordingID)
+			return
+		default:
+			// Stop polling while the maximum number of undelivered messages is
+			// reached
+			limit := min(a.FragmentLimit, cap(a.sem)-len(a.sem))
+			if limit <= 0 {
+				idleStrategy.Idle(0)
+				continue
+			}
+
+			fragmentsRead := a.subscription.Poll(a.assembler.OnFragment, limit)
+			idleStrategy.Idle(fragmentsRead)
+		}
+	}
+}
+
+// Gather is called by Telegraf to collect metrics, the replayed messages are
+// added in the background
+func (*AeronArchive) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+// Stop stops the replay and closes the connection to the archive
+func (a *AeronArchive) Stop() {
+	a.Log.Info("Stopping Aeron archive plugin")
+
+	if a.cancel != nil {
+		a.cancel()
+	}
+
+	if a.wg != nil {
+		a.wg.Wait()
+	}
+
+	a.close()
+}
+
+// close stops an active replay and releases all resources
+func (a *AeronArchive) close() {
+	if a.archive == nil {
+		return
+	}
+
+	if a.replaying {
+		select {
+		case <-a.done:
+		default:
+			if err := a.archive.StopReplay(a.replaySessionID); err != nil {
+				a.Log.Debugf("Stopping replay failed: %v", err)
+			}
+		}
+		a.replaying = false
+	}
+
+	if a.subscription != nil {
+		a.subscription.Close()
+		a.subscription = nil
+	}
+
+	if err := a.archive.Close(); err != nil {
+		a.Log.Errorf("Error closing Aeron archive: %v", err)
+	}
+	a.archive = nil
+
+	a.releaseDriver()
+	a.releaseDriver = nil
+}
+
+func init() {
+	inputs.Add("aeron_archive", func() telegraf.Input {
+		return &AeronArchive{
+			RecordingID: -1,
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package aeron_archive
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+	"github.com/lirm/aeron-go/archive/codecs"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestAeronArchive_Init_Defaults(t *testing.T) {
+	plugin := &AeronArchive{
+		Channel:     "aeron:udp?endpoint=localhost:40123",
+		StreamID:    10,
+		RecordingID: -1,
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	require.Equal(t, config.Duration(30*time.Second), plugin.DriverTimeout)
+	require.Equal(t, "aeron:udp?endpoint=localhost:8010", plugin.ControlRequestChannel)
+	require.Equal(t, int32(10), plugin.ControlRequestStreamID)
+	require.Equal(t, "aeron:udp?endpoint=localhost:0", plugin.ControlResponseChannel)
+	require.Equal(t, int32(20), plugin.ControlResponseStreamID)
+	require.Equal(t, config.Duration(5*time.Second), plugin.ControlTimeout)
+	require.Equal(t, "aeron:ipc", plugin.ReplayChannel)
+	require.Equal(t, int32(1001), plugin.ReplayStreamID)
+	require.Equal(t, 10, plugin.FragmentLimit)
+	require.Equal(t, 1000, plugin.MaxUndeliveredMessages)
+	require.Equal(t, state{RecordingID: -1, Position: -1}, plugin.GetState())
+}
+
+func TestAeronArchive_Init_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *AeronArchive
+		expected string
+	}{
+		{
+			name:     "no recording",
+			plugin:   &AeronArchive{RecordingID: -1},
+			expected: "either recording_id or channel is required",
+		},
+		{
+			name:     "negative start position",
+			plugin:   &AeronArchive{RecordingID: 1, StartPosition: -1},
+			expected: "start_position must be non-negative",
+		},
+		{
+			name:     "invalid start time",
+			plugin:   &AeronArchive{RecordingID: 1, StartTime: "yesterday"},
+			expected: "invalid start_time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Log = testutil.Logger{}
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestAeronArchive_State(t *testing.T) {
+	plugin := &AeronArchive{RecordingID: 3, Log: testutil.Logger{}}
+	require.NoError(t, plugin.Init())
+
+	require.NoError(t, plugin.SetState(state{RecordingID: 3, Position: 4096}))
+	require.Equal(t, state{RecordingID: 3, Position: 4096}, plugin.GetState())
+
+	require.ErrorContains(t, plugin.SetState(map[string]int64{}), "state has wrong type")
+}
+
+func TestAeronArchive_SelectRecording(t *testing.T) {
+	recordings := []*codecs.RecordingDescriptor{
+		{RecordingId: 1, StartTimestamp: 1000},
+		{RecordingId: 2, StartTimestamp: 2000},
+		{RecordingId: 3, StartTimestamp: 3000},
+	}
+
+	require.Nil(t, selectRecording(nil, time.Time{}))
+	require.Equal(t, int64(3), selectRecording(recordings, time.Time{}).RecordingId)
+	require.Equal(t, int64(2), selectRecording(recordings, time.UnixMilli(2500)).RecordingId)
+	require.Equal(t, int64(2), selectRecording(recordings, time.UnixMilli(2000)).RecordingId)
+	require.Equal(t, int64(1), selectRecording(recordings, time.UnixMilli(500)).RecordingId)
+}
+
+func TestAeronArchive_PositionTracker(t *testing.T) {
+	var tracker positionTracker
+	tracker.reset(7, 0)
+
+	ids := []telegraf.TrackingID{1, 2, 3}
+	for i, id := range ids {
+		tracker.add(int64(i+1)*64, func() telegraf.TrackingID { return id })
+	}
+
+	// Out-of-order delivery must not advance the position
+	tracker.delivered(2)
+	require.Equal(t, state{RecordingID: 7, Position: 0}, tracker.state())
+
+	tracker.delivered(1)
+	require.Equal(t, state{RecordingID: 7, Position: 128}, tracker.state())
+
+	// Messages without metrics only advance once all previous messages are
+	// delivered
+	tracker.skip(256)
+	require.Equal(t, state{RecordingID: 7, Position: 128}, tracker.state())
+
+	tracker.delivered(3)
+	require.Equal(t, state{RecordingID: 7, Position: 256}, tracker.state())
+	require.Empty(t, tracker.pending)
+}
+
+func TestAeronArchive_Replay(t *testing.T) {
+	plugin := &AeronArchive{
+		RecordingID:            1,
+		StartTime:              "2021-01-01T00:00:01Z",
+		MaxUndeliveredMessages: 2,
+		Log:                    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	parser := &influx.Parser{}
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+
+	var acc testutil.Accumulator
+	plugin.tracking = acc.WithTracking(plugin.MaxUndeliveredMessages)
+	plugin.sem = make(semaphore, plugin.MaxUndeliveredMessages)
+	plugin.positions.reset(1, 0)
+
+	handler := plugin.createFragmentHandler()
+
+	// Metrics before the start time are dropped
+	payload := []byte("test value=1i 1609459200000000000\ntest value=2i 1609459201000000000")
+	buffer, header := newTestFrame(payload, 0)
+	handler(buffer, logbuffer.DataFrameHeader.Length, int32(len(payload)), header)
+	require.Len(t, plugin.sem, 1)
+
+	// Messages without metrics are skipped
+	invalid := []byte("invalid line protocol")
+	buffer, header = newTestFrame(invalid, 128)
+	handler(buffer, 128+logbuffer.DataFrameHeader.Length, int32(len(invalid)), header)
+	require.Len(t, plugin.sem, 1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{},
+			map[string]interface{}{"value": int64(2)},
+			time.Unix(1609459201, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// The position advances once the metrics are delivered
+	require.Equal(t, state{RecordingID: 1, Position: 0}, plugin.GetState())
+	for _, m := range acc.GetTelegrafMetrics() {
+		m.Accept()
+	}
+	select {
+	case info := <-plugin.tracking.Delivered():
+		plugin.positions.delivered(info.ID())
+		<-plugin.sem
+	case <-time.After(time.Second):
+		require.FailNow(t, "message not delivered")
+	}
+	require.Equal(t, state{RecordingID: 1, Position: 192}, plugin.GetState())
+}
+
+// newTestFrame constructs a term buffer with a data frame containing the
+// given payload at the given term offset
+func newTestFrame(payload []byte, termOffset int32) (*atomic.Buffer, *logbuffer.Header) {
+	frameLength := logbuffer.DataFrameHeader.Length + int32(len(payload))
+	term := make([]byte, termOffset+frameLength)
+	buffer := atomic.MakeBuffer(term)
+	buffer.PutInt32(termOffset+logbuffer.DataFrameHeader.FrameLengthFieldOffset, frameLength)
+	buffer.PutInt32(termOffset+logbuffer.DataFrameHeader.TermOffsetFieldOffset, termOffset)
+	buffer.PutInt32(termOffset+logbuffer.DataFrameHeader.SessionIDFieldOffset, 42)
+	buffer.PutInt32(termOffset+logbuffer.DataFrameHeader.StreamIDFieldOffset, 1001)
+	buffer.PutInt32(termOffset+logbuffer.DataFrameHeader.TermIDFieldOffset, 0)
+	buffer.PutBytesArray(termOffset+logbuffer.DataFrameHeader.Length, &payload, 0, int32(len(payload)))
+
+	header := &logbuffer.Header{}
+	header.Wrap(unsafe.Pointer(&term[0]), int32(len(term)))
+	header.SetOffset(termOffset)
+	header.SetPositionBitsToShift(16)
+
+	return buffer, header
+}
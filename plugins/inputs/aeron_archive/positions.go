@@ -0,0 +1,97 @@
+package aeron_archive
+
+import (
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// state is the replay position persisted across restarts
+type state struct {
+	RecordingID int64 `json:"recording_id"`
+	Position    int64 `json:"position"`
+}
+
+// pendingMessage is a replayed message not yet written by the outputs
+type pendingMessage struct {
+	id        telegraf.TrackingID
+	position  int64
+	delivered bool
+}
+
+// positionTracker keeps track of the replay position up to which all
+// messages were delivered. Messages might be delivered out of order so the
+// position only advances once all previous messages were delivered.
+type positionTracker struct {
+	sync.Mutex
+
+	recordingID int64
+	position    int64
+	pending     []pendingMessage
+}
+
+// restore sets the persisted position
+func (t *positionTracker) restore(s state) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.recordingID = s.RecordingID
+	t.position = s.Position
+	t.pending = nil
+}
+
+// reset starts tracking the given recording at the given position
+func (t *positionTracker) reset(recordingID, position int64) {
+	t.restore(state{RecordingID: recordingID, Position: position})
+}
+
+// add adds a message ending at the given position. The tracking function is
+// called with the tracker locked so the message is known before it can be
+// delivered.
+func (t *positionTracker) add(position int64, track func() telegraf.TrackingID) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.pending = append(t.pending, pendingMessage{id: track(), position: position})
+}
+
+// skip advances the position for a message without metrics
+func (t *positionTracker) skip(position int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.pending = append(t.pending, pendingMessage{position: position, delivered: true})
+	t.advance()
+}
+
+// delivered marks the message with the given tracking ID as delivered
+func (t *positionTracker) delivered(id telegraf.TrackingID) {
+	t.Lock()
+	defer t.Unlock()
+
+	for i := range t.pending {
+		if !t.pending[i].delivered && t.pending[i].id == id {
+			t.pending[i].delivered = true
+			break
+		}
+	}
+	t.advance()
+}
+
+// advance moves the position past all delivered messages at the front
+func (t *positionTracker) advance() {
+	var n int
+	for n < len(t.pending) && t.pending[n].delivered {
+		t.position = t.pending[n].position
+		n++
+	}
+	t.pending = t.pending[n:]
+}
+
+// state returns the current position to persist
+func (t *positionTracker) state() state {
+	t.Lock()
+	defer t.Unlock()
+
+	return state{RecordingID: t.recordingID, Position: t.position}
+}
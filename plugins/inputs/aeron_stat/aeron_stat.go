@@ -1,32 +1,47 @@
 package aeron_stat
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
 	"github.com/lirm/aeron-go/aeron/counters"
-	"github.com/lirm/aeron-go/aeron/util/memmap"
 )
 
 // AeronStat implements the telegraf.Input interface to collect Aeron CnC file metrics
 type AeronStat struct {
 	AeronDir    string            `toml:"aeron_dir"`
+	AeronDirs   []string          `toml:"aeron_dirs"`
 	ReadTimeout config.Duration   `toml:"read_timeout"`
+	ErrorLog    bool              `toml:"error_log"`
+	LossReport  bool              `toml:"loss_report"`
+	StreamStats bool              `toml:"stream_stats"`
 	Tags        map[string]string `toml:"tags"`
 	Log         telegraf.Logger   `toml:"-"`
 
 	// Internal fields
-	reader      *counters.Reader
-	counterFile *counters.MetaDataFlyweight
-	cncFile     *memmap.File
+	globs         []*globpath.GlobPath
+	drivers       map[string]*mediaDriver
+	releaseDriver func()
 }
 
+// positionLabelRe matches the labels of the Aeron position counters in the
+// form "<name>: <registration-id> <session-id> <stream-id> <channel>"
+var positionLabelRe = regexp.MustCompile(`^[a-z-]+(?: \([a-z]+\))?: -?\d+ (-?\d+) (-?\d+) aeron:`)
+
 // Description returns a description of the plugin
 func (a *AeronStat) Description() string {
 	return "Collect Aeron media driver CnC file metrics"
@@ -39,9 +54,27 @@ func (a *AeronStat) SampleConfig() string {
   ## Default: system default (usually /dev/shm/aeron or /tmp/aeron)
   # aeron_dir = "/dev/shm/aeron"
   
+  ## Glob patterns of Aeron directories to discover multiple media drivers.
+  ## Drivers are discovered as they appear and removed when they vanish.
+  ## Metrics of discovered drivers are tagged with "aeron_dir" and
+  ## "driver_pid". If set, "aeron_dir" does not default to the system default.
+  # aeron_dirs = ["/dev/shm/aeron-*"]
+  
   ## Timeout for reading CnC files
   # read_timeout = "5s"
   
+  ## Report the distinct errors of the media driver's error log. Each error
+  ## is reported again whenever it is observed after the last collection.
+  # error_log = false
+  
+  ## Report the per-stream loss observations of the loss report
+  ## (loss-report.dat in the Aeron directory)
+  # loss_report = false
+  
+  ## Report per-stream lag and throughput by correlating the publisher,
+  ## sender, receiver and subscriber position counters of each stream
+  # stream_stats = false
+  
   ## Add custom tags to all metrics
   # [inputs.aeron_stat.tags]
   #   environment = "production"
@@ -56,23 +89,52 @@ func (a *AeronStat) Init() error {
 	}
 
 	// If no aeron_dir specified, use the default from Aeron
-	if a.AeronDir == "" {
+	if a.AeronDir == "" && len(a.AeronDirs) == 0 {
 		// Use the same default as NewContext() does
 		a.AeronDir = aeron.DefaultAeronDir + "/aeron-" + aeron.UserName
 		a.Log.Debugf("Using default Aeron directory: %s", a.AeronDir)
 	}
 
+	a.globs = make([]*globpath.GlobPath, 0, len(a.AeronDirs))
+	for _, pattern := range a.AeronDirs {
+		g, err := globpath.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid aeron_dirs pattern %q: %w", pattern, err)
+		}
+		a.globs = append(a.globs, g)
+	}
+
 	return nil
 }
 
 // Start initializes the CnC file reader
 func (a *AeronStat) Start(acc telegraf.Accumulator) error {
-	a.Log.Infof("Starting Aeron stat collection from directory: %s", a.AeronDir)
+	if a.AeronDir != "" {
+		a.Log.Infof("Starting Aeron stat collection from directory: %s", a.AeronDir)
+	}
+	if len(a.AeronDirs) > 0 {
+		a.Log.Infof("Starting Aeron stat collection from directories: %s", strings.Join(a.AeronDirs, ", "))
+	}
 
-	// Initialize the CnC file reader
-	err := a.initializeReader()
-	if err != nil {
-		return fmt.Errorf("failed to initialize CnC reader: %w", err)
+	// Wait for the media driver if it is launched by Telegraf for another
+	// Aeron plugin using the same directory
+	if a.AeronDir != "" {
+		release, err := mediadriver.Acquire(a.AeronDir, time.Duration(a.ReadTimeout))
+		if err != nil {
+			return err
+		}
+		a.releaseDriver = release
+	}
+
+	// Map the CnC files of the media drivers, only a missing driver in the
+	// explicitly configured directory is fatal
+	a.drivers = make(map[string]*mediaDriver)
+	if err := a.updateDrivers(); err != nil {
+		if len(a.AeronDirs) == 0 {
+			a.cleanup()
+			return fmt.Errorf("failed to initialize CnC reader: %w", err)
+		}
+		a.Log.Warn(err)
 	}
 
 	a.Log.Info("Aeron stat plugin started successfully")
@@ -87,76 +149,131 @@ func (a *AeronStat) Stop() {
 
 // Gather collects metrics from the Aeron CnC files
 func (a *AeronStat) Gather(acc telegraf.Accumulator) error {
-	// Ensure reader is initialized
-	if a.reader == nil {
-		err := a.initializeReader()
-		if err != nil {
-			a.Log.Errorf("Failed to initialize CnC reader: %v", err)
-			return err
-		}
+	if a.drivers == nil {
+		a.drivers = make(map[string]*mediaDriver)
 	}
 
-	// Collect counter metrics
-	err := a.collectCounters(acc)
-	if err != nil {
-		a.Log.Errorf("Failed to collect counters: %v", err)
-		// Try to reinitialize on next gather
-		a.cleanup()
-		return err
+	// Discover new drivers and remove the vanished ones
+	errs := make([]error, 0)
+	if err := a.updateDrivers(); err != nil {
+		a.Log.Errorf("Failed to initialize CnC reader: %v", err)
+		errs = append(errs, err)
 	}
 
-	return nil
-}
+	dirs := make([]string, 0, len(a.drivers))
+	for dir := range a.drivers {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		d := a.drivers[dir]
+
+		// Collect counter metrics
+		if err := a.collectCounters(acc, d); err != nil {
+			a.Log.Errorf("Failed to collect counters of %s: %v", dir, err)
+			// Try to reinitialize on next gather
+			d.close()
+			delete(a.drivers, dir)
+			errs = append(errs, err)
+			continue
+		}
 
-// initializeReader sets up the CnC file reader
-func (a *AeronStat) initializeReader() error {
-	// Clean up any existing resources
-	a.cleanup()
+		a.collectDriver(acc, d)
 
-	// Get the CnC file path
-	ctx := aeron.NewContext()
-	if a.AeronDir != "" {
-		ctx.AeronDir(a.AeronDir)
+		if a.ErrorLog {
+			a.collectErrorLog(acc, d)
+		}
+
+		if a.LossReport {
+			if err := a.collectLossReport(acc, d); err != nil {
+				acc.AddError(err)
+			}
+		}
 	}
-	cncFileName := ctx.CncFileName()
 
-	a.Log.Debugf("Opening CnC file: %s", cncFileName)
+	return errors.Join(errs...)
+}
+
+// updateDrivers maps the CnC files of new media drivers and unmaps the ones
+// of drivers that vanished or restarted
+func (a *AeronStat) updateDrivers() error {
+	dirs := a.driverDirs()
 
-	// Map the CnC file
-	counterFile, cncFile, err := counters.MapFile(cncFileName)
-	if err != nil {
-		return fmt.Errorf("failed to map CnC file %s: %w", cncFileName, err)
+	for dir, d := range a.drivers {
+		if _, found := dirs[dir]; found && !d.restarted() {
+			continue
+		}
+		a.Log.Infof("Media driver in %s vanished or restarted", dir)
+		d.close()
+		delete(a.drivers, dir)
 	}
 
-	// Create counter reader
-	reader := counters.NewReader(counterFile.ValuesBuf.Get(), counterFile.MetaDataBuf.Get())
+	errs := make([]error, 0)
+	for dir, discovered := range dirs {
+		if _, found := a.drivers[dir]; found {
+			continue
+		}
 
-	// Store references
-	a.counterFile = counterFile
-	a.cncFile = cncFile
-	a.reader = reader
+		a.Log.Debugf("Opening CnC file in: %s", dir)
+		d, err := openMediaDriver(dir, a.Tags, discovered)
+		if err != nil {
+			// Wait for discovered drivers to finish startup
+			if discovered && errors.Is(err, mediadriver.ErrCncNotReady) {
+				a.Log.Debugf("Skipping media driver in %s: %v", dir, err)
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		a.drivers[dir] = d
 
-	a.Log.Debugf("Successfully initialized CnC reader")
-	return nil
+		a.Log.Debugf("Successfully initialized CnC reader for %s", dir)
+	}
+
+	return errors.Join(errs...)
+}
+
+// driverDirs returns the configured Aeron directory and the ones discovered
+// via the glob patterns containing a CnC file. The value is true for
+// discovered directories.
+func (a *AeronStat) driverDirs() map[string]bool {
+	dirs := make(map[string]bool)
+	for _, g := range a.globs {
+		for _, dir := range g.Match() {
+			if _, err := os.Stat(filepath.Join(dir, counters.CncFile)); err == nil {
+				dirs[dir] = true
+			}
+		}
+	}
+	if a.AeronDir != "" {
+		dirs[a.AeronDir] = false
+	}
+	return dirs
 }
 
 // collectCounters gathers all counter metrics
-func (a *AeronStat) collectCounters(acc telegraf.Accumulator) error {
-	if a.reader == nil {
-		return fmt.Errorf("counter reader not initialized")
+func (a *AeronStat) collectCounters(acc telegraf.Accumulator, d *mediaDriver) error {
+	if d.reader == nil {
+		return errors.New("counter reader not initialized")
 	}
 
 	counterCount := 0
 
+	var streams *streamCollector
+	if a.StreamStats {
+		streams = newStreamCollector()
+	}
+
 	// Scan all counters and convert to metrics
-	a.reader.Scan(func(counter counters.Counter) {
+	d.reader.Scan(func(counter counters.Counter) {
 		counterCount++
 
 		// Create base tags
 		tags := make(map[string]string)
 
-		// Add configured tags
-		for key, value := range a.Tags {
+		// Add configured and driver tags
+		for key, value := range d.tags {
 			tags[key] = value
 		}
 
@@ -176,6 +293,10 @@ func (a *AeronStat) collectCounters(acc telegraf.Accumulator) error {
 			tags[key] = value
 		}
 
+		if streams != nil {
+			streams.add(counter, parsedLabel)
+		}
+
 		// Create fields with parsed label information
 		fields := map[string]interface{}{
 			"value": counter.Value,
@@ -195,7 +316,7 @@ func (a *AeronStat) collectCounters(acc telegraf.Accumulator) error {
 
 	// Add summary metric
 	summaryTags := make(map[string]string)
-	for key, value := range a.Tags {
+	for key, value := range d.tags {
 		summaryTags[key] = value
 	}
 
@@ -205,18 +326,110 @@ func (a *AeronStat) collectCounters(acc telegraf.Accumulator) error {
 
 	acc.AddFields("aeron_stat_summary", summaryFields, summaryTags)
 
-	a.Log.Debugf("Collected %d counters", counterCount)
+	if streams != nil {
+		d.streams = streams.emit(acc, d.tags, d.streams, time.Now())
+	}
+
+	a.Log.Debugf("Collected %d counters from %s", counterCount, d.dir)
+	return nil
+}
+
+// collectDriver gathers the media driver information including the age of
+// the driver heartbeat to detect a hung media driver
+func (a *AeronStat) collectDriver(acc telegraf.Accumulator, d *mediaDriver) {
+	tags := make(map[string]string, len(d.tags))
+	for key, value := range d.tags {
+		tags[key] = value
+	}
+
+	now := time.Now()
+	heartbeat := d.toDriver.ConsumerHeartbeatTime()
+	fields := map[string]interface{}{
+		"pid":                 d.counterFile.DriverPid.Get(),
+		"start_timestamp":     d.counterFile.DriverStartTimestamp.Get(),
+		"heartbeat_timestamp": heartbeat,
+		"heartbeat_age_ms":    now.UnixMilli() - heartbeat,
+	}
+
+	acc.AddFields("aeron_driver", fields, tags, now)
+}
+
+// collectErrorLog gathers the errors of the distinct error log observed
+// since the last collection
+func (a *AeronStat) collectErrorLog(acc telegraf.Accumulator, d *mediaDriver) {
+	records := readErrorLog(d.counterFile.ErrorBuf.Get(), d.lastErrorObservation)
+	for _, r := range records {
+		tags := make(map[string]string, len(d.tags))
+		for key, value := range d.tags {
+			tags[key] = value
+		}
+
+		fields := map[string]interface{}{
+			"observation_count": r.observationCount,
+			"first_observation": r.firstObservation,
+			"last_observation":  r.lastObservation,
+			"error":             r.encodedError,
+		}
+
+		acc.AddFields("aeron_error_log", fields, tags, time.UnixMilli(r.lastObservation))
+
+		d.lastErrorObservation = max(d.lastErrorObservation, r.lastObservation)
+	}
+
+	a.Log.Debugf("Collected %d errors from %s", len(records), d.dir)
+}
+
+// collectLossReport gathers the loss observations of the loss report
+func (a *AeronStat) collectLossReport(acc telegraf.Accumulator, d *mediaDriver) error {
+	filename := filepath.Join(d.dir, lossReportFileName)
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The loss report is created on the first loss
+			return nil
+		}
+		return fmt.Errorf("reading loss report %s failed: %w", filename, err)
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+
+	entries := readLossReport(atomic.MakeBuffer(buf))
+	for _, e := range entries {
+		tags := make(map[string]string, len(d.tags)+4)
+		for key, value := range d.tags {
+			tags[key] = value
+		}
+		tags["session_id"] = strconv.FormatInt(int64(e.sessionID), 10)
+		tags["stream_id"] = strconv.FormatInt(int64(e.streamID), 10)
+		tags["channel"] = e.channel
+		tags["source"] = e.source
+
+		fields := map[string]interface{}{
+			"observation_count": e.observationCount,
+			"total_bytes_lost":  e.totalBytesLost,
+			"first_observation": e.firstObservation,
+			"last_observation":  e.lastObservation,
+		}
+
+		acc.AddFields("aeron_loss_report", fields, tags)
+	}
+
+	a.Log.Debugf("Collected %d loss report entries from %s", len(entries), d.dir)
 	return nil
 }
 
 // cleanup releases resources
 func (a *AeronStat) cleanup() {
-	if a.cncFile != nil {
-		a.cncFile.Close()
-		a.cncFile = nil
+	for dir, d := range a.drivers {
+		d.close()
+		delete(a.drivers, dir)
+	}
+
+	if a.releaseDriver != nil {
+		a.releaseDriver()
+		a.releaseDriver = nil
 	}
-	a.counterFile = nil
-	a.reader = nil
 }
 
 // ParsedLabel holds structured information extracted from counter labels
@@ -365,6 +578,20 @@ func (a *AeronStat) parseCounterLabel(label string) ParsedLabel {
 		}
 	}
 
+	// Parse session and stream ID of position counters required to correlate
+	// the counters of a stream, keep the counter metrics unchanged otherwise
+	if !a.StreamStats {
+		return parsed
+	}
+	if match := positionLabelRe.FindStringSubmatch(label); match != nil {
+		if sessionId, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			parsed.fields["session_id"] = sessionId
+		}
+		if streamId, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+			parsed.fields["stream_id"] = streamId
+		}
+	}
+
 	return parsed
 }
 
@@ -1,12 +1,20 @@
 package aeron_stat
 
 import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/counters"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
-	"github.com/stretchr/testify/require"
 )
 
 func TestAeronStat_Init_Defaults(t *testing.T) {
@@ -81,9 +89,7 @@ func TestAeronStat_Cleanup(t *testing.T) {
 	plugin.cleanup()
 	plugin.Stop()
 
-	require.Nil(t, plugin.reader)
-	require.Nil(t, plugin.counterFile)
-	require.Nil(t, plugin.cncFile)
+	require.Empty(t, plugin.drivers)
 }
 
 func TestAeronStat_ParseCounterType(t *testing.T) {
@@ -144,6 +150,27 @@ func TestAeronStat_ParseCounterLabel(t *testing.T) {
 	}
 }
 
+func TestAeronStat_ParseCounterLabel_Position(t *testing.T) {
+	plugin := &AeronStat{}
+
+	// The session and stream ID are only added for stream statistics
+	result := plugin.parseCounterLabel("sub-pos: 42 -1234 10 aeron:udp?endpoint=localhost:40123 @0")
+	require.NotContains(t, result.fields, "session_id")
+	require.NotContains(t, result.fields, "stream_id")
+	require.Equal(t, "aeron:udp?endpoint=localhost:40123", result.tags["channel"])
+
+	plugin.StreamStats = true
+	result = plugin.parseCounterLabel("sub-pos: 42 -1234 10 aeron:udp?endpoint=localhost:40123 @0")
+	require.Equal(t, int64(-1234), result.fields["session_id"])
+	require.Equal(t, int64(10), result.fields["stream_id"])
+	require.Equal(t, "aeron:udp?endpoint=localhost:40123", result.tags["channel"])
+
+	result = plugin.parseCounterLabel("pub-pos (sampled): 7 1234 10 aeron:ipc")
+	require.Equal(t, int64(1234), result.fields["session_id"])
+	require.Equal(t, int64(10), result.fields["stream_id"])
+	require.Equal(t, "aeron:ipc", result.tags["channel"])
+}
+
 func TestAeronStat_GetMeasurementName(t *testing.T) {
 	plugin := &AeronStat{}
 
@@ -168,3 +195,333 @@ func TestAeronStat_GetMeasurementName(t *testing.T) {
 		require.Equal(t, tc.expected, result, "Type ID %d should use measurement %s", tc.typeId, tc.expected)
 	}
 }
+
+func TestAeronStat_ReadErrorLog(t *testing.T) {
+	buf := atomic.MakeBuffer(make([]byte, 1024))
+	offset := putErrorRecord(buf, 0, 3, 1000, 2000, "java.lang.IllegalStateException: first")
+	putErrorRecord(buf, offset, 1, 1500, 1500, "io.aeron.exceptions.AeronException: second")
+
+	records := readErrorLog(buf, 0)
+	require.Equal(t, []errorRecord{
+		{
+			observationCount: 3,
+			firstObservation: 1000,
+			lastObservation:  2000,
+			encodedError:     "java.lang.IllegalStateException: first",
+		},
+		{
+			observationCount: 1,
+			firstObservation: 1500,
+			lastObservation:  1500,
+			encodedError:     "io.aeron.exceptions.AeronException: second",
+		},
+	}, records)
+
+	// Only errors observed after the given timestamp are returned
+	records = readErrorLog(buf, 1500)
+	require.Len(t, records, 1)
+	require.Equal(t, "java.lang.IllegalStateException: first", records[0].encodedError)
+
+	require.Empty(t, readErrorLog(buf, 2000))
+}
+
+func TestAeronStat_ReadErrorLog_Truncated(t *testing.T) {
+	buf := atomic.MakeBuffer(make([]byte, 64))
+	buf.PutInt32(errorLengthOffset, 128)
+	require.Empty(t, readErrorLog(buf, 0))
+}
+
+func TestAeronStat_ReadLossReport(t *testing.T) {
+	buf := atomic.MakeBuffer(make([]byte, 1024))
+	offset := putLossEntry(buf, 0, 2, 4096, 1000, 2000, 1234, 10, "aeron:udp?endpoint=localhost:40123", "127.0.0.1:53412")
+	require.Equal(t, int32(128), offset)
+	putLossEntry(buf, offset, 1, 1408, 3000, 3000, 5678, 11, "aeron:udp?endpoint=localhost:40124", "127.0.0.1:53413")
+
+	entries := readLossReport(buf)
+	require.Equal(t, []lossEntry{
+		{
+			observationCount: 2,
+			totalBytesLost:   4096,
+			firstObservation: 1000,
+			lastObservation:  2000,
+			sessionID:        1234,
+			streamID:         10,
+			channel:          "aeron:udp?endpoint=localhost:40123",
+			source:           "127.0.0.1:53412",
+		},
+		{
+			observationCount: 1,
+			totalBytesLost:   1408,
+			firstObservation: 3000,
+			lastObservation:  3000,
+			sessionID:        5678,
+			streamID:         11,
+			channel:          "aeron:udp?endpoint=localhost:40124",
+			source:           "127.0.0.1:53413",
+		},
+	}, entries)
+}
+
+func TestAeronStat_CollectLossReport(t *testing.T) {
+	dir := t.TempDir()
+
+	plugin := &AeronStat{
+		AeronDir:   dir,
+		LossReport: true,
+		Log:        testutil.Logger{},
+	}
+	driver := &mediaDriver{dir: dir, tags: map[string]string{"env": "test"}}
+
+	// A missing loss report is not an error as the driver creates it lazily
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.collectLossReport(&acc, driver))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	data := make([]byte, 1024)
+	putLossEntry(atomic.MakeBuffer(data), 0, 2, 4096, 1000, 2000, 1234, 10, "aeron:udp?endpoint=localhost:40123", "127.0.0.1:53412")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, lossReportFileName), data, 0o600))
+
+	require.NoError(t, plugin.collectLossReport(&acc, driver))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"aeron_loss_report",
+			map[string]string{
+				"env":        "test",
+				"session_id": "1234",
+				"stream_id":  "10",
+				"channel":    "aeron:udp?endpoint=localhost:40123",
+				"source":     "127.0.0.1:53412",
+			},
+			map[string]interface{}{
+				"observation_count": int64(2),
+				"total_bytes_lost":  int64(4096),
+				"first_observation": int64(1000),
+				"last_observation":  int64(2000),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+// putErrorRecord writes a distinct error log record at the given offset and
+// returns the offset of the next record
+func putErrorRecord(buf *atomic.Buffer, offset, count int32, first, last int64, msg string) int32 {
+	length := int32(errorEncodedErrorOffset + len(msg))
+	buf.PutInt32(offset+errorLengthOffset, length)
+	buf.PutInt32(offset+errorObservationCountOffset, count)
+	buf.PutInt64(offset+errorLastObservationOffset, last)
+	buf.PutInt64(offset+errorFirstObservationOffset, first)
+	b := []byte(msg)
+	buf.PutBytesArray(offset+errorEncodedErrorOffset, &b, 0, int32(len(b)))
+	return offset + align(length, errorRecordAlignment)
+}
+
+// putLossEntry writes a loss report entry at the given offset and returns the
+// offset of the next entry
+func putLossEntry(buf *atomic.Buffer, offset int32, count, lost, first, last int64, session, stream int32, channel, source string) int32 {
+	buf.PutInt64(offset+lossObservationCountOffset, count)
+	buf.PutInt64(offset+lossTotalBytesLostOffset, lost)
+	buf.PutInt64(offset+lossFirstObservationOffset, first)
+	buf.PutInt64(offset+lossLastObservationOffset, last)
+	buf.PutInt32(offset+lossSessionIDOffset, session)
+	buf.PutInt32(offset+lossStreamIDOffset, stream)
+
+	pos := offset + lossChannelOffset
+	for _, s := range []string{channel, source} {
+		b := []byte(s)
+		buf.PutInt32(pos, int32(len(b)))
+		buf.PutBytesArray(pos+4, &b, 0, int32(len(b)))
+		pos += 4 + int32(len(b))
+	}
+	return offset + align(pos-offset, lossEntryAlignment)
+}
+
+func TestAeronStat_StreamStats(t *testing.T) {
+	plugin := &AeronStat{StreamStats: true, Tags: map[string]string{"env": "test"}}
+
+	scan := func(positions map[string]int64) *streamCollector {
+		c := newStreamCollector()
+		for label, value := range positions {
+			c.add(counters.Counter{Value: value, Label: label}, plugin.parseCounterLabel(label))
+		}
+		// Counters without stream information are ignored
+		c.add(counters.Counter{Value: 1, Label: "Bytes sent"}, plugin.parseCounterLabel("Bytes sent"))
+		return c
+	}
+
+	start := time.Unix(1700000000, 0)
+	var acc testutil.Accumulator
+	samples := scan(map[string]int64{
+		"pub-lmt: 1 1234 10 aeron:udp?endpoint=localhost:40123":           4096,
+		"pub-pos (sampled): 1 1234 10 aeron:udp?endpoint=localhost:40123": 3072,
+		"snd-pos: 1 1234 10 aeron:udp?endpoint=localhost:40123":           2048,
+		"rcv-hwm: 2 1234 10 aeron:udp?endpoint=localhost:40123":           2048,
+		"sub-pos: 3 1234 10 aeron:udp?endpoint=localhost:40123 @0":        1024,
+		"sub-pos: 4 1234 10 aeron:udp?endpoint=localhost:40123 @0":        512,
+		"sub-pos: 5 99 11 aeron:ipc @0":                                   100,
+	}).emit(&acc, plugin.Tags, nil, start)
+	require.Len(t, samples, 2)
+
+	acc.ClearMetrics()
+	scan(map[string]int64{
+		"pub-lmt: 1 1234 10 aeron:udp?endpoint=localhost:40123":           8192,
+		"pub-pos (sampled): 1 1234 10 aeron:udp?endpoint=localhost:40123": 6144,
+		"snd-pos: 1 1234 10 aeron:udp?endpoint=localhost:40123":           4096,
+		"rcv-hwm: 2 1234 10 aeron:udp?endpoint=localhost:40123":           4096,
+		"sub-pos: 3 1234 10 aeron:udp?endpoint=localhost:40123 @0":        3072,
+		"sub-pos: 4 1234 10 aeron:udp?endpoint=localhost:40123 @0":        2560,
+		"sub-pos: 5 99 11 aeron:ipc @0":                                   50,
+	}).emit(&acc, plugin.Tags, samples, start.Add(2*time.Second))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"aeron_stream",
+			map[string]string{
+				"env":        "test",
+				"session_id": "1234",
+				"stream_id":  "10",
+				"channel":    "aeron:udp?endpoint=localhost:40123",
+			},
+			map[string]interface{}{
+				"publisher_limit":             int64(8192),
+				"publisher_position":          int64(6144),
+				"sender_position":             int64(4096),
+				"receiver_hwm":                int64(4096),
+				"subscriber_position":         int64(2560),
+				"subscribers":                 2,
+				"sender_lag_bytes":            int64(2048),
+				"consumer_lag_bytes":          int64(1536),
+				"sender_bytes_per_second":     float64(1024),
+				"subscriber_bytes_per_second": float64(1024),
+			},
+			start.Add(2*time.Second),
+		),
+		metric.New(
+			"aeron_stream",
+			map[string]string{
+				"env":        "test",
+				"session_id": "99",
+				"stream_id":  "11",
+				"channel":    "aeron:ipc",
+			},
+			map[string]interface{}{
+				"subscriber_position": int64(50),
+				"subscribers":         1,
+			},
+			start.Add(2*time.Second),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestAeronStat_DiscoverDrivers(t *testing.T) {
+	root := t.TempDir()
+	writeTestCncFile(t, filepath.Join(root, "aeron-a"), counters.CurrentCncVersion, 100)
+	writeTestCncFile(t, filepath.Join(root, "aeron-b"), counters.CurrentCncVersion, 200)
+	// Drivers still initializing their CnC file are skipped silently
+	writeTestCncFile(t, filepath.Join(root, "aeron-c"), 0, 300)
+	// Directories without CnC file are ignored
+	require.NoError(t, os.Mkdir(filepath.Join(root, "aeron-d"), 0o750))
+
+	plugin := &AeronStat{
+		AeronDirs: []string{filepath.Join(root, "aeron-*")},
+		Tags:      map[string]string{"env": "test"},
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.Empty(t, plugin.AeronDir)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	require.NoError(t, plugin.Gather(&acc))
+	require.Equal(t, map[string]string{
+		filepath.Join(root, "aeron-a"): "100",
+		filepath.Join(root, "aeron-b"): "200",
+	}, driverPIDs(t, &acc))
+
+	// Vanished drivers are removed and restarted drivers are mapped again
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "aeron-b")))
+	writeTestCncFile(t, filepath.Join(root, "aeron-a"), counters.CurrentCncVersion, 101)
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Equal(t, map[string]string{
+		filepath.Join(root, "aeron-a"): "101",
+	}, driverPIDs(t, &acc))
+	require.Len(t, plugin.drivers, 1)
+
+	// Drivers finishing their startup are discovered
+	writeTestCncFile(t, filepath.Join(root, "aeron-c"), counters.CurrentCncVersion, 300)
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Equal(t, map[string]string{
+		filepath.Join(root, "aeron-a"): "101",
+		filepath.Join(root, "aeron-c"): "300",
+	}, driverPIDs(t, &acc))
+}
+
+func TestAeronStat_IncompatibleCncVersion(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "aeron")
+	writeTestCncFile(t, dir, 1<<16, 100)
+
+	plugin := &AeronStat{
+		AeronDir: dir,
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	err := plugin.Gather(&acc)
+	require.ErrorContains(t, err, "incompatible CnC version 1.0.0, expected 0.2.0")
+	require.Empty(t, plugin.drivers)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+// driverPIDs returns the PID tag of the driver metrics by Aeron directory
+func driverPIDs(t *testing.T, acc *testutil.Accumulator) map[string]string {
+	t.Helper()
+
+	pids := make(map[string]string)
+	for _, m := range acc.GetTelegrafMetrics() {
+		if m.Name() != "aeron_driver" {
+			continue
+		}
+		require.Equal(t, "test", m.Tags()["env"])
+		age, ok := m.GetField("heartbeat_age_ms")
+		require.True(t, ok)
+		require.Less(t, age.(int64), int64(time.Minute/time.Millisecond))
+		pids[m.Tags()["aeron_dir"]] = m.Tags()["driver_pid"]
+	}
+	return pids
+}
+
+// writeTestCncFile writes a minimal CnC file with an empty to-driver buffer
+// and no counters to the given directory. The file is replaced atomically like
+// a restarting media driver would do.
+func writeTestCncFile(t *testing.T, dir string, version int32, pid int64) {
+	t.Helper()
+
+	const (
+		headerLength   = 128
+		toDriverLength = 1024 + 768 // capacity plus ring buffer trailer
+		heartbeatIndex = headerLength + 1024 + 640
+	)
+
+	buf := make([]byte, headerLength+toDriverLength)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(version))
+	binary.LittleEndian.PutUint32(buf[4:], toDriverLength)
+	binary.LittleEndian.PutUint64(buf[24:], uint64(10*time.Second/time.Millisecond))
+	binary.LittleEndian.PutUint64(buf[32:], uint64(time.Now().UnixMilli()))
+	binary.LittleEndian.PutUint64(buf[40:], uint64(pid))
+	binary.LittleEndian.PutUint64(buf[heartbeatIndex:], uint64(time.Now().UnixMilli()))
+
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	tmp := filepath.Join(dir, counters.CncFile+".tmp")
+	require.NoError(t, os.WriteFile(tmp, buf, 0o600))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, counters.CncFile)))
+}
@@ -0,0 +1,110 @@
+package aeron_stat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/lirm/aeron-go/aeron/counters"
+	rb "github.com/lirm/aeron-go/aeron/ringbuffer"
+	"github.com/lirm/aeron-go/aeron/util"
+	"github.com/lirm/aeron-go/aeron/util/memmap"
+
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
+)
+
+// mediaDriver holds the mapped CnC file of a media driver
+type mediaDriver struct {
+	dir  string
+	tags map[string]string
+
+	// File information of the mapped CnC file to detect restarts
+	info os.FileInfo
+
+	reader      *counters.Reader
+	counterFile *counters.MetaDataFlyweight
+	cncFile     *memmap.File
+	toDriver    rb.ManyToOne
+
+	// Last observation timestamp of the reported errors in epoch ms
+	lastErrorObservation int64
+
+	// Positions of the streams at the last collection to compute rates
+	streams map[streamKey]streamSample
+}
+
+// openMediaDriver maps the CnC file in the given Aeron directory after
+// checking the compatibility of the CnC version. The given tags are added to
+// all metrics of the driver and if requested the directory and PID of the
+// driver are added as tags.
+func openMediaDriver(dir string, tags map[string]string, tagDriver bool) (*mediaDriver, error) {
+	cncFileName := filepath.Join(dir, counters.CncFile)
+
+	info, err := os.Stat(cncFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map CnC file %s: %w", cncFileName, err)
+	}
+
+	version, err := mediadriver.ReadCncVersion(cncFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map CnC file %s: %w", cncFileName, err)
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("failed to map CnC file %s: %w", cncFileName, mediadriver.ErrCncNotReady)
+	}
+	if version != counters.CurrentCncVersion {
+		return nil, fmt.Errorf(
+			"failed to map CnC file %s: incompatible CnC version %s, expected %s",
+			cncFileName,
+			util.SemanticVersionToString(uint32(version)),
+			util.SemanticVersionToString(uint32(counters.CurrentCncVersion)),
+		)
+	}
+
+	counterFile, cncFile, err := counters.MapFile(cncFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map CnC file %s: %w", cncFileName, err)
+	}
+
+	d := &mediaDriver{
+		dir:         dir,
+		tags:        make(map[string]string, len(tags)+2),
+		info:        info,
+		reader:      counters.NewReader(counterFile.ValuesBuf.Get(), counterFile.MetaDataBuf.Get()),
+		counterFile: counterFile,
+		cncFile:     cncFile,
+	}
+	d.toDriver.Init(counterFile.ToDriverBuf.Get())
+
+	for key, value := range tags {
+		d.tags[key] = value
+	}
+	if tagDriver {
+		d.tags["aeron_dir"] = dir
+		d.tags["driver_pid"] = strconv.FormatInt(counterFile.DriverPid.Get(), 10)
+	}
+
+	return d, nil
+}
+
+// restarted checks if the CnC file vanished or was replaced by a new media
+// driver since it was mapped
+func (d *mediaDriver) restarted() bool {
+	info, err := os.Stat(filepath.Join(d.dir, counters.CncFile))
+	if err != nil || !os.SameFile(d.info, info) {
+		return true
+	}
+	return d.counterFile.CncVersion.Get() != counters.CurrentCncVersion
+}
+
+// close unmaps the CnC file
+func (d *mediaDriver) close() {
+	if d.cncFile != nil {
+		d.cncFile.Close()
+		d.cncFile = nil
+	}
+	d.counterFile = nil
+	d.reader = nil
+	d.streams = nil
+}
@@ -0,0 +1,61 @@
+package aeron_stat
+
+import (
+	"github.com/lirm/aeron-go/aeron/atomic"
+)
+
+// Layout of a record in the distinct error log of the CnC file, see
+// org.agrona.concurrent.errors.DistinctErrorLog
+const (
+	errorLengthOffset           = 0
+	errorObservationCountOffset = 4
+	errorLastObservationOffset  = 8
+	errorFirstObservationOffset = 16
+	errorEncodedErrorOffset     = 24
+	errorRecordAlignment        = 8
+)
+
+// errorRecord is a distinct error observed by the media driver
+type errorRecord struct {
+	observationCount int32
+	firstObservation int64 // epoch milliseconds
+	lastObservation  int64 // epoch milliseconds
+	encodedError     string
+}
+
+// readErrorLog returns all records of the distinct error log in the given
+// buffer that were last observed after the given timestamp in epoch ms
+func readErrorLog(buf *atomic.Buffer, since int64) []errorRecord {
+	var records []errorRecord
+
+	capacity := buf.Capacity()
+	for offset := int32(0); offset+errorEncodedErrorOffset <= capacity; {
+		length := buf.GetInt32Volatile(offset + errorLengthOffset)
+		if length <= 0 {
+			break
+		}
+		if length < errorEncodedErrorOffset || length > capacity-offset {
+			// Record is not completely written or corrupt
+			break
+		}
+
+		lastObservation := buf.GetInt64Volatile(offset + errorLastObservationOffset)
+		if lastObservation > since {
+			records = append(records, errorRecord{
+				observationCount: buf.GetInt32Volatile(offset + errorObservationCountOffset),
+				firstObservation: buf.GetInt64(offset + errorFirstObservationOffset),
+				lastObservation:  lastObservation,
+				encodedError:     string(buf.GetBytesArray(offset+errorEncodedErrorOffset, length-errorEncodedErrorOffset)),
+			})
+		}
+
+		offset += align(length, errorRecordAlignment)
+	}
+
+	return records
+}
+
+// align rounds the value up to the next multiple of the given power of two
+func align(value, alignment int32) int32 {
+	return (value + alignment - 1) &^ (alignment - 1)
+}
@@ -63,10 +63,12 @@ func TestAeronStat_IntegrationWithLiveDriver(t *testing.T) {
 	}
 	require.True(t, summaryFound, "Should have summary metrics")
 
-	// Verify metric structure
+	// Verify metric structure of the counter metrics
 	for _, metric := range acc.Metrics {
-		if metric.Measurement != "aeron_stat_summary" {
-			// All non-summary metrics should have these tags
+		switch metric.Measurement {
+		case "aeron_stat_summary", "aeron_driver":
+		default:
+			// All counter metrics should have these tags
 			require.Contains(t, metric.Tags, "counter_id")
 			require.Contains(t, metric.Tags, "type_id")
 			require.Contains(t, metric.Tags, "counter_type")
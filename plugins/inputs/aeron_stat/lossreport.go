@@ -0,0 +1,85 @@
+package aeron_stat
+
+import (
+	"github.com/lirm/aeron-go/aeron/atomic"
+)
+
+// Name of the loss report file in the Aeron directory
+const lossReportFileName = "loss-report.dat"
+
+// Layout of an entry in the loss report, see io.aeron.driver.reports.LossReport
+const (
+	lossObservationCountOffset = 0
+	lossTotalBytesLostOffset   = 8
+	lossFirstObservationOffset = 16
+	lossLastObservationOffset  = 24
+	lossSessionIDOffset        = 32
+	lossStreamIDOffset         = 36
+	lossChannelOffset          = 40
+	lossEntryAlignment         = 64
+)
+
+// lossEntry is the loss observed by the media driver for a stream
+type lossEntry struct {
+	observationCount int64
+	totalBytesLost   int64
+	firstObservation int64 // epoch milliseconds
+	lastObservation  int64 // epoch milliseconds
+	sessionID        int32
+	streamID         int32
+	channel          string
+	source           string
+}
+
+// readLossReport returns all entries of the loss report in the given buffer
+func readLossReport(buf *atomic.Buffer) []lossEntry {
+	var entries []lossEntry
+
+	capacity := buf.Capacity()
+	for offset := int32(0); offset+lossChannelOffset+4 <= capacity; {
+		observationCount := buf.GetInt64Volatile(offset + lossObservationCountOffset)
+		if observationCount <= 0 {
+			break
+		}
+
+		channel, ok := readASCII(buf, offset+lossChannelOffset)
+		if !ok {
+			break
+		}
+		source, ok := readASCII(buf, offset+lossChannelOffset+4+int32(len(channel)))
+		if !ok {
+			break
+		}
+
+		entries = append(entries, lossEntry{
+			observationCount: observationCount,
+			totalBytesLost:   buf.GetInt64Volatile(offset + lossTotalBytesLostOffset),
+			firstObservation: buf.GetInt64(offset + lossFirstObservationOffset),
+			lastObservation:  buf.GetInt64Volatile(offset + lossLastObservationOffset),
+			sessionID:        buf.GetInt32(offset + lossSessionIDOffset),
+			streamID:         buf.GetInt32(offset + lossStreamIDOffset),
+			channel:          channel,
+			source:           source,
+		})
+
+		length := lossChannelOffset + 8 + int32(len(channel)) + int32(len(source))
+		offset += align(length, lossEntryAlignment)
+	}
+
+	return entries
+}
+
+// readASCII reads a length-prefixed ASCII string at the given offset
+func readASCII(buf *atomic.Buffer, offset int32) (string, bool) {
+	if offset+4 > buf.Capacity() {
+		return "", false
+	}
+	length := buf.GetInt32(offset)
+	if length < 0 || length > buf.Capacity()-offset-4 {
+		return "", false
+	}
+	if length == 0 {
+		return "", true
+	}
+	return string(buf.GetBytesArray(offset+4, length)), true
+}
@@ -0,0 +1,187 @@
+package aeron_stat
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/lirm/aeron-go/aeron/counters"
+)
+
+// Label prefixes of the position counters used to correlate the streams
+const (
+	publisherLimitLabel     = "pub-lmt:"
+	publisherPositionLabel  = "pub-pos (sampled):"
+	senderPositionLabel     = "snd-pos:"
+	receiverHWMLabel        = "rcv-hwm:"
+	subscriberPositionLabel = "sub-pos:"
+)
+
+// streamKey identifies a stream across the position counters
+type streamKey struct {
+	sessionID int64
+	streamID  int64
+	channel   string
+}
+
+// streamPositions holds the position counters of a stream
+type streamPositions struct {
+	publisherLimit      *int64
+	publisherPosition   *int64
+	senderPosition      *int64
+	receiverHWM         *int64
+	subscriberPositions []int64
+}
+
+// streamSample holds the positions of a stream used to compute the rates
+// at the next collection
+type streamSample struct {
+	timestamp          time.Time
+	senderPosition     *int64
+	subscriberPosition *int64
+}
+
+// streamCollector joins the position counters of a scan by stream
+type streamCollector struct {
+	streams map[streamKey]*streamPositions
+}
+
+func newStreamCollector() *streamCollector {
+	return &streamCollector{streams: make(map[streamKey]*streamPositions)}
+}
+
+// add records the counter if it is a position counter of a stream
+func (c *streamCollector) add(counter counters.Counter, parsed ParsedLabel) {
+	sessionID, ok := parsed.fields["session_id"].(int64)
+	if !ok {
+		return
+	}
+	streamID, ok := parsed.fields["stream_id"].(int64)
+	if !ok {
+		return
+	}
+	channel, ok := parsed.tags["channel"]
+	if !ok {
+		return
+	}
+
+	var position **int64
+	key := streamKey{sessionID: sessionID, streamID: streamID, channel: channel}
+	stream := c.streams[key]
+	if stream == nil {
+		stream = &streamPositions{}
+	}
+
+	value := counter.Value
+	switch {
+	case strings.HasPrefix(counter.Label, publisherLimitLabel):
+		position = &stream.publisherLimit
+	case strings.HasPrefix(counter.Label, publisherPositionLabel):
+		position = &stream.publisherPosition
+	case strings.HasPrefix(counter.Label, senderPositionLabel):
+		position = &stream.senderPosition
+	case strings.HasPrefix(counter.Label, receiverHWMLabel):
+		position = &stream.receiverHWM
+	case strings.HasPrefix(counter.Label, subscriberPositionLabel):
+		stream.subscriberPositions = append(stream.subscriberPositions, value)
+	default:
+		return
+	}
+	if position != nil {
+		*position = &value
+	}
+	c.streams[key] = stream
+}
+
+// emit adds a metric per stream and returns the samples for computing the
+// rates at the next collection
+func (c *streamCollector) emit(
+	acc telegraf.Accumulator,
+	globalTags map[string]string,
+	previous map[streamKey]streamSample,
+	now time.Time,
+) map[streamKey]streamSample {
+	samples := make(map[streamKey]streamSample, len(c.streams))
+	for key, stream := range c.streams {
+		tags := make(map[string]string, len(globalTags)+3)
+		for k, v := range globalTags {
+			tags[k] = v
+		}
+		tags["session_id"] = strconv.FormatInt(key.sessionID, 10)
+		tags["stream_id"] = strconv.FormatInt(key.streamID, 10)
+		tags["channel"] = key.channel
+
+		fields := make(map[string]interface{}, 10)
+		if stream.publisherLimit != nil {
+			fields["publisher_limit"] = *stream.publisherLimit
+		}
+		if stream.publisherPosition != nil {
+			fields["publisher_position"] = *stream.publisherPosition
+		}
+		if stream.senderPosition != nil {
+			fields["sender_position"] = *stream.senderPosition
+		}
+		if stream.receiverHWM != nil {
+			fields["receiver_hwm"] = *stream.receiverHWM
+		}
+
+		// The slowest subscriber determines the lag of the stream
+		var subscriberPosition *int64
+		if len(stream.subscriberPositions) > 0 {
+			slowest := stream.subscriberPositions[0]
+			for _, p := range stream.subscriberPositions[1:] {
+				slowest = min(slowest, p)
+			}
+			subscriberPosition = &slowest
+			fields["subscriber_position"] = slowest
+			fields["subscribers"] = len(stream.subscriberPositions)
+		}
+
+		// Data offered by the publisher but not yet sent to the network
+		if stream.publisherPosition != nil && stream.senderPosition != nil {
+			fields["sender_lag_bytes"] = max(*stream.publisherPosition-*stream.senderPosition, 0)
+		}
+
+		// Data received, or published for IPC streams, but not yet consumed
+		// by the slowest subscriber
+		if subscriberPosition != nil {
+			if stream.receiverHWM != nil {
+				fields["consumer_lag_bytes"] = max(*stream.receiverHWM-*subscriberPosition, 0)
+			} else if stream.publisherPosition != nil {
+				fields["consumer_lag_bytes"] = max(*stream.publisherPosition-*subscriberPosition, 0)
+			}
+		}
+
+		// Throughput since the last collection, positions going backwards
+		// indicate a new stream with the same key
+		sample := streamSample{
+			timestamp:          now,
+			senderPosition:     stream.senderPosition,
+			subscriberPosition: subscriberPosition,
+		}
+		if prev, found := previous[key]; found {
+			if elapsed := now.Sub(prev.timestamp).Seconds(); elapsed > 0 {
+				if rate, ok := positionRate(prev.senderPosition, sample.senderPosition, elapsed); ok {
+					fields["sender_bytes_per_second"] = rate
+				}
+				if rate, ok := positionRate(prev.subscriberPosition, sample.subscriberPosition, elapsed); ok {
+					fields["subscriber_bytes_per_second"] = rate
+				}
+			}
+		}
+		samples[key] = sample
+
+		acc.AddFields("aeron_stream", fields, tags, now)
+	}
+
+	return samples
+}
+
+// positionRate returns the rate of change between the two positions
+func positionRate(previous, current *int64, elapsed float64) (float64, bool) {
+	if previous == nil || current == nil || *current < *previous {
+		return 0, false
+	}
+	return float64(*current-*previous) / elapsed, true
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
@@ -16,32 +18,50 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
+const defaultMaxUndeliveredMessages = 1000
+
+type empty struct{}
+type semaphore chan empty
+
 // AeronSubscriber represents the Aeron subscriber input plugin
 type AeronSubscriber struct {
 	// Configuration options
-	AeronDir          string          `toml:"aeron_dir"`
-	Channel           string          `toml:"channel"`
-	StreamID          int32           `toml:"stream_id"`
-	DriverTimeout     config.Duration `toml:"driver_timeout"`
-	FragmentLimit     int             `toml:"fragment_limit"`
-	IdleStrategy      string          `toml:"idle_strategy"`
-	IdleSleepDuration config.Duration `toml:"idle_sleep_duration"`
-	Log               telegraf.Logger `toml:"-"`
+	AeronDir               string              `toml:"aeron_dir"`
+	Channel                string              `toml:"channel"`
+	StreamID               int32               `toml:"stream_id"`
+	DriverTimeout          config.Duration     `toml:"driver_timeout"`
+	FragmentLimit          int                 `toml:"fragment_limit"`
+	IdleStrategy           string              `toml:"idle_strategy"`
+	IdleSleepDuration      config.Duration     `toml:"idle_sleep_duration"`
+	HeaderTags             []string            `toml:"header_tags"`
+	HeaderFields           []string            `toml:"header_fields"`
+	ImageEvents            bool                `toml:"image_events"`
+	MaxUndeliveredMessages int                 `toml:"max_undelivered_messages"`
+	MediaDriver            *mediadriver.Config `toml:"media_driver"`
+	Log                    telegraf.Logger     `toml:"-"`
 
 	// Internal state
 	parser             telegraf.Parser
+	releaseDriver      func()
 	aeron              *aeron.Aeron
 	subscription       *aeron.Subscription
 	assembler          *aeron.FragmentAssembler
 	currentAccumulator telegraf.Accumulator // Store current accumulator for fragment handler
+	tracking           telegraf.TrackingAccumulator
+	sem                semaphore
 	cancel             context.CancelFunc
 	wg                 *sync.WaitGroup
+
+	// Source identities of the currently available images by session ID
+	sources     map[int32]string
+	sourcesLock sync.Mutex
 }
 
 // SampleConfig returns the sample configuration for the plugin
@@ -68,6 +88,10 @@ func (a *AeronSubscriber) Init() error {
 		a.IdleSleepDuration = config.Duration(1 * time.Millisecond)
 	}
 
+	if a.MaxUndeliveredMessages == 0 {
+		a.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
+	}
+
 	// Validate required configuration
 	if a.Channel == "" {
 		return fmt.Errorf("channel is required")
@@ -77,6 +101,34 @@ func (a *AeronSubscriber) Init() error {
 		return fmt.Errorf("stream_id must be non-negative")
 	}
 
+	if a.MaxUndeliveredMessages < 0 {
+		return fmt.Errorf("max_undelivered_messages must be non-negative")
+	}
+
+	for _, tag := range a.HeaderTags {
+		switch tag {
+		case "session_id", "stream_id", "source_identity":
+		default:
+			return fmt.Errorf("invalid header tag %q", tag)
+		}
+	}
+
+	for _, field := range a.HeaderFields {
+		switch field {
+		case "position", "term_offset":
+		default:
+			return fmt.Errorf("invalid header field %q", field)
+		}
+	}
+
+	if a.MediaDriver != nil {
+		if err := mediadriver.Register(a.AeronDir, a.MediaDriver, a.Log); err != nil {
+			return fmt.Errorf("registering media driver failed: %w", err)
+		}
+	}
+
+	a.sources = make(map[int32]string)
+
 	return nil
 }
 
@@ -89,16 +141,44 @@ func (a *AeronSubscriber) SetParser(parser telegraf.Parser) {
 func (a *AeronSubscriber) Start(acc telegraf.Accumulator) error {
 	a.Log.Info("Starting Aeron subscriber plugin")
 
+	// Store the accumulator before connecting as image events might arrive
+	// as soon as the subscription is added
+	a.currentAccumulator = acc
+
+	// Use tracking metrics to limit the number of messages not yet written
+	// by the outputs
+	a.tracking = acc.WithTracking(a.MaxUndeliveredMessages)
+	a.sem = make(semaphore, a.MaxUndeliveredMessages)
+
+	// Wait for the media driver if it is launched by Telegraf
+	release, err := mediadriver.Acquire(a.AeronDir, time.Duration(a.DriverTimeout))
+	if err != nil {
+		return err
+	}
+
 	// Setup Aeron connection
 	if err := a.connect(); err != nil {
+		release()
 		return fmt.Errorf("failed to connect to Aeron: %w", err)
 	}
+	a.releaseDriver = release
 
 	ctx, cancel := context.WithCancel(context.Background())
 	a.cancel = cancel
 
 	a.wg = &sync.WaitGroup{}
-	a.wg.Add(1)
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.tracking.Delivered():
+				<-a.sem
+			}
+		}
+	}()
 	go func() {
 		defer a.wg.Done()
 		a.consume(ctx, acc)
@@ -129,10 +209,12 @@ func (a *AeronSubscriber) connect() error {
 		return fmt.Errorf("failed to connect to Aeron: %w", err)
 	}
 
-	// Add subscription
-	a.subscription, err = a.aeron.AddSubscription(a.Channel, a.StreamID)
+	// Add subscription tracking the images, i.e. publishers, joining and
+	// leaving the subscription
+	a.subscription, err = a.aeron.AddSubscriptionWithHandlers(a.Channel, a.StreamID, a.onAvailableImage, a.onUnavailableImage)
 	if err != nil {
 		a.aeron.Close()
+		a.aeron = nil
 		return fmt.Errorf("failed to add subscription: %w", err)
 	}
 
@@ -159,16 +241,137 @@ func (a *AeronSubscriber) createFragmentHandler() term.FragmentHandler {
 			return
 		}
 
-		// Add metrics to accumulator (stored in context via closure)
-		if acc := a.getAccumulator(); acc != nil {
+		// Add the requested header information
+		if len(a.HeaderTags) > 0 || len(a.HeaderFields) > 0 {
 			for _, metric := range metrics {
-				acc.AddMetric(metric)
+				a.addHeaderInfo(metric, header)
 			}
+		}
+
+		// Add metrics of the message as a tracking group occupying one slot of
+		// the undelivered messages. The consume loop makes sure to only poll
+		// as many fragments as there are free slots so this never blocks.
+		if len(metrics) > 0 {
+			a.sem <- empty{}
+			a.tracking.AddTrackingMetricGroup(metrics)
 			a.Log.Debugf("Added %d metrics to accumulator", len(metrics))
 		}
 	}
 }
 
+// addHeaderInfo adds the configured Aeron header information as tags and
+// fields to the given metric
+func (a *AeronSubscriber) addHeaderInfo(metric telegraf.Metric, header *logbuffer.Header) {
+	for _, tag := range a.HeaderTags {
+		switch tag {
+		case "session_id":
+			metric.AddTag("session_id", strconv.FormatInt(int64(header.SessionId()), 10))
+		case "stream_id":
+			metric.AddTag("stream_id", strconv.FormatInt(int64(header.StreamId()), 10))
+		case "source_identity":
+			if source := a.sourceIdentity(header.SessionId()); source != "" {
+				metric.AddTag("source_identity", source)
+			}
+		}
+	}
+
+	for _, field := range a.HeaderFields {
+		switch field {
+		case "position":
+			metric.AddField("position", header.Position())
+		case "term_offset":
+			metric.AddField("term_offset", int64(header.Offset()))
+		}
+	}
+}
+
+// sourceIdentity returns the source identity of the image with the given
+// session ID or an empty string if the image is unknown
+func (a *AeronSubscriber) sourceIdentity(sessionID int32) string {
+	a.sourcesLock.Lock()
+	defer a.sourcesLock.Unlock()
+	return a.sources[sessionID]
+}
+
+// onAvailableImage is called by the Aeron client conductor when a publisher
+// joins the subscription
+func (a *AeronSubscriber) onAvailableImage(image aeron.Image) {
+	source := imageSourceIdentity(image)
+	a.Log.Debugf("Image available: sessionId=%d, source=%s", image.SessionID(), source)
+
+	a.sourcesLock.Lock()
+	a.sources[image.SessionID()] = source
+	a.sourcesLock.Unlock()
+
+	a.emitImageEvent("available", image, source)
+}
+
+// onUnavailableImage is called by the Aeron client conductor when a publisher
+// leaves the subscription, e.g. due to a timeout or end-of-stream
+func (a *AeronSubscriber) onUnavailableImage(image aeron.Image) {
+	// The conductor passes a nil image if it does not know about the image
+	if image == nil {
+		return
+	}
+
+	a.sourcesLock.Lock()
+	source := a.sources[image.SessionID()]
+	delete(a.sources, image.SessionID())
+	a.sourcesLock.Unlock()
+
+	a.Log.Debugf("Image unavailable: sessionId=%d, source=%s", image.SessionID(), source)
+	a.emitImageEvent("unavailable", image, source)
+}
+
+// emitImageEvent adds a metric for the given image lifecycle event if enabled
+func (a *AeronSubscriber) emitImageEvent(event string, image aeron.Image, source string) {
+	acc := a.getAccumulator()
+	if !a.ImageEvents || acc == nil {
+		return
+	}
+
+	tags := map[string]string{
+		"channel":    a.Channel,
+		"stream_id":  strconv.FormatInt(int64(a.StreamID), 10),
+		"session_id": strconv.FormatInt(int64(image.SessionID()), 10),
+	}
+	if source != "" {
+		tags["source_identity"] = source
+	}
+	fields := map[string]interface{}{
+		"event":          event,
+		"correlation_id": image.CorrelationID(),
+		"images":         a.imageCount(),
+	}
+	acc.AddFields("aeron_subscriber_image", fields, tags)
+}
+
+// imageCount returns the number of images currently known
+func (a *AeronSubscriber) imageCount() int {
+	a.sourcesLock.Lock()
+	defer a.sourcesLock.Unlock()
+	return len(a.sources)
+}
+
+// imageSourceIdentity returns the source identity of the given image. The
+// aeron-go image does not expose the identity so use reflection to access
+// the field as a workaround.
+func imageSourceIdentity(image aeron.Image) string {
+	v := reflect.ValueOf(image)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ""
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName("sourceIdentity")
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
 // getAccumulator gets the current accumulator from the context
 // This is a simple implementation using a stored reference
 func (a *AeronSubscriber) getAccumulator() telegraf.Accumulator {
@@ -212,8 +415,19 @@ func (a *AeronSubscriber) consume(ctx context.Context, acc telegraf.Accumulator)
 			a.Log.Info("Context cancelled, stopping consumption")
 			return
 		default:
-			// Poll for fragments with configured limit
-			fragmentsRead := a.subscription.Poll(a.assembler.OnFragment, a.FragmentLimit)
+			// Stop polling while the maximum number of undelivered messages is
+			// reached. This lets Aeron flow control push back on the publishers
+			// until the outputs caught up.
+			limit := min(a.FragmentLimit, cap(a.sem)-len(a.sem))
+			if limit <= 0 {
+				idleStrategy.Idle(0)
+				continue
+			}
+
+			// Poll for fragments with configured limit, each complete message
+			// consists of at least one fragment so limiting the fragments
+			// also limits the number of messages
+			fragmentsRead := a.subscription.Poll(a.assembler.OnFragment, limit)
 
 			// Use idle strategy - it will internally decide whether to idle based on fragmentsRead
 			idleStrategy.Idle(fragmentsRead)
@@ -243,7 +457,27 @@ func (a *AeronSubscriber) Stop() {
 		a.wg.Wait()
 	}
 
-	// Phase 2: Aeron connection cleanup will be added here
+	// Close the subscription and the client before releasing the media driver
+	// as the driver might be stopped on release
+	if a.subscription != nil {
+		if err := a.subscription.Close(); err != nil {
+			a.Log.Errorf("Closing subscription failed: %v", err)
+		}
+		a.subscription = nil
+	}
+
+	if a.aeron != nil {
+		if err := a.aeron.Close(); err != nil {
+			a.Log.Errorf("Closing Aeron client failed: %v", err)
+		}
+		a.aeron = nil
+	}
+
+	if a.releaseDriver != nil {
+		a.releaseDriver()
+		a.releaseDriver = nil
+	}
+
 	a.Log.Info("Aeron subscriber plugin stopped")
 }
 
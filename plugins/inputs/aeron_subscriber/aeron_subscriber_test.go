@@ -3,8 +3,15 @@ package aeron_subscriber
 import (
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -35,6 +42,7 @@ func TestAeronSubscriber_Init_Defaults(t *testing.T) {
 	require.Equal(t, 10, plugin.FragmentLimit)
 	require.Equal(t, "backoff", plugin.IdleStrategy)
 	require.Equal(t, config.Duration(1*time.Millisecond), plugin.IdleSleepDuration)
+	require.Equal(t, 1000, plugin.MaxUndeliveredMessages)
 }
 
 func TestAeronSubscriber_Init_ValidationErrors(t *testing.T) {
@@ -378,3 +386,200 @@ func TestAeronSubscriber_CustomConfig(t *testing.T) {
 	require.Equal(t, config.Duration(15*time.Second), plugin.DriverTimeout)
 	require.Equal(t, config.Duration(500*time.Microsecond), plugin.IdleSleepDuration)
 }
+
+// Test validation of header tags and fields
+func TestAeronSubscriber_Init_InvalidHeaderInfo(t *testing.T) {
+	plugin := &AeronSubscriber{
+		Channel:    "aeron:ipc",
+		StreamID:   1001,
+		HeaderTags: []string{"session_id", "foo"},
+		Log:        testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), `invalid header tag "foo"`)
+
+	plugin = &AeronSubscriber{
+		Channel:      "aeron:ipc",
+		StreamID:     1001,
+		HeaderFields: []string{"bar"},
+		Log:          testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), `invalid header field "bar"`)
+}
+
+// testImage mimics the aeron-go image implementation for the fields we use
+type testImage struct {
+	aeron.Image
+	sessionID      int32
+	correlationID  int64
+	sourceIdentity string
+}
+
+func (img *testImage) SessionID() int32 {
+	return img.sessionID
+}
+
+func (img *testImage) CorrelationID() int64 {
+	return img.correlationID
+}
+
+// newTestFrame constructs a data frame with header and the given payload
+func newTestFrame(payload []byte) (*atomic.Buffer, *logbuffer.Header) {
+	frameLength := logbuffer.DataFrameHeader.Length + int32(len(payload))
+	frame := make([]byte, frameLength)
+	buffer := atomic.MakeBuffer(frame)
+	buffer.PutInt32(logbuffer.DataFrameHeader.FrameLengthFieldOffset, frameLength)
+	buffer.PutInt32(logbuffer.DataFrameHeader.TermOffsetFieldOffset, 0)
+	buffer.PutInt32(logbuffer.DataFrameHeader.SessionIDFieldOffset, 42)
+	buffer.PutInt32(logbuffer.DataFrameHeader.StreamIDFieldOffset, 1001)
+	buffer.PutInt32(logbuffer.DataFrameHeader.TermIDFieldOffset, 0)
+	buffer.PutBytesArray(logbuffer.DataFrameHeader.Length, &payload, 0, int32(len(payload)))
+
+	header := &logbuffer.Header{}
+	header.Wrap(unsafe.Pointer(&frame[0]), frameLength)
+	header.SetPositionBitsToShift(16)
+
+	return buffer, header
+}
+
+// Test adding the header information to the parsed metrics
+func TestAeronSubscriber_HeaderInfo(t *testing.T) {
+	plugin := &AeronSubscriber{
+		Channel:      "aeron:ipc",
+		StreamID:     1001,
+		HeaderTags:   []string{"session_id", "stream_id", "source_identity"},
+		HeaderFields: []string{"position", "term_offset"},
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	parser := &influx.Parser{}
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+
+	var acc testutil.Accumulator
+	plugin.currentAccumulator = &acc
+	plugin.tracking = acc.WithTracking(plugin.MaxUndeliveredMessages)
+	plugin.sem = make(semaphore, plugin.MaxUndeliveredMessages)
+	plugin.onAvailableImage(&testImage{sessionID: 42, correlationID: 1, sourceIdentity: "127.0.0.1:40123"})
+
+	payload := []byte("test value=1i 1609459200000000000")
+	buffer, header := newTestFrame(payload)
+
+	handler := plugin.createFragmentHandler()
+	handler(buffer, logbuffer.DataFrameHeader.Length, int32(len(payload)), header)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{
+				"session_id":      "42",
+				"stream_id":       "1001",
+				"source_identity": "127.0.0.1:40123",
+			},
+			map[string]interface{}{
+				"value":       int64(1),
+				"position":    int64(96),
+				"term_offset": int64(0),
+			},
+			time.Unix(1609459200, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+// Test the image lifecycle events
+func TestAeronSubscriber_ImageEvents(t *testing.T) {
+	plugin := &AeronSubscriber{
+		Channel:     "aeron:ipc",
+		StreamID:    1001,
+		ImageEvents: true,
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	plugin.currentAccumulator = &acc
+
+	img := &testImage{sessionID: 42, correlationID: 7, sourceIdentity: "127.0.0.1:40123"}
+	plugin.onAvailableImage(img)
+	require.Equal(t, "127.0.0.1:40123", plugin.sourceIdentity(42))
+	plugin.onUnavailableImage(img)
+	require.Empty(t, plugin.sourceIdentity(42))
+
+	// Unknown images must be ignored
+	plugin.onUnavailableImage(nil)
+
+	tags := map[string]string{
+		"channel":         "aeron:ipc",
+		"stream_id":       "1001",
+		"session_id":      "42",
+		"source_identity": "127.0.0.1:40123",
+	}
+	expected := []telegraf.Metric{
+		metric.New(
+			"aeron_subscriber_image",
+			tags,
+			map[string]interface{}{"event": "available", "correlation_id": int64(7), "images": 1},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"aeron_subscriber_image",
+			tags,
+			map[string]interface{}{"event": "unavailable", "correlation_id": int64(7), "images": 0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+// Test tracking of undelivered messages
+func TestAeronSubscriber_TrackingMetrics(t *testing.T) {
+	plugin := &AeronSubscriber{
+		Channel:                "aeron:ipc",
+		StreamID:               1001,
+		MaxUndeliveredMessages: 2,
+		Log:                    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	parser := &influx.Parser{}
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+
+	var acc testutil.Accumulator
+	plugin.tracking = acc.WithTracking(plugin.MaxUndeliveredMessages)
+	plugin.sem = make(semaphore, plugin.MaxUndeliveredMessages)
+
+	// Each message occupies one slot independent of the number of metrics
+	handler := plugin.createFragmentHandler()
+	payload := []byte("test value=1i 1609459200000000000\ntest value=2i 1609459201000000000")
+	buffer, header := newTestFrame(payload)
+	handler(buffer, logbuffer.DataFrameHeader.Length, int32(len(payload)), header)
+	require.Len(t, plugin.sem, 1)
+
+	// Invalid messages must not occupy a slot
+	invalid := []byte("invalid line protocol")
+	buffer, header = newTestFrame(invalid)
+	handler(buffer, logbuffer.DataFrameHeader.Length, int32(len(invalid)), header)
+	require.Len(t, plugin.sem, 1)
+
+	buffer, header = newTestFrame(payload)
+	handler(buffer, logbuffer.DataFrameHeader.Length, int32(len(payload)), header)
+	require.Len(t, plugin.sem, 2)
+
+	// Delivering the metrics releases the slots
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 4)
+	for _, m := range metrics {
+		m.Accept()
+	}
+	for range 2 {
+		select {
+		case <-plugin.tracking.Delivered():
+			<-plugin.sem
+		case <-time.After(time.Second):
+			require.FailNow(t, "message not delivered")
+		}
+	}
+	require.Empty(t, plugin.sem)
+}
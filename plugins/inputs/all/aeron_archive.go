@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.aeron_archive
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/aeron_archive" // register plugin
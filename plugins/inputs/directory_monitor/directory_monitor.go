@@ -47,13 +47,14 @@ type DirectoryMonitor struct {
 	ErrorDirectory    string `toml:"error_directory"`
 	FileTag           string `toml:"file_tag"`
 
-	FilesToMonitor             []string        `toml:"files_to_monitor"`
-	FilesToIgnore              []string        `toml:"files_to_ignore"`
-	MaxBufferedMetrics         int             `toml:"max_buffered_metrics"`
-	DirectoryDurationThreshold config.Duration `toml:"directory_duration_threshold"`
-	Log                        telegraf.Logger `toml:"-"`
-	FileQueueSize              int             `toml:"file_queue_size"`
-	ParseMethod                string          `toml:"parse_method"`
+	FilesToMonitor             []string                `toml:"files_to_monitor"`
+	FilesToIgnore              []string                `toml:"files_to_ignore"`
+	MaxBufferedMetrics         int                     `toml:"max_buffered_metrics"`
+	DirectoryDurationThreshold config.Duration         `toml:"directory_duration_threshold"`
+	Log                        telegraf.Logger         `toml:"-"`
+	FileQueueSize              int                     `toml:"file_queue_size"`
+	ParseMethod                string                  `toml:"parse_method"`
+	Persister                  telegraf.StatePersister `toml:"-"`
 
 	filesInUse          sync.Map
 	cancel              context.CancelFunc
@@ -70,6 +71,32 @@ type DirectoryMonitor struct {
 	fileRegexesToMatch  []*regexp.Regexp
 	fileRegexesToIgnore []*regexp.Regexp
 	filesToProcess      chan string
+
+	// Number of metrics of partially processed files already delivered to
+	// the outputs and the metrics not yet delivered
+	progress     map[string]int64
+	undelivered  map[telegraf.TrackingID]undeliveredMetric
+	progressLock sync.Mutex
+}
+
+// fileProgress counts the metrics of a file passed on and delivered, skipping
+// the metrics delivered before a restart
+type fileProgress struct {
+	filename string
+	skip     int64
+	sent     int64
+
+	// Number of metrics from the start of the file delivered and the indices
+	// of metrics delivered out of order
+	delivered int64
+	done      map[int64]bool
+	finished  bool
+}
+
+// undeliveredMetric is a metric of a file passed on but not yet delivered
+type undeliveredMetric struct {
+	progress *fileProgress
+	index    int64
 }
 
 func (*DirectoryMonitor) SampleConfig() string {
@@ -116,6 +143,8 @@ func (monitor *DirectoryMonitor) Init() error {
 		}
 	}
 
+	monitor.progress = make(map[string]int64)
+	monitor.undelivered = make(map[telegraf.TrackingID]undeliveredMetric)
 	monitor.waitGroup = &sync.WaitGroup{}
 	monitor.sem = semaphore.NewWeighted(int64(monitor.MaxBufferedMetrics))
 	monitor.context, monitor.cancel = context.WithCancel(context.Background())
@@ -145,11 +174,39 @@ func (monitor *DirectoryMonitor) Init() error {
 	return nil
 }
 
+// GetState returns the progress of the partially processed files
+func (monitor *DirectoryMonitor) GetState() interface{} {
+	monitor.progressLock.Lock()
+	defer monitor.progressLock.Unlock()
+
+	state := make(map[string]int64, len(monitor.progress))
+	for k, v := range monitor.progress {
+		state[k] = v
+	}
+	return state
+}
+
+func (monitor *DirectoryMonitor) SetState(state interface{}) error {
+	progress, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	monitor.progressLock.Lock()
+	defer monitor.progressLock.Unlock()
+
+	for k, v := range progress {
+		monitor.progress[k] = v
+	}
+	return nil
+}
+
 func (monitor *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
 	// Use tracking to determine when more metrics can be added without overflowing the outputs.
 	monitor.acc = acc.WithTracking(monitor.MaxBufferedMetrics)
 	go func() {
-		for range monitor.acc.Delivered() {
+		for track := range monitor.acc.Delivered() {
+			monitor.onDelivery(track)
 			monitor.sem.Release(1)
 		}
 	}()
@@ -275,6 +332,15 @@ func (monitor *DirectoryMonitor) processFile(path string) {
 func (monitor *DirectoryMonitor) read(filePath string) {
 	// Open, read, and parse the contents of the file.
 	err := monitor.ingestFile(filePath)
+
+	// Keep partially processed files to resume them after a restart if the
+	// progress is persisted
+	if monitor.resumable(err) {
+		monitor.Log.Debugf("Stopped processing %q, resuming after restart", filePath)
+		return
+	}
+	defer monitor.finish(filePath)
+
 	var pathErr *os.PathError
 	if errors.As(err, &pathErr) {
 		return
@@ -297,6 +363,24 @@ func (monitor *DirectoryMonitor) read(filePath string) {
 	monitor.filesProcessedDir.Incr(1)
 }
 
+// resumable returns true if processing the file was stopped with the given
+// error and can be resumed after a restart
+func (monitor *DirectoryMonitor) resumable(err error) bool {
+	return errors.Is(err, context.Canceled) && monitor.Persister != nil
+}
+
+// finish forgets the progress of a file no longer in the monitored directory
+func (monitor *DirectoryMonitor) finish(filePath string) {
+	monitor.progressLock.Lock()
+	_, found := monitor.progress[filePath]
+	delete(monitor.progress, filePath)
+	monitor.progressLock.Unlock()
+
+	if found && monitor.Persister != nil {
+		monitor.Persister.Checkpoint()
+	}
+}
+
 func (monitor *DirectoryMonitor) ingestFile(filePath string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -320,16 +404,29 @@ func (monitor *DirectoryMonitor) ingestFile(filePath string) error {
 		reader = file
 	}
 
-	return monitor.parseFile(parser, reader, file.Name())
+	monitor.progressLock.Lock()
+	skip := monitor.progress[filePath]
+	progress := &fileProgress{filename: filePath, skip: skip, delivered: skip, done: make(map[int64]bool)}
+	monitor.progressLock.Unlock()
+
+	err = monitor.parseFile(parser, reader, progress)
+
+	// Ignore deliveries of the remaining metrics once the file is done
+	if !monitor.resumable(err) {
+		monitor.progressLock.Lock()
+		progress.finished = true
+		monitor.progressLock.Unlock()
+	}
+	return err
 }
 
-func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Reader, fileName string) error {
+func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Reader, progress *fileProgress) error {
 	var splitter bufio.SplitFunc
 
 	// Decide on how to split the file
 	switch monitor.ParseMethod {
 	case "at-once":
-		return monitor.parseAtOnce(parser, reader, fileName)
+		return monitor.parseAtOnce(parser, reader, progress)
 	case "line-by-line":
 		splitter = bufio.ScanLines
 	default:
@@ -340,12 +437,12 @@ func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Rea
 	scanner.Split(splitter)
 
 	for scanner.Scan() {
-		metrics, err := monitor.parseMetrics(parser, scanner.Bytes(), fileName)
+		metrics, err := monitor.parseMetrics(parser, scanner.Bytes(), progress.filename)
 		if err != nil {
 			return err
 		}
 
-		if err := monitor.sendMetrics(metrics); err != nil {
+		if err := monitor.sendMetrics(metrics, progress); err != nil {
 			return err
 		}
 	}
@@ -353,18 +450,18 @@ func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Rea
 	return scanner.Err()
 }
 
-func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, fileName string) error {
+func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, progress *fileProgress) error {
 	bytes, err := io.ReadAll(reader)
 	if err != nil {
 		return err
 	}
 
-	metrics, err := monitor.parseMetrics(parser, bytes, fileName)
+	metrics, err := monitor.parseMetrics(parser, bytes, progress.filename)
 	if err != nil {
 		return err
 	}
 
-	return monitor.sendMetrics(metrics)
+	return monitor.sendMetrics(metrics, progress)
 }
 
 func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byte, fileName string) (metrics []telegraf.Metric, err error) {
@@ -391,18 +488,57 @@ func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byt
 	return metrics, err
 }
 
-func (monitor *DirectoryMonitor) sendMetrics(metrics []telegraf.Metric) error {
+func (monitor *DirectoryMonitor) sendMetrics(metrics []telegraf.Metric, progress *fileProgress) error {
 	// Report the metrics for the file.
 	for _, m := range metrics {
+		// Skip the metrics already delivered before a restart.
+		if progress.sent < progress.skip {
+			progress.sent++
+			continue
+		}
+
 		// Block until metric can be written.
 		if err := monitor.sem.Acquire(monitor.context, 1); err != nil {
 			return err
 		}
-		monitor.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+		monitor.progressLock.Lock()
+		id := monitor.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+		monitor.undelivered[id] = undeliveredMetric{progress: progress, index: progress.sent}
+		monitor.progressLock.Unlock()
+		progress.sent++
 	}
 	return nil
 }
 
+// onDelivery advances the progress of the file the delivered metric belongs
+// to. The progress only covers the metrics from the start of the file without
+// gaps so metrics not yet delivered are passed on again after a restart.
+// Rejected metrics are handled by the outputs and count as delivered.
+func (monitor *DirectoryMonitor) onDelivery(track telegraf.DeliveryInfo) {
+	monitor.progressLock.Lock()
+	defer monitor.progressLock.Unlock()
+
+	u, found := monitor.undelivered[track.ID()]
+	if !found {
+		return
+	}
+	delete(monitor.undelivered, track.ID())
+
+	progress := u.progress
+	progress.done[u.index] = true
+	if !progress.done[progress.delivered] {
+		return
+	}
+	for progress.done[progress.delivered] {
+		delete(progress.done, progress.delivered)
+		progress.delivered++
+	}
+
+	if !progress.finished {
+		monitor.progress[progress.filename] = progress.delivered
+	}
+}
+
 func (monitor *DirectoryMonitor) moveFile(srcPath, dstBaseDir string) {
 	// Appends any subdirectories in the srcPath to the dstBaseDir and
 	// creates those subdirectories.
@@ -7,12 +7,15 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -680,3 +683,105 @@ func TestParseSubdirectoriesFilesIgnore(t *testing.T) {
 	_, err = os.Stat(filepath.Join(finishedDirectory, testJSONFile))
 	require.NoError(t, err)
 }
+
+func TestResumePartiallyProcessedFile(t *testing.T) {
+	finishedDirectory := t.TempDir()
+	processDirectory := t.TempDir()
+
+	r := DirectoryMonitor{
+		Directory:          processDirectory,
+		FinishedDirectory:  finishedDirectory,
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+	r.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+
+	// Two metrics of the file were delivered before the restart
+	filename := processDirectory + "/test.influx"
+	require.NoError(t, os.WriteFile(filename, []byte("test value=1i\ntest value=2i\ntest value=3i\n"), 0o600))
+	var p telegraf.StatefulPlugin = &r
+	require.NoError(t, p.SetState(map[string]int64{filename: 2}))
+
+	var acc testutil.Accumulator
+	require.NoError(t, r.Start(&acc))
+	require.NoError(t, r.Gather(&acc))
+	acc.Wait(1)
+	r.Stop()
+
+	require.NoError(t, acc.FirstError())
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(3)}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	// The file is finished and its progress forgotten
+	require.FileExists(t, filepath.Join(finishedDirectory, "test.influx"))
+	require.Empty(t, p.GetState())
+}
+
+func TestProgressAdvancesOnDelivery(t *testing.T) {
+	r := DirectoryMonitor{
+		Directory:          t.TempDir(),
+		FinishedDirectory:  t.TempDir(),
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, r.Start(&acc))
+	defer r.Stop()
+
+	metrics := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	progress := &fileProgress{filename: "test.influx", done: make(map[int64]bool)}
+	require.NoError(t, r.sendMetrics(metrics, progress))
+
+	// Passing on the metrics does not advance the progress
+	var p telegraf.StatefulPlugin = &r
+	require.Empty(t, p.GetState())
+
+	// Deliveries out of order only count once the gap is closed
+	tracked := acc.GetTelegrafMetrics()
+	require.Len(t, tracked, 3)
+	tracked[1].Accept()
+	require.Eventually(t, func() bool {
+		return len(r.undeliveredIDs()) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Empty(t, p.GetState())
+
+	tracked[0].Accept()
+	require.Eventually(t, func() bool {
+		return len(r.undeliveredIDs()) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]int64{"test.influx": 2}, p.GetState())
+
+	tracked[2].Reject()
+	require.Eventually(t, func() bool {
+		return len(r.undeliveredIDs()) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]int64{"test.influx": 3}, p.GetState())
+}
+
+func (monitor *DirectoryMonitor) undeliveredIDs() []telegraf.TrackingID {
+	monitor.progressLock.Lock()
+	defer monitor.progressLock.Unlock()
+
+	ids := make([]telegraf.TrackingID, 0, len(monitor.undelivered))
+	for id := range monitor.undelivered {
+		ids = append(ids, id)
+	}
+	return ids
+}
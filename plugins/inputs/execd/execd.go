@@ -4,6 +4,7 @@ package execd
 import (
 	"bufio"
 	_ "embed"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -26,6 +27,9 @@ var sampleConfig string
 
 var once sync.Once
 
+// Environment variable passing the last state reported by the program on start
+const stateEnvVar = "TELEGRAF_EXECD_STATE"
+
 type Execd struct {
 	Command      []string        `toml:"command"`
 	Environment  []string        `toml:"environment"`
@@ -39,6 +43,9 @@ type Execd struct {
 	acc          telegraf.Accumulator
 	parser       telegraf.Parser
 	outputReader func(io.Reader)
+
+	state     []byte
+	stateLock sync.Mutex
 }
 
 func (*Execd) SampleConfig() string {
@@ -64,6 +71,28 @@ func (e *Execd) SetParser(parser telegraf.Parser) {
 	}
 }
 
+// GetState returns the opaque state last reported by the program
+func (e *Execd) GetState() interface{} {
+	e.stateLock.Lock()
+	defer e.stateLock.Unlock()
+
+	return e.state
+}
+
+// SetState sets the state passed to the program on start
+func (e *Execd) SetState(state interface{}) error {
+	s, ok := state.([]byte)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	e.stateLock.Lock()
+	e.state = s
+	e.stateLock.Unlock()
+
+	return nil
+}
+
 func (e *Execd) Start(acc telegraf.Accumulator) error {
 	e.acc = acc
 	var err error
@@ -76,6 +105,7 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 	e.process.RestartDelay = time.Duration(e.RestartDelay)
 	e.process.StopOnError = e.StopOnError
 	e.process.Log = e.Log
+	e.process.EnvironmentFn = e.stateEnvironment
 
 	if err = e.process.Start(); err != nil {
 		// if there was only one argument, and it contained spaces, warn the user
@@ -165,6 +195,8 @@ func (e *Execd) cmdReadErr(out io.Reader) {
 			e.Log.Debug(msg[3:])
 		case strings.HasPrefix(msg, "T! "):
 			e.Log.Trace(msg[3:])
+		case strings.HasPrefix(msg, "S! "):
+			e.updateState(msg[3:])
 		default:
 			e.Log.Errorf("stderr: %q", msg)
 		}
@@ -175,6 +207,30 @@ func (e *Execd) cmdReadErr(out io.Reader) {
 	}
 }
 
+// updateState stores the base64 encoded state reported by the program
+func (e *Execd) updateState(encoded string) {
+	state, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		e.Log.Errorf("Decoding state reported by the program failed: %v", err)
+		return
+	}
+
+	e.stateLock.Lock()
+	e.state = state
+	e.stateLock.Unlock()
+}
+
+// stateEnvironment returns the environment passing the state to the program
+func (e *Execd) stateEnvironment() []string {
+	e.stateLock.Lock()
+	defer e.stateLock.Unlock()
+
+	if len(e.state) == 0 {
+		return nil
+	}
+	return []string{stateEnvVar + "=" + base64.StdEncoding.EncodeToString(e.state)}
+}
+
 func init() {
 	inputs.Add("execd", func() telegraf.Input {
 		return &Execd{
@@ -2,9 +2,11 @@ package execd
 
 import (
 	"bufio"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -348,6 +350,43 @@ func TestLoggingWithPrefix(t *testing.T) {
 	}
 }
 
+func TestState(t *testing.T) {
+	// Use own test as mocking executable
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	plugin := &Execd{
+		Command:      []string{exe, "-mode", "state"},
+		Environment:  []string{"PLUGINS_INPUTS_EXECD_MODE=application"},
+		Signal:       "STDIN",
+		RestartDelay: config.Duration(100 * time.Millisecond),
+		Log:          testutil.Logger{},
+	}
+	parser := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{})
+	require.NoError(t, parser.Init())
+	plugin.SetParser(parser)
+	require.NoError(t, plugin.Init())
+
+	// Restore the state which is passed to the program on start
+	var p telegraf.StatefulPlugin = plugin
+	require.NoError(t, p.SetState([]byte("41")))
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, plugin.Gather(&acc))
+
+	// The program reports its new state via stderr
+	require.Eventually(t, func() bool {
+		return string(p.GetState().([]byte)) == "42"
+	}, 3*time.Second, 100*time.Millisecond)
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(42)}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
 func readChanWithTimeout(t *testing.T, metrics chan telegraf.Metric, timeout time.Duration) telegraf.Metric {
 	to := time.NewTimer(timeout)
 	defer to.Stop()
@@ -407,6 +446,11 @@ func TestMain(m *testing.M) {
 			os.Exit(1)
 		}
 		os.Exit(0)
+	case "state":
+		if err := runStateProgram(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 	os.Exit(23)
 }
@@ -457,3 +501,27 @@ func runLoggingProgram() error {
 	}
 	return nil
 }
+
+func runStateProgram() error {
+	state, err := base64.StdEncoding.DecodeString(os.Getenv("TELEGRAF_EXECD_STATE"))
+	if err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(string(state))
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		i++
+		if _, err := fmt.Fprintf(os.Stdout, "test value=%di\n", i); err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(i)))
+		if _, err := fmt.Fprintf(os.Stderr, "S! %s\n", encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/dimchansky/utfbom"
 
@@ -24,15 +25,25 @@ var sampleConfig string
 var once sync.Once
 
 type File struct {
-	Files             []string        `toml:"files"`
-	FileTag           string          `toml:"file_tag"`
-	FilePathTag       string          `toml:"file_path_tag"`
-	CharacterEncoding string          `toml:"character_encoding"`
-	Log               telegraf.Logger `toml:"-"`
+	Files              []string        `toml:"files"`
+	FileTag            string          `toml:"file_tag"`
+	FilePathTag        string          `toml:"file_path_tag"`
+	CharacterEncoding  string          `toml:"character_encoding"`
+	SkipProcessedFiles bool            `toml:"skip_processed_files"`
+	Log                telegraf.Logger `toml:"-"`
 
 	parserFunc telegraf.ParserFunc
 	filenames  []string
 	decoder    *encoding.Decoder
+
+	processed     map[string]processedFile
+	processedLock sync.Mutex
+}
+
+// processedFile identifies the version of a file already processed
+type processedFile struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
 }
 
 func (*File) SampleConfig() string {
@@ -40,6 +51,8 @@ func (*File) SampleConfig() string {
 }
 
 func (f *File) Init() error {
+	f.processed = make(map[string]processedFile)
+
 	var err error
 	f.decoder, err = encoding.NewDecoder(f.CharacterEncoding)
 	return err
@@ -49,12 +62,54 @@ func (f *File) SetParserFunc(fn telegraf.ParserFunc) {
 	f.parserFunc = fn
 }
 
+// GetState returns the files already processed
+func (f *File) GetState() interface{} {
+	f.processedLock.Lock()
+	defer f.processedLock.Unlock()
+
+	state := make(map[string]processedFile, len(f.processed))
+	for k, v := range f.processed {
+		state[k] = v
+	}
+	return state
+}
+
+func (f *File) SetState(state interface{}) error {
+	processed, ok := state.(map[string]processedFile)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	f.processedLock.Lock()
+	defer f.processedLock.Unlock()
+
+	for k, v := range processed {
+		f.processed[k] = v
+	}
+	return nil
+}
+
 func (f *File) Gather(acc telegraf.Accumulator) error {
 	err := f.refreshFilePaths()
 	if err != nil {
 		return err
 	}
+	if f.SkipProcessedFiles {
+		f.forgetRemovedFiles()
+	}
 	for _, k := range f.filenames {
+		var current processedFile
+		if f.SkipProcessedFiles {
+			info, err := os.Stat(k)
+			if err != nil {
+				return err
+			}
+			current = processedFile{ModTime: info.ModTime(), Size: info.Size()}
+			if f.isProcessed(k, current) {
+				continue
+			}
+		}
+
 		metrics, err := f.readMetric(k)
 		if err != nil {
 			return err
@@ -71,10 +126,42 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 			}
 			acc.AddMetric(m)
 		}
+
+		if f.SkipProcessedFiles {
+			f.processedLock.Lock()
+			f.processed[k] = current
+			f.processedLock.Unlock()
+		}
 	}
 	return nil
 }
 
+// isProcessed returns true if the given version of the file was processed
+func (f *File) isProcessed(filename string, current processedFile) bool {
+	f.processedLock.Lock()
+	defer f.processedLock.Unlock()
+
+	previous, found := f.processed[filename]
+	return found && previous.Size == current.Size && previous.ModTime.Equal(current.ModTime)
+}
+
+// forgetRemovedFiles removes files no longer matching from the processed files
+func (f *File) forgetRemovedFiles() {
+	current := make(map[string]bool, len(f.filenames))
+	for _, k := range f.filenames {
+		current[k] = true
+	}
+
+	f.processedLock.Lock()
+	defer f.processedLock.Unlock()
+
+	for k := range f.processed {
+		if !current[k] {
+			delete(f.processed, k)
+		}
+	}
+}
+
 func (f *File) refreshFilePaths() error {
 	var allFiles []string
 	for _, file := range f.Files {
@@ -18,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -464,3 +465,50 @@ func TestCSVBehavior(t *testing.T) {
 	actual := acc.GetTelegrafMetrics()
 	testutil.RequireMetricsEqual(t, expected, actual, options...)
 }
+
+func TestSkipProcessedFiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.out")
+	require.NoError(t, os.WriteFile(filename, []byte("test value=1i\n"), 0o600))
+
+	newParser := func() (telegraf.Parser, error) {
+		p := &influx.Parser{}
+		err := p.Init()
+		return p, err
+	}
+
+	plugin := &File{
+		Files:              []string{filename},
+		SkipProcessedFiles: true,
+		Log:                testutil.Logger{},
+	}
+	plugin.SetParserFunc(newParser)
+	require.NoError(t, plugin.Init())
+
+	// Unchanged files are only processed once
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	// The processed files are restored after a restart
+	var p telegraf.StatefulPlugin = plugin
+	state := p.GetState()
+
+	restarted := &File{
+		Files:              []string{filename},
+		SkipProcessedFiles: true,
+		Log:                testutil.Logger{},
+	}
+	restarted.SetParserFunc(newParser)
+	require.NoError(t, restarted.Init())
+	require.NoError(t, restarted.SetState(state))
+
+	acc.ClearMetrics()
+	require.NoError(t, restarted.Gather(&acc))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// Changed files are processed again
+	require.NoError(t, os.WriteFile(filename, []byte("test value=1i\ntest value=2i\n"), 0o600))
+	require.NoError(t, restarted.Gather(&acc))
+	require.Len(t, acc.GetTelegrafMetrics(), 2)
+}
@@ -67,6 +67,8 @@ type KafkaConsumer struct {
 	topicLock sync.Mutex
 	wg        sync.WaitGroup
 	cancel    context.CancelFunc
+
+	offsets offsetTracker
 }
 
 // consumerGroupHandler is a sarama.ConsumerGroupHandler implementation.
@@ -85,10 +87,19 @@ type consumerGroupHandler struct {
 
 	mu          sync.Mutex
 	undelivered map[telegraf.TrackingID]message
+	offsets     *offsetTracker
 
 	log telegraf.Logger
 }
 
+// offsetTracker keeps the offsets of the processed messages per topic and
+// partition. The offsets are persisted to resume consumption after a restart
+// even if they were not yet committed to the broker.
+type offsetTracker struct {
+	offsets map[string]map[int32]int64
+	sync.Mutex
+}
+
 // message is an aggregate type binding the Kafka message and the session so that offsets can be updated.
 type message struct {
 	message *sarama.ConsumerMessage
@@ -122,6 +133,7 @@ func (*KafkaConsumer) SampleConfig() string {
 
 func (k *KafkaConsumer) Init() error {
 	kafka.SetLogger(k.Log.Level())
+	k.offsets.offsets = make(map[string]map[int32]int64)
 
 	if k.MaxUndeliveredMessages == 0 {
 		k.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
@@ -220,6 +232,37 @@ func (k *KafkaConsumer) SetParser(parser telegraf.Parser) {
 	k.parser = parser
 }
 
+// GetState returns the offsets of the processed messages per topic and partition
+func (k *KafkaConsumer) GetState() interface{} {
+	k.offsets.Lock()
+	defer k.offsets.Unlock()
+
+	state := make(map[string]map[int32]int64, len(k.offsets.offsets))
+	for topic, partitions := range k.offsets.offsets {
+		state[topic] = make(map[int32]int64, len(partitions))
+		for partition, offset := range partitions {
+			state[topic][partition] = offset
+		}
+	}
+	return state
+}
+
+// SetState restores the offsets to skip messages already processed but not
+// committed to the broker
+func (k *KafkaConsumer) SetState(state interface{}) error {
+	offsets, ok := state.(map[string]map[int32]int64)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			k.offsets.mark(topic, partition, offset)
+		}
+	}
+	return nil
+}
+
 func (k *KafkaConsumer) Start(acc telegraf.Accumulator) error {
 	var err error
 
@@ -262,6 +305,7 @@ func (k *KafkaConsumer) Start(acc telegraf.Accumulator) error {
 
 		for ctx.Err() == nil {
 			handler := newConsumerGroupHandler(acc, k.MaxUndeliveredMessages, k.parser, k.Log)
+			handler.offsets = &k.offsets
 			handler.maxMessageLen = k.MaxMessageLen
 			handler.topicTag = k.TopicTag
 			handler.msgHeaderToMetricName = k.MsgHeaderAsMetricName
@@ -426,9 +470,14 @@ func newConsumerGroupHandler(acc telegraf.Accumulator, maxUndelivered int, parse
 }
 
 // Setup is called once when a new session is opened. It setups up the handler and begins processing delivered messages.
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	h.undelivered = make(map[telegraf.TrackingID]message)
 
+	// Skip messages processed before but not committed to the broker
+	if h.offsets != nil {
+		h.offsets.restore(session)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	h.cancel = cancel
 
@@ -495,13 +544,22 @@ func (h *consumerGroupHandler) onDelivery(track telegraf.DeliveryInfo) {
 	}
 
 	if track.Delivered() {
-		msg.session.MarkMessage(msg.message, "")
+		h.markMessage(msg.session, msg.message)
 	}
 
 	delete(h.undelivered, track.ID())
 	<-h.sem
 }
 
+// markMessage marks the message as processed in the session and the offsets
+// persisted by the plugin.
+func (h *consumerGroupHandler) markMessage(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	session.MarkMessage(msg, "")
+	if h.offsets != nil {
+		h.offsets.mark(msg.Topic, msg.Partition, msg.Offset+1)
+	}
+}
+
 // reserve blocks until there is an available slot for a new message.
 func (h *consumerGroupHandler) reserve(ctx context.Context) error {
 	select {
@@ -519,7 +577,7 @@ func (h *consumerGroupHandler) release() {
 // handle processes a message and if successful saves it to be acknowledged after delivery.
 func (h *consumerGroupHandler) handle(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) error {
 	if h.maxMessageLen != 0 && len(msg.Value) > h.maxMessageLen {
-		session.MarkMessage(msg, "")
+		h.markMessage(session, msg)
 		h.release()
 		return fmt.Errorf("message exceeds max_message_len (actual %d, max %d)",
 			len(msg.Value), h.maxMessageLen)
@@ -527,7 +585,7 @@ func (h *consumerGroupHandler) handle(session sarama.ConsumerGroupSession, msg *
 
 	metrics, err := h.parser.Parse(msg.Value)
 	if err != nil {
-		session.MarkMessage(msg, "")
+		h.markMessage(session, msg)
 		h.release()
 		return err
 	}
@@ -584,6 +642,34 @@ func (h *consumerGroupHandler) handle(session sarama.ConsumerGroupSession, msg *
 	return nil
 }
 
+// mark records the offset of the next message to consume
+func (t *offsetTracker) mark(topic string, partition int32, offset int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, found := t.offsets[topic]; !found {
+		t.offsets[topic] = make(map[int32]int64)
+	}
+	if offset > t.offsets[topic][partition] {
+		t.offsets[topic][partition] = offset
+	}
+}
+
+// restore marks the recorded offsets of the partitions claimed by the session,
+// offsets behind the committed ones are ignored by the session
+func (t *offsetTracker) restore(session sarama.ConsumerGroupSession) {
+	t.Lock()
+	defer t.Unlock()
+
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			if offset, found := t.offsets[topic][partition]; found {
+				session.MarkOffset(topic, partition, offset, "")
+			}
+		}
+	}
+}
+
 func init() {
 	inputs.Add("kafka_consumer", func() telegraf.Input {
 		return &KafkaConsumer{}
@@ -37,9 +37,9 @@ type fakeConsumerGroup struct {
 	errors  chan error
 }
 
-func (g *fakeConsumerGroup) Consume(_ context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
+func (g *fakeConsumerGroup) Consume(ctx context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
 	g.handler = handler
-	return g.handler.Setup(nil)
+	return g.handler.Setup(&FakeConsumerGroupSession{ctx: ctx})
 }
 
 func (g *fakeConsumerGroup) Errors() <-chan error {
@@ -237,11 +237,13 @@ func TestStartStop(t *testing.T) {
 }
 
 type FakeConsumerGroupSession struct {
-	ctx context.Context
+	ctx    context.Context
+	claims map[string][]int32
+	marked map[string]map[int32]int64
 }
 
-func (*FakeConsumerGroupSession) Claims() map[string][]int32 {
-	panic("not implemented")
+func (s *FakeConsumerGroupSession) Claims() map[string][]int32 {
+	return s.claims
 }
 
 func (*FakeConsumerGroupSession) MemberID() string {
@@ -252,8 +254,14 @@ func (*FakeConsumerGroupSession) GenerationID() int32 {
 	panic("not implemented")
 }
 
-func (*FakeConsumerGroupSession) MarkOffset(string, int32, int64, string) {
-	panic("not implemented")
+func (s *FakeConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	if s.marked == nil {
+		s.marked = make(map[string]map[int32]int64)
+	}
+	if _, found := s.marked[topic]; !found {
+		s.marked[topic] = make(map[int32]int64)
+	}
+	s.marked[topic][partition] = offset
 }
 
 func (*FakeConsumerGroupSession) ResetOffset(string, int32, int64, string) {
@@ -477,6 +485,40 @@ func TestConsumerGroupHandlerHandle(t *testing.T) {
 	}
 }
 
+func TestConsumerGroupHandlerState(t *testing.T) {
+	plugin := &KafkaConsumer{Log: testutil.Logger{}}
+	require.NoError(t, plugin.Init())
+
+	var p telegraf.StatefulPlugin = plugin
+	require.NoError(t, p.SetState(map[string]map[int32]int64{"telegraf": {0: 42, 1: 7}}))
+
+	parser := value.Parser{
+		MetricName: "cpu",
+		DataType:   "int",
+	}
+	require.NoError(t, parser.Init())
+	cg := newConsumerGroupHandler(&testutil.Accumulator{}, 1, &parser, testutil.Logger{})
+	cg.offsets = &plugin.offsets
+	cg.maxMessageLen = 4
+
+	// The offsets of the claimed partitions are restored
+	session := &FakeConsumerGroupSession{
+		ctx:    t.Context(),
+		claims: map[string][]int32{"telegraf": {0}},
+	}
+	require.NoError(t, cg.Setup(session))
+	require.Equal(t, map[string]map[int32]int64{"telegraf": {0: 42}}, session.marked)
+
+	// Processed messages update the state
+	require.NoError(t, cg.reserve(t.Context()))
+	msg := &sarama.ConsumerMessage{Topic: "telegraf", Partition: 1, Offset: 9, Value: []byte("12345")}
+	require.Error(t, cg.handle(session, msg))
+	require.NoError(t, cg.Cleanup(session))
+
+	expected := map[string]map[int32]int64{"telegraf": {0: 42, 1: 10}}
+	require.Equal(t, expected, p.GetState())
+}
+
 func TestExponentialBackoff(t *testing.T) {
 	var err error
 
@@ -46,6 +46,7 @@ type KinesisConsumer struct {
 	iteratorStore *store
 
 	records    map[telegraf.TrackingID]iterator
+	positions  map[string]string
 	recordsTex sync.Mutex
 
 	wg sync.WaitGroup
@@ -99,6 +100,7 @@ func (k *KinesisConsumer) Init() error {
 	}
 
 	k.records = make(map[telegraf.TrackingID]iterator, k.MaxUndeliveredMessages)
+	k.positions = make(map[string]string)
 	k.sem = make(chan struct{}, k.MaxUndeliveredMessages)
 
 	// Setup the client to connect to the Kinesis service
@@ -119,6 +121,35 @@ func (k *KinesisConsumer) Init() error {
 	return nil
 }
 
+// GetState returns the sequence number of the last delivered record per shard
+func (k *KinesisConsumer) GetState() interface{} {
+	k.recordsTex.Lock()
+	defer k.recordsTex.Unlock()
+
+	state := make(map[string]string, len(k.positions))
+	for shard, seqnr := range k.positions {
+		state[shard] = seqnr
+	}
+	return state
+}
+
+// SetState restores the shard positions to resume consumption if no DynamoDB
+// checkpoint store is configured
+func (k *KinesisConsumer) SetState(state interface{}) error {
+	positions, ok := state.(map[string]string)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	k.recordsTex.Lock()
+	defer k.recordsTex.Unlock()
+
+	for shard, seqnr := range positions {
+		k.positions[shard] = seqnr
+	}
+	return nil
+}
+
 func (k *KinesisConsumer) Start(acc telegraf.Accumulator) error {
 	k.acc = acc.WithTracking(k.MaxUndeliveredMessages)
 
@@ -168,6 +199,13 @@ func (k *KinesisConsumer) Start(acc telegraf.Accumulator) error {
 
 			return seqnr
 		}
+	} else {
+		k.consumer.position = func(shard string) string {
+			k.recordsTex.Lock()
+			defer k.recordsTex.Unlock()
+
+			return k.positions[shard]
+		}
 	}
 	if err := k.consumer.init(); err != nil {
 		return fmt.Errorf("initializing consumer failed: %w", err)
@@ -239,10 +277,7 @@ func (k *KinesisConsumer) onDelivery(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case info := <-k.acc.Delivered():
-			// Store the metric iterator in DynamoDB if configured
-			if k.iteratorStore != nil {
-				k.storeDelivered(info.ID())
-			}
+			k.storeDelivered(info.ID())
 
 			// Reduce the number of undelivered messages by reading from the channel
 			<-k.sem
@@ -264,8 +299,13 @@ func (k *KinesisConsumer) storeDelivered(id telegraf.TrackingID) {
 	// Remove metric
 	delete(k.records, id)
 
-	// Store the iterator in the database
-	k.iteratorStore.set(k.StreamName, iter.shard, iter.seqnr)
+	// Store the iterator in the database if configured or keep it for
+	// persisting the state otherwise
+	if k.iteratorStore != nil {
+		k.iteratorStore.set(k.StreamName, iter.shard, iter.seqnr)
+		return
+	}
+	k.positions[iter.shard] = iter.seqnr
 }
 
 func init() {
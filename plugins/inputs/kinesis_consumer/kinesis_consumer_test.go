@@ -162,3 +162,33 @@ func TestOnMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestState(t *testing.T) {
+	parser := &json.Parser{MetricName: "json_test"}
+	require.NoError(t, parser.Init())
+
+	plugin := &KinesisConsumer{
+		StreamName: "foo",
+		Log:        &testutil.Logger{},
+		parser:     parser,
+	}
+	require.NoError(t, plugin.Init())
+
+	var p telegraf.StatefulPlugin = plugin
+	require.NoError(t, p.SetState(map[string]string{"shard-0": "1", "shard-1": "7"}))
+
+	// Deliver a record and check the position of the shard is updated
+	var acc testutil.Accumulator
+	record := &types.Record{
+		Data:           []byte(`{"value": 42}`),
+		SequenceNumber: aws.String("2"),
+	}
+	require.NoError(t, plugin.onMessage(acc.WithTracking(1), "shard-0", record))
+	require.Len(t, plugin.records, 1)
+	for id := range plugin.records {
+		plugin.storeDelivered(id)
+	}
+
+	expected := map[string]string{"shard-0": "2", "shard-1": "7"}
+	require.Equal(t, expected, p.GetState())
+}
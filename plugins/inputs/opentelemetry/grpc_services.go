@@ -6,8 +6,11 @@ import (
 
 	"github.com/influxdata/influxdb-observability/common"
 	"github.com/influxdata/influxdb-observability/otel2influx"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 )
 
@@ -34,8 +37,50 @@ func newTraceService(logger common.Logger, writer *writeToAccumulator, spanDimen
 
 // Export processes and exports the trace data received in the request.
 func (s *traceService) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
-	err := s.exporter.WriteTraces(ctx, req.Traces())
-	return ptraceotlp.NewExportResponse(), err
+	return s.export(ctx, req.Traces())
+}
+
+// export converts all spans at once. If this fails, the spans are converted
+// one at a time so spans failing the conversion are reported as rejected in
+// the partial success of the response instead of dropping the remaining spans
+// of the request. An error is returned if none of the spans can be converted.
+func (s *traceService) export(ctx context.Context, td ptrace.Traces) (ptraceotlp.ExportResponse, error) {
+	resp := ptraceotlp.NewExportResponse()
+	if err := s.exporter.WriteTraces(ctx, td); err == nil {
+		return resp, nil
+	}
+
+	total := int64(td.SpanCount())
+	var rejected int64
+	var lastErr error
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		resourceSpans := td.ResourceSpans().At(i)
+		for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+			scopeSpans := resourceSpans.ScopeSpans().At(j)
+			for k := 0; k < scopeSpans.Spans().Len(); k++ {
+				single := ptrace.NewTraces()
+				rs := single.ResourceSpans().AppendEmpty()
+				resourceSpans.Resource().CopyTo(rs.Resource())
+				ss := rs.ScopeSpans().AppendEmpty()
+				scopeSpans.Scope().CopyTo(ss.Scope())
+				scopeSpans.Spans().At(k).MoveTo(ss.Spans().AppendEmpty())
+
+				if err := s.exporter.WriteTraces(ctx, single); err != nil {
+					rejected++
+					lastErr = err
+				}
+			}
+		}
+	}
+
+	if lastErr != nil && rejected == total {
+		return resp, lastErr
+	}
+	if lastErr != nil {
+		resp.PartialSuccess().SetRejectedSpans(rejected)
+		resp.PartialSuccess().SetErrorMessage(lastErr.Error())
+	}
+	return resp, nil
 }
 
 type metricsService struct {
@@ -71,8 +116,51 @@ func newMetricsService(logger common.Logger, writer *writeToAccumulator, schema
 
 // Export processes and exports the metrics data received in the request.
 func (s *metricsService) Export(ctx context.Context, req pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
-	err := s.exporter.WriteMetrics(ctx, req.Metrics())
-	return pmetricotlp.NewExportResponse(), err
+	return s.export(ctx, req.Metrics())
+}
+
+// export converts all metrics at once. If this fails, the metrics are
+// converted one at a time so the data points of metrics failing the conversion
+// are reported as rejected in the partial success of the response instead of
+// dropping the remaining metrics of the request. An error is returned if none
+// of the metrics can be converted.
+func (s *metricsService) export(ctx context.Context, md pmetric.Metrics) (pmetricotlp.ExportResponse, error) {
+	resp := pmetricotlp.NewExportResponse()
+	if err := s.exporter.WriteMetrics(ctx, md); err == nil {
+		return resp, nil
+	}
+
+	total := int64(md.DataPointCount())
+	var rejected int64
+	var lastErr error
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		resourceMetrics := md.ResourceMetrics().At(i)
+		for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+			scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
+			for k := 0; k < scopeMetrics.Metrics().Len(); k++ {
+				single := pmetric.NewMetrics()
+				rm := single.ResourceMetrics().AppendEmpty()
+				resourceMetrics.Resource().CopyTo(rm.Resource())
+				sm := rm.ScopeMetrics().AppendEmpty()
+				scopeMetrics.Scope().CopyTo(sm.Scope())
+				scopeMetrics.Metrics().At(k).MoveTo(sm.Metrics().AppendEmpty())
+
+				if err := s.exporter.WriteMetrics(ctx, single); err != nil {
+					rejected += int64(single.DataPointCount())
+					lastErr = err
+				}
+			}
+		}
+	}
+
+	if lastErr != nil && rejected == total {
+		return resp, lastErr
+	}
+	if lastErr != nil {
+		resp.PartialSuccess().SetRejectedDataPoints(rejected)
+		resp.PartialSuccess().SetErrorMessage(lastErr.Error())
+	}
+	return resp, nil
 }
 
 type logsService struct {
@@ -98,6 +186,49 @@ func newLogsService(logger common.Logger, writer *writeToAccumulator, logRecordD
 
 // Export processes and exports the logs data received in the request.
 func (s *logsService) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
-	err := s.converter.WriteLogs(ctx, req.Logs())
-	return plogotlp.NewExportResponse(), err
+	return s.export(ctx, req.Logs())
+}
+
+// export converts all log records at once. If this fails, the records are
+// converted one at a time so records failing the conversion are reported as
+// rejected in the partial success of the response instead of dropping the
+// remaining records of the request. An error is returned if none of the
+// records can be converted.
+func (s *logsService) export(ctx context.Context, ld plog.Logs) (plogotlp.ExportResponse, error) {
+	resp := plogotlp.NewExportResponse()
+	if err := s.converter.WriteLogs(ctx, ld); err == nil {
+		return resp, nil
+	}
+
+	total := int64(ld.LogRecordCount())
+	var rejected int64
+	var lastErr error
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		resourceLogs := ld.ResourceLogs().At(i)
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			scopeLogs := resourceLogs.ScopeLogs().At(j)
+			for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+				single := plog.NewLogs()
+				rl := single.ResourceLogs().AppendEmpty()
+				resourceLogs.Resource().CopyTo(rl.Resource())
+				sl := rl.ScopeLogs().AppendEmpty()
+				scopeLogs.Scope().CopyTo(sl.Scope())
+				scopeLogs.LogRecords().At(k).MoveTo(sl.LogRecords().AppendEmpty())
+
+				if err := s.converter.WriteLogs(ctx, single); err != nil {
+					rejected++
+					lastErr = err
+				}
+			}
+		}
+	}
+
+	if lastErr != nil && rejected == total {
+		return resp, lastErr
+	}
+	if lastErr != nil {
+		resp.PartialSuccess().SetRejectedLogRecords(rejected)
+		resp.PartialSuccess().SetErrorMessage(lastErr.Error())
+	}
+	return resp, nil
 }
@@ -0,0 +1,164 @@
+package opentelemetry
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	// Same default as the maximum receive message size of gRPC
+	defaultMaxMsgSize = 4 * 1024 * 1024
+)
+
+// otlpRequest is an OTLP export request of any signal
+type otlpRequest interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+// otlpResponse is an OTLP export response of any signal
+type otlpResponse interface {
+	MarshalProto() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+}
+
+// newHTTPHandler returns the handler serving the OTLP/HTTP endpoints of the
+// signals. Profiles are only supported via gRPC.
+func (o *OpenTelemetry) newHTTPHandler(traceSvc *traceService, metricsSvc *metricsService, logsSvc *logsService) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		req := ptraceotlp.NewExportRequest()
+		o.serveExport(w, r, &req, func(ctx context.Context) (otlpResponse, error) {
+			return traceSvc.export(ctx, req.Traces())
+		})
+	})
+	mux.HandleFunc("/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		req := pmetricotlp.NewExportRequest()
+		o.serveExport(w, r, &req, func(ctx context.Context) (otlpResponse, error) {
+			return metricsSvc.export(ctx, req.Metrics())
+		})
+	})
+	mux.HandleFunc("/v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		req := plogotlp.NewExportRequest()
+		o.serveExport(w, r, &req, func(ctx context.Context) (otlpResponse, error) {
+			return logsSvc.export(ctx, req.Logs())
+		})
+	})
+	return mux
+}
+
+// serveExport decodes the request in the protobuf or JSON encoding, exports
+// the data and responds in the encoding of the request.
+func (o *OpenTelemetry) serveExport(w http.ResponseWriter, r *http.Request, req otlpRequest, export func(context.Context) (otlpResponse, error)) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != contentTypeProtobuf && contentType != contentTypeJSON) {
+		http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body := r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+	case "gzip":
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			o.writeStatus(w, contentType, http.StatusBadRequest, codes.InvalidArgument, fmt.Errorf("decompressing body failed: %w", err))
+			return
+		}
+		defer reader.Close()
+		body = reader
+	default:
+		http.Error(w, fmt.Sprintf("unsupported content encoding %q", encoding), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Limit the size of the decompressed body
+	buf, err := io.ReadAll(http.MaxBytesReader(w, body, int64(o.MaxMsgSize)))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			o.writeStatus(w, contentType, http.StatusRequestEntityTooLarge, codes.ResourceExhausted, err)
+		} else {
+			o.writeStatus(w, contentType, http.StatusBadRequest, codes.InvalidArgument, fmt.Errorf("reading body failed: %w", err))
+		}
+		return
+	}
+
+	if contentType == contentTypeJSON {
+		err = req.UnmarshalJSON(buf)
+	} else {
+		err = req.UnmarshalProto(buf)
+	}
+	if err != nil {
+		o.writeStatus(w, contentType, http.StatusBadRequest, codes.InvalidArgument, fmt.Errorf("decoding request failed: %w", err))
+		return
+	}
+
+	resp, err := export(r.Context())
+	if err != nil {
+		o.writeStatus(w, contentType, http.StatusBadRequest, codes.InvalidArgument, err)
+		return
+	}
+	if contentType == contentTypeJSON {
+		buf, err = resp.MarshalJSON()
+	} else {
+		buf, err = resp.MarshalProto()
+	}
+	if err != nil {
+		o.writeStatus(w, contentType, http.StatusInternalServerError, codes.Internal, fmt.Errorf("encoding response failed: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf); err != nil {
+		o.Log.Debugf("Writing response failed: %v", err)
+	}
+}
+
+// writeStatus responds with the error as google.rpc.Status message in the
+// encoding of the request as required by the OTLP/HTTP specification.
+func (o *OpenTelemetry) writeStatus(w http.ResponseWriter, contentType string, code int, rpcCode codes.Code, err error) {
+	o.Log.Debugf("Rejecting request: %v", err)
+
+	msg := status.New(rpcCode, err.Error()).Proto()
+	var buf []byte
+	var merr error
+	if contentType == contentTypeJSON {
+		buf, merr = protojson.Marshal(msg)
+	} else {
+		buf, merr = proto.Marshal(msg)
+	}
+	if merr != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	if _, err := w.Write(buf); err != nil {
+		o.Log.Debugf("Writing response failed: %v", err)
+	}
+}
@@ -2,9 +2,12 @@
 package opentelemetry
 
 import (
+	"crypto/tls"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -18,7 +21,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
-	"github.com/influxdata/telegraf/plugins/common/tls"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -27,6 +30,7 @@ var sampleConfig string
 
 type OpenTelemetry struct {
 	ServiceAddress      string          `toml:"service_address"`
+	Protocol            string          `toml:"protocol"`
 	SpanDimensions      []string        `toml:"span_dimensions"`
 	LogRecordDimensions []string        `toml:"log_record_dimensions"`
 	ProfileDimensions   []string        `toml:"profile_dimensions"`
@@ -34,10 +38,11 @@ type OpenTelemetry struct {
 	MaxMsgSize          config.Size     `toml:"max_msg_size"`
 	Timeout             config.Duration `toml:"timeout"`
 	Log                 telegraf.Logger `toml:"-"`
-	tls.ServerConfig
+	common_tls.ServerConfig
 
 	listener   net.Listener // overridden in tests
 	grpcServer *grpc.Server
+	httpServer *http.Server
 
 	wg sync.WaitGroup
 }
@@ -47,8 +52,21 @@ func (*OpenTelemetry) SampleConfig() string {
 }
 
 func (o *OpenTelemetry) Init() error {
-	if o.ServiceAddress == "" {
-		o.ServiceAddress = "0.0.0.0:4317"
+	switch o.Protocol {
+	case "", "grpc":
+		o.Protocol = "grpc"
+		if o.ServiceAddress == "" {
+			o.ServiceAddress = "0.0.0.0:4317"
+		}
+	case "http":
+		if o.ServiceAddress == "" {
+			o.ServiceAddress = "0.0.0.0:4318"
+		}
+		if o.MaxMsgSize == 0 {
+			o.MaxMsgSize = config.Size(defaultMaxMsgSize)
+		}
+	default:
+		return fmt.Errorf("invalid protocol %q", o.Protocol)
 	}
 	switch o.MetricsSchema {
 	case "": // Set default
@@ -62,39 +80,45 @@ func (o *OpenTelemetry) Init() error {
 }
 
 func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
-	var grpcOptions []grpc.ServerOption
-	if tlsConfig, err := o.ServerConfig.TLSConfig(); err != nil {
+	tlsConfig, err := o.ServerConfig.TLSConfig()
+	if err != nil {
 		return err
-	} else if tlsConfig != nil {
-		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
-	}
-	if o.Timeout > 0 {
-		grpcOptions = append(grpcOptions, grpc.ConnectionTimeout(time.Duration(o.Timeout)))
-	}
-	if o.MaxMsgSize > 0 {
-		grpcOptions = append(grpcOptions, grpc.MaxRecvMsgSize(int(o.MaxMsgSize)))
 	}
 
 	logger := &otelLogger{o.Log}
 	influxWriter := &writeToAccumulator{acc}
-	o.grpcServer = grpc.NewServer(grpcOptions...)
 
 	traceSvc, err := newTraceService(logger, influxWriter, o.SpanDimensions)
 	if err != nil {
 		return err
 	}
-	ptraceotlp.RegisterGRPCServer(o.grpcServer, traceSvc)
-
 	metricsSvc, err := newMetricsService(logger, influxWriter, o.MetricsSchema)
 	if err != nil {
 		return err
 	}
-	pmetricotlp.RegisterGRPCServer(o.grpcServer, metricsSvc)
-
 	logsSvc, err := newLogsService(logger, influxWriter, o.LogRecordDimensions)
 	if err != nil {
 		return err
 	}
+
+	if o.Protocol == "http" {
+		return o.startHTTP(acc, tlsConfig, traceSvc, metricsSvc, logsSvc)
+	}
+
+	var grpcOptions []grpc.ServerOption
+	if tlsConfig != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if o.Timeout > 0 {
+		grpcOptions = append(grpcOptions, grpc.ConnectionTimeout(time.Duration(o.Timeout)))
+	}
+	if o.MaxMsgSize > 0 {
+		grpcOptions = append(grpcOptions, grpc.MaxRecvMsgSize(int(o.MaxMsgSize)))
+	}
+	o.grpcServer = grpc.NewServer(grpcOptions...)
+
+	ptraceotlp.RegisterGRPCServer(o.grpcServer, traceSvc)
+	pmetricotlp.RegisterGRPCServer(o.grpcServer, metricsSvc)
 	plogotlp.RegisterGRPCServer(o.grpcServer, logsSvc)
 
 	profileSvc, err := newProfileService(acc, o.Log, o.ProfileDimensions)
@@ -119,6 +143,32 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (o *OpenTelemetry) startHTTP(acc telegraf.Accumulator, tlsConfig *tls.Config, traceSvc *traceService, metricsSvc *metricsService, logsSvc *logsService) error {
+	listener, err := net.Listen("tcp", o.ServiceAddress)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	o.listener = listener
+
+	o.httpServer = &http.Server{
+		Handler:           o.newHTTPHandler(traceSvc, metricsSvc, logsSvc),
+		ReadHeaderTimeout: time.Duration(o.Timeout),
+	}
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.httpServer.Serve(o.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			acc.AddError(fmt.Errorf("failed to stop OpenTelemetry HTTP service: %w", err))
+		}
+	}()
+
+	return nil
+}
+
 func (*OpenTelemetry) Gather(telegraf.Accumulator) error {
 	return nil
 }
@@ -127,6 +177,9 @@ func (o *OpenTelemetry) Stop() {
 	if o.grpcServer != nil {
 		o.grpcServer.Stop()
 	}
+	if o.httpServer != nil {
+		o.httpServer.Close()
+	}
 	o.listener = nil
 
 	o.wg.Wait()
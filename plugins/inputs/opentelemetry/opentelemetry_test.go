@@ -1,8 +1,12 @@
 package opentelemetry
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -14,6 +18,11 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb-observability/otel2influx"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -21,7 +30,9 @@ import (
 	otlpmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	otlpprofiles "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
 	otlptrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/encoding/protojson"
 
@@ -254,3 +265,272 @@ func TestCases(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenTelemetryHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		gzip        bool
+	}{
+		{
+			name:        "protobuf",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+		},
+		{
+			name:        "protobuf with gzip",
+			contentType: "application/x-protobuf",
+			gzip:        true,
+		},
+		{
+			name:        "json with gzip",
+			contentType: "application/json; charset=utf-8",
+			gzip:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &OpenTelemetry{
+				ServiceAddress: "127.0.0.1:0",
+				Protocol:       "http",
+				Log:            testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+
+			md := pmetric.NewMetrics()
+			rm := md.ResourceMetrics().AppendEmpty()
+			rm.Resource().Attributes().PutStr("service.name", "test")
+			m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			m.SetName("temperature")
+			dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+			dp.SetTimestamp(pcommon.Timestamp(1622848686000000000))
+			dp.SetDoubleValue(23.5)
+			req := pmetricotlp.NewExportRequestFromMetrics(md)
+
+			var body []byte
+			var err error
+			if strings.HasPrefix(tt.contentType, "application/json") {
+				body, err = req.MarshalJSON()
+			} else {
+				body, err = req.MarshalProto()
+			}
+			require.NoError(t, err)
+			if tt.gzip {
+				var buf bytes.Buffer
+				w := gzip.NewWriter(&buf)
+				_, err := w.Write(body)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				body = buf.Bytes()
+			}
+
+			httpReq, err := http.NewRequest(http.MethodPost, "http://"+plugin.listener.Addr().String()+"/v1/metrics", bytes.NewReader(body))
+			require.NoError(t, err)
+			httpReq.Header.Set("Content-Type", tt.contentType)
+			if tt.gzip {
+				httpReq.Header.Set("Content-Encoding", "gzip")
+			}
+			resp, err := http.DefaultClient.Do(httpReq)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, strings.Split(tt.contentType, ";")[0], resp.Header.Get("Content-Type"))
+
+			expected := []telegraf.Metric{
+				testutil.MustMetric(
+					"temperature",
+					map[string]string{"service.name": "test"},
+					map[string]interface{}{"gauge": 23.5},
+					time.Unix(0, 1622848686000000000),
+					telegraf.Gauge,
+				),
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestOpenTelemetryHTTPPartialSuccess(t *testing.T) {
+	plugin := &OpenTelemetry{
+		ServiceAddress: "127.0.0.1:0",
+		Protocol:       "http",
+		SpanDimensions: otel2influx.DefaultOtelTracesToLineProtocolConfig().SpanDimensions,
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// The second span is missing the trace ID and cannot be converted
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	valid := spans.AppendEmpty()
+	valid.SetName("valid")
+	valid.SetTraceID(pcommon.TraceID{1, 2, 3})
+	valid.SetSpanID(pcommon.SpanID{1})
+	valid.SetStartTimestamp(pcommon.Timestamp(1622848686000000000))
+	valid.SetEndTimestamp(pcommon.Timestamp(1622848687000000000))
+	invalid := spans.AppendEmpty()
+	invalid.SetName("invalid")
+	invalid.SetSpanID(pcommon.SpanID{2})
+
+	body, err := ptraceotlp.NewExportRequestFromTraces(td).MarshalProto()
+	require.NoError(t, err)
+
+	url := "http://" + plugin.listener.Addr().String() + "/v1/traces"
+	resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	buf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	response := ptraceotlp.NewExportResponse()
+	require.NoError(t, response.UnmarshalProto(buf))
+	require.Equal(t, int64(1), response.PartialSuccess().RejectedSpans())
+	require.Contains(t, response.PartialSuccess().ErrorMessage(), "span has no trace ID")
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	name, found := metrics[0].GetField("span.name")
+	require.True(t, found)
+	require.Equal(t, "valid", name)
+}
+
+func TestOpenTelemetryHTTPRejected(t *testing.T) {
+	plugin := &OpenTelemetry{
+		ServiceAddress: "127.0.0.1:0",
+		Protocol:       "http",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// None of the spans can be converted
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("invalid")
+	body, err := ptraceotlp.NewExportRequestFromTraces(td).MarshalProto()
+	require.NoError(t, err)
+
+	url := "http://" + plugin.listener.Addr().String() + "/v1/traces"
+	resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestTraceServiceExport(t *testing.T) {
+	var acc testutil.Accumulator
+	dimensions := otel2influx.DefaultOtelTracesToLineProtocolConfig().SpanDimensions
+	svc, err := newTraceService(&otelLogger{testutil.Logger{}}, &writeToAccumulator{&acc}, dimensions)
+	require.NoError(t, err)
+
+	newSpan := func(spans ptrace.SpanSlice, name string, valid bool) {
+		span := spans.AppendEmpty()
+		span.SetName(name)
+		span.SetSpanID(pcommon.SpanID{1})
+		if valid {
+			span.SetTraceID(pcommon.TraceID{1})
+		}
+		span.SetStartTimestamp(pcommon.Timestamp(1622848686000000000))
+		span.SetEndTimestamp(pcommon.Timestamp(1622848687000000000))
+	}
+
+	// All spans are converted at once
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	newSpan(spans, "first", true)
+	newSpan(spans, "second", true)
+	resp, err := svc.Export(t.Context(), ptraceotlp.NewExportRequestFromTraces(td))
+	require.NoError(t, err)
+	require.Zero(t, resp.PartialSuccess().RejectedSpans())
+	require.Len(t, acc.GetTelegrafMetrics(), 2)
+
+	// Failing spans are rejected without dropping the valid ones
+	acc.ClearMetrics()
+	td = ptrace.NewTraces()
+	spans = td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	newSpan(spans, "valid", true)
+	newSpan(spans, "invalid", false)
+	resp, err = svc.Export(t.Context(), ptraceotlp.NewExportRequestFromTraces(td))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.PartialSuccess().RejectedSpans())
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	// An error is returned if no span can be converted
+	acc.ClearMetrics()
+	td = ptrace.NewTraces()
+	spans = td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	newSpan(spans, "invalid", false)
+	_, err = svc.Export(t.Context(), ptraceotlp.NewExportRequestFromTraces(td))
+	require.ErrorContains(t, err, "span has no trace ID")
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestOpenTelemetryHTTPInvalid(t *testing.T) {
+	plugin := &OpenTelemetry{
+		ServiceAddress: "127.0.0.1:0",
+		Protocol:       "http",
+		MaxMsgSize:     config.Size(64),
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	base := "http://" + plugin.listener.Addr().String()
+
+	// Wrong method
+	resp, err := http.Get(base + "/v1/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	// Unsupported content type
+	resp, err = http.Post(base+"/v1/metrics", "text/plain", strings.NewReader("cpu value=1"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	// Malformed request with the error as status message
+	resp, err = http.Post(base+"/v1/metrics", "application/json", strings.NewReader("{"))
+	require.NoError(t, err)
+	buf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var msg spb.Status
+	require.NoError(t, protojson.Unmarshal(buf, &msg))
+	require.Equal(t, int32(codes.InvalidArgument), msg.GetCode())
+	require.Contains(t, msg.GetMessage(), "decoding request failed")
+
+	// Request exceeding the maximum size
+	resp, err = http.Post(base+"/v1/logs", "application/x-protobuf", bytes.NewReader(make([]byte, 128)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// Unknown path
+	resp, err = http.Post(base+"/v1/unknown", "application/x-protobuf", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
@@ -190,7 +190,23 @@ func (t *Tail) getSeekInfo(file string) (*tail.SeekInfo, error) {
 }
 
 func (t *Tail) GetState() interface{} {
-	return t.offsets
+	t.tailersMutex.RLock()
+	defer t.tailersMutex.RUnlock()
+
+	// Include the current offsets of the active tailers to allow
+	// checkpointing the state while running
+	offsets := make(map[string]int64, len(t.offsets)+len(t.tailers))
+	for k, v := range t.offsets {
+		offsets[k] = v
+	}
+	if !t.Pipe {
+		for _, tailer := range t.tailers {
+			if offset, err := tailer.Tell(); err == nil {
+				offsets[tailer.Filename] = offset
+			}
+		}
+	}
+	return offsets
 }
 
 func (t *Tail) SetState(state interface{}) error {
@@ -0,0 +1,330 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package aeron_archive
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/archive"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_aeron "github.com/influxdata/telegraf/plugins/common/aeron"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// AeronArchive publishes metrics to an Aeron stream recorded by an Aeron
+// Archive
+type AeronArchive struct {
+	AeronDir                string              `toml:"aeron_dir"`
+	DriverTimeout           config.Duration     `toml:"driver_timeout"`
+	ControlRequestChannel   string              `toml:"control_request_channel"`
+	ControlRequestStreamID  int32               `toml:"control_request_stream_id"`
+	ControlResponseChannel  string              `toml:"control_response_channel"`
+	ControlResponseStreamID int32               `toml:"control_response_stream_id"`
+	ControlTimeout          config.Duration     `toml:"control_timeout"`
+	Channel                 string              `toml:"channel"`
+	StreamID                int32               `toml:"stream_id"`
+	PublicationTimeout      config.Duration     `toml:"publication_timeout"`
+	MaxMessageSize          int                 `toml:"max_message_size"`
+	MaxRetries              int                 `toml:"max_retries"`
+	RetryDelay              config.Duration     `toml:"retry_delay"`
+	MediaDriver             *mediadriver.Config `toml:"media_driver"`
+	Log                     telegraf.Logger     `toml:"-"`
+
+	// Internal state
+	serializer    telegraf.Serializer
+	retry         common_aeron.Retry
+	releaseDriver func()
+	archive       *archive.Archive
+	publication   common_aeron.Publication
+	mutex         sync.Mutex
+
+	// Channel including the session ID of the recorded publication
+	recordingChannel string
+
+	// Statistics (exposed as Telegraf metrics via selfstat)
+	messagesSent     selfstat.Stat
+	messagesDropped  selfstat.Stat
+	bytesTransferred selfstat.Stat
+}
+
+// SampleConfig returns the sample configuration for the plugin
+func (*AeronArchive) SampleConfig() string {
+	return sampleConfig
+}
+
+// Init sets the defaults and validates the configuration
+func (a *AeronArchive) Init() error {
+	if a.DriverTimeout == 0 {
+		a.DriverTimeout = config.Duration(30 * time.Second)
+	}
+	if a.ControlRequestChannel == "" {
+		a.ControlRequestChannel = "aeron:udp?endpoint=localhost:8010"
+	}
+	if a.ControlRequestStreamID == 0 {
+		a.ControlRequestStreamID = 10
+	}
+	if a.ControlResponseChannel == "" {
+		a.ControlResponseChannel = "aeron:udp?endpoint=localhost:0"
+	}
+	if a.ControlResponseStreamID == 0 {
+		a.ControlResponseStreamID = 20
+	}
+	if a.ControlTimeout == 0 {
+		a.ControlTimeout = config.Duration(5 * time.Second)
+	}
+	if a.PublicationTimeout == 0 {
+		a.PublicationTimeout = config.Duration(10 * time.Second)
+	}
+	if a.MaxRetries == 0 {
+		a.MaxRetries = 3
+	}
+	if a.RetryDelay == 0 {
+		a.RetryDelay = config.Duration(1 * time.Millisecond)
+	}
+
+	if a.Channel == "" {
+		return errors.New("channel is required")
+	}
+	if a.StreamID == 0 {
+		return errors.New("stream_id is required and must be non-zero")
+	}
+	if _, err := aeron.ParseChannelUri(a.Channel); err != nil {
+		return fmt.Errorf("parsing channel failed: %w", err)
+	}
+
+	if a.MediaDriver != nil {
+		if err := mediadriver.Register(a.AeronDir, a.MediaDriver, a.Log); err != nil {
+			return fmt.Errorf("registering media driver failed: %w", err)
+		}
+	}
+
+	tags := map[string]string{
+		"channel":   a.Channel,
+		"stream_id": fmt.Sprintf("%d", a.StreamID),
+	}
+	a.messagesSent = selfstat.Register("aeron_archive", "messages_sent", tags)
+	a.messagesDropped = selfstat.Register("aeron_archive", "messages_dropped", tags)
+	a.bytesTransferred = selfstat.Register("aeron_archive", "bytes_transferred", tags)
+
+	a.retry = common_aeron.Retry{
+		MaxRetries: a.MaxRetries,
+		Delay:      time.Duration(a.RetryDelay),
+	}
+
+	return nil
+}
+
+// SetSerializer sets the serializer for the metrics
+func (a *AeronArchive) SetSerializer(serializer telegraf.Serializer) {
+	a.serializer = serializer
+}
+
+// Connect connects to the archive, adds the publication and starts recording
+// the publication
+func (a *AeronArchive) Connect() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// Wait for the media driver if it is launched by Telegraf
+	release, err := mediadriver.Acquire(a.AeronDir, time.Duration(a.DriverTimeout))
+	if err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		release()
+		return err
+	}
+	a.releaseDriver = release
+	return nil
+}
+
+// connect connects to the archive, adds the publication and starts recording
+// the publication
+func (a *AeronArchive) connect() error {
+	ctx := aeron.NewContext()
+	if a.AeronDir != "" {
+		ctx.AeronDir(a.AeronDir)
+	}
+	ctx.MediaDriverTimeout(time.Duration(a.DriverTimeout))
+
+	options := archive.DefaultOptions()
+	options.RequestChannel = a.ControlRequestChannel
+	options.RequestStream = a.ControlRequestStreamID
+	options.ResponseChannel = a.ControlResponseChannel
+	options.ResponseStream = a.ControlResponseStreamID
+	options.Timeout = time.Duration(a.ControlTimeout)
+
+	arch, err := archive.NewArchive(options, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Aeron archive: %w", err)
+	}
+
+	// Add the publication and record its session
+	publication, err := arch.AddRecordedPublication(a.Channel, a.StreamID)
+	if err != nil {
+		arch.Close()
+		return fmt.Errorf("failed to add recorded publication: %w", err)
+	}
+	channel, err := archive.AddSessionIdToChannel(publication.Channel(), publication.SessionID())
+	if err != nil {
+		publication.Close()
+		arch.Close()
+		return fmt.Errorf("determining recording channel failed: %w", err)
+	}
+
+	// Wait for the recording, and possibly other subscribers, to connect
+	waitStart := time.Now()
+	for !publication.IsConnected() {
+		if time.Since(waitStart) > time.Duration(a.PublicationTimeout) {
+			a.stopRecording(arch, channel)
+			publication.Close()
+			arch.Close()
+			return fmt.Errorf("publication not ready within timeout: %v", a.PublicationTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	a.archive = arch
+	a.publication = publication
+	a.recordingChannel = channel
+
+	a.Log.Infof("Recording channel=%s, stream_id=%d, session_id=%d", a.Channel, a.StreamID, publication.SessionID())
+	return nil
+}
+
+// disconnect stops the recording and closes the publication and the
+// connection to the archive. The recording of a closed publication is not
+// stopped as the archive is unreachable or has lost the recording anyway.
+func (a *AeronArchive) disconnect() error {
+	if a.publication != nil {
+		if !a.publication.IsClosed() {
+			a.stopRecording(a.archive, a.recordingChannel)
+		}
+		a.publication.Close()
+		a.publication = nil
+	}
+
+	var err error
+	if a.archive != nil {
+		err = a.archive.Close()
+		a.archive = nil
+	}
+	return err
+}
+
+// Write publishes the metrics to the recorded stream. Metrics that could not
+// be published are kept in the output buffer and retried on the next flush.
+func (a *AeronArchive) Write(metrics []telegraf.Metric) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.publication == nil {
+		// Without a media driver reference the plugin was never connected
+		// or is already closed so there is nothing to reconnect
+		if a.releaseDriver == nil {
+			return errors.New("not connected to Aeron archive")
+		}
+		if err := a.connect(); err != nil {
+			return fmt.Errorf("not connected to Aeron archive: %w", err)
+		}
+	}
+
+	writeErr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	for i, m := range metrics {
+		data, err := a.serializer.Serialize(m)
+		if err != nil {
+			a.Log.Errorf("Failed to serialize metric: %v", err)
+			writeErr.Err = internal.ErrSerialization
+			writeErr.MetricsReject = append(writeErr.MetricsReject, i)
+			writeErr.MetricsRejectErrors = append(writeErr.MetricsRejectErrors, err)
+			continue
+		}
+
+		// Oversized metrics will never succeed so reject them instead of
+		// keeping them in the buffer
+		if a.MaxMessageSize > 0 && len(data) > a.MaxMessageSize {
+			a.messagesDropped.Incr(1)
+			a.Log.Warnf("Dropping metric: serialized size %d exceeds max_message_size %d",
+				len(data), a.MaxMessageSize)
+			writeErr.Err = internal.ErrSizeLimitReached
+			writeErr.MetricsReject = append(writeErr.MetricsReject, i)
+			writeErr.MetricsRejectErrors = append(writeErr.MetricsRejectErrors, internal.ErrSizeLimitReached)
+			continue
+		}
+
+		// Stop publishing on failure and leave all remaining metrics in the
+		// buffer to preserve ordering
+		buffer := atomic.MakeBuffer(data)
+		if err := a.retry.Offer(func() int64 {
+			return a.publication.Offer(buffer, 0, int32(len(data)), nil)
+		}); err != nil {
+			// A closed publication, e.g. after a restart of the media driver
+			// or the archive, is recreated on the next write
+			if errors.Is(err, common_aeron.ErrClosed) {
+				a.Log.Warn("Publication closed, reconnecting on next write")
+				if err := a.disconnect(); err != nil {
+					a.Log.Errorf("Closing Aeron archive failed: %v", err)
+				}
+			}
+			writeErr.Err = fmt.Errorf("failed to publish metric: %w", err)
+			break
+		}
+
+		a.messagesSent.Incr(1)
+		a.bytesTransferred.Incr(int64(len(data)))
+		writeErr.MetricsAccept = append(writeErr.MetricsAccept, i)
+	}
+
+	if writeErr.Err == nil {
+		return nil
+	}
+	return writeErr
+}
+
+// stopRecording stops the recording of the given session-specific channel
+func (a *AeronArchive) stopRecording(arch *archive.Archive, channel string) {
+	if err := arch.StopRecording(channel, a.StreamID); err != nil {
+		a.Log.Errorf("Stopping recording failed: %v", err)
+	}
+}
+
+// Close stops the recording and closes the connection to the archive
+func (a *AeronArchive) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.releaseDriver == nil {
+		return nil
+	}
+
+	err := a.disconnect()
+	a.releaseDriver()
+	a.releaseDriver = nil
+	if err != nil {
+		return fmt.Errorf("closing Aeron archive failed: %w", err)
+	}
+
+	a.Log.Info("Aeron archive output closed")
+	return nil
+}
+
+func init() {
+	outputs.Add("aeron_archive", func() telegraf.Output {
+		return &AeronArchive{}
+	})
+}
@@ -0,0 +1,184 @@
+package aeron_archive
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_aeron "github.com/influxdata/telegraf/plugins/common/aeron"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestAeronArchive_Init_Defaults(t *testing.T) {
+	plugin := &AeronArchive{
+		Channel:  "aeron:udp?endpoint=localhost:40123",
+		StreamID: 10,
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	require.Equal(t, config.Duration(30*time.Second), plugin.DriverTimeout)
+	require.Equal(t, "aeron:udp?endpoint=localhost:8010", plugin.ControlRequestChannel)
+	require.Equal(t, int32(10), plugin.ControlRequestStreamID)
+	require.Equal(t, "aeron:udp?endpoint=localhost:0", plugin.ControlResponseChannel)
+	require.Equal(t, int32(20), plugin.ControlResponseStreamID)
+	require.Equal(t, config.Duration(5*time.Second), plugin.ControlTimeout)
+	require.Equal(t, config.Duration(10*time.Second), plugin.PublicationTimeout)
+	require.Equal(t, 3, plugin.MaxRetries)
+	require.Equal(t, config.Duration(time.Millisecond), plugin.RetryDelay)
+}
+
+func TestAeronArchive_Init_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *AeronArchive
+		expected string
+	}{
+		{
+			name:     "no channel",
+			plugin:   &AeronArchive{StreamID: 10},
+			expected: "channel is required",
+		},
+		{
+			name:     "no stream",
+			plugin:   &AeronArchive{Channel: "aeron:ipc"},
+			expected: "stream_id is required",
+		},
+		{
+			name:     "invalid channel",
+			plugin:   &AeronArchive{Channel: "udp://localhost:40123", StreamID: 10},
+			expected: "parsing channel failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Log = testutil.Logger{}
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestAeronArchive_NotConnected(t *testing.T) {
+	plugin := &AeronArchive{
+		Channel:  "aeron:ipc",
+		StreamID: 10,
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	// Metrics must be kept in the buffer if not connected
+	err := plugin.Write(testutil.MockMetrics())
+	require.ErrorContains(t, err, "not connected to Aeron archive")
+
+	require.NoError(t, plugin.Close())
+}
+
+func TestAeronArchive_Write(t *testing.T) {
+	fields := make(map[string]interface{})
+	for i := range 10 {
+		fields[fmt.Sprintf("field_%d", i)] = "very_long_value_that_exceeds_size_limit"
+	}
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		testutil.MustMetric("test", nil, fields, time.Unix(0, 0)),
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+
+	tests := []struct {
+		name     string
+		results  []int64
+		accepted []int
+		rejected []int
+		offered  int
+		expected string
+	}{
+		{
+			name:     "accept and reject oversized",
+			accepted: []int{0, 2, 3},
+			rejected: []int{1},
+			offered:  3,
+			expected: internal.ErrSizeLimitReached.Error(),
+		},
+		{
+			name:     "backpressure keeps remaining metrics",
+			results:  []int64{1, aeron.BackPressured, aeron.BackPressured},
+			accepted: []int{0},
+			rejected: []int{1},
+			offered:  1,
+			expected: "backpressure: publication buffer full",
+		},
+		{
+			name:     "not connected keeps all metrics",
+			results:  []int64{aeron.NotConnected, aeron.NotConnected},
+			accepted: []int{},
+			offered:  0,
+			expected: "publication not connected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &common_aeron.MockPublication{Results: tt.results}
+			plugin := newTestPlugin(t, pub)
+
+			err := plugin.Write(metrics)
+			require.ErrorContains(t, err, tt.expected)
+			var writeErr *internal.PartialWriteError
+			require.ErrorAs(t, err, &writeErr)
+			require.Equal(t, tt.accepted, writeErr.MetricsAccept)
+			require.Equal(t, tt.rejected, writeErr.MetricsReject)
+			require.Len(t, pub.Offered, tt.offered)
+			require.Same(t, pub, plugin.publication)
+		})
+	}
+}
+
+func TestAeronArchive_Write_PublicationClosed(t *testing.T) {
+	pub := &common_aeron.MockPublication{Results: []int64{1, aeron.PublicationClosed}}
+	plugin := newTestPlugin(t, pub)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+	}
+
+	// The closed publication is released to reconnect on the next write
+	err := plugin.Write(metrics)
+	require.ErrorContains(t, err, "publication closed")
+	var writeErr *internal.PartialWriteError
+	require.ErrorAs(t, err, &writeErr)
+	require.Equal(t, []int{0}, writeErr.MetricsAccept)
+	require.Nil(t, plugin.publication)
+	require.True(t, pub.Closed)
+}
+
+func newTestPlugin(t *testing.T, pub *common_aeron.MockPublication) *AeronArchive {
+	t.Helper()
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := &AeronArchive{
+		Channel:        "aeron:ipc",
+		StreamID:       10,
+		MaxMessageSize: 128,
+		MaxRetries:     1,
+		RetryDelay:     config.Duration(time.Microsecond),
+		Log:            testutil.Logger{},
+	}
+	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Init())
+	plugin.publication = pub
+	plugin.releaseDriver = func() {}
+
+	return plugin
+}
@@ -2,47 +2,75 @@ package aeron_publisher
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_aeron "github.com/influxdata/telegraf/plugins/common/aeron"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/lirm/aeron-go/aeron"
 	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
+// publication is the part of the Aeron publication used by the plugin
+type publication interface {
+	common_aeron.Publication
+	RegistrationID() int64
+}
+
 // AeronPublisher implements the telegraf.Output interface for publishing metrics to Aeron streams
 type AeronPublisher struct {
-	AeronDir               string          `toml:"aeron_dir"`
-	Channel                string          `toml:"channel"`
-	StreamID               int32           `toml:"stream_id"`
-	DriverTimeout          config.Duration `toml:"driver_timeout"`
-	PublicationTimeout     config.Duration `toml:"publication_timeout"`
-	MaxMessageSize         int             `toml:"max_message_size"`
-	MaxRetries             int             `toml:"max_retries"`
-	RetryDelay             config.Duration `toml:"retry_delay"`
-	RetryBackoffMultiplier float64         `toml:"retry_backoff_multiplier"`
-	MaxRetryDelay          config.Duration `toml:"max_retry_delay"`
-	Log                    telegraf.Logger `toml:"-"`
+	AeronDir               string              `toml:"aeron_dir"`
+	Channel                string              `toml:"channel"`
+	StreamID               int32               `toml:"stream_id"`
+	DriverTimeout          config.Duration     `toml:"driver_timeout"`
+	PublicationTimeout     config.Duration     `toml:"publication_timeout"`
+	MaxMessageSize         int                 `toml:"max_message_size"`
+	MaxRetries             int                 `toml:"max_retries"`
+	RetryDelay             config.Duration     `toml:"retry_delay"`
+	RetryBackoffMultiplier float64             `toml:"retry_backoff_multiplier"`
+	MaxRetryDelay          config.Duration     `toml:"max_retry_delay"`
+	BatchMessages          bool                `toml:"batch_messages"`
+	UseTryClaim            bool                `toml:"use_try_claim"`
+	TryClaimThreshold      int                 `toml:"try_claim_threshold"`
+	PublicationType        string              `toml:"publication_type"`
+	Destinations           []string            `toml:"destinations"`
+	MediaDriver            *mediadriver.Config `toml:"media_driver"`
+	Log                    telegraf.Logger     `toml:"-"`
 
 	// Internal state
-	serializer telegraf.Serializer
-	connected  bool
+	serializer       telegraf.Serializer
+	connected        bool
+	maxPayloadLength int
+	maxMessageLength int
+	batchEstimate    int
+	retry            common_aeron.Retry
+	claim            logbuffer.Claim
+	releaseDriver    func()
 
 	// Aeron objects
 	aeronContext  *aeron.Context
 	aeronInstance *aeron.Aeron
-	publication   *aeron.Publication
+	publication   publication
+	destinations  *destinationManager
 	mutex         sync.RWMutex
 
 	// Statistics (exposed as Telegraf metrics via selfstat)
 	messagesSent       selfstat.Stat
+	metricsSent        selfstat.Stat
 	messagesDropped    selfstat.Stat
 	bytesTransferred   selfstat.Stat
 	backpressureErrors selfstat.Stat
@@ -55,6 +83,30 @@ func (*AeronPublisher) SampleConfig() string {
 	return sampleConfig
 }
 
+// Init registers the statistics and the media driver if it is launched by
+// Telegraf
+func (a *AeronPublisher) Init() error {
+	// Initialize selfstat metrics for monitoring plugin health
+	tags := map[string]string{
+		"channel":   a.Channel,
+		"stream_id": fmt.Sprintf("%d", a.StreamID),
+	}
+	a.messagesSent = selfstat.Register("aeron_publisher", "messages_sent", tags)
+	a.metricsSent = selfstat.Register("aeron_publisher", "metrics_sent", tags)
+	a.messagesDropped = selfstat.Register("aeron_publisher", "messages_dropped", tags)
+	a.bytesTransferred = selfstat.Register("aeron_publisher", "bytes_transferred", tags)
+	a.backpressureErrors = selfstat.Register("aeron_publisher", "backpressure_errors", tags)
+	a.connectionErrors = selfstat.Register("aeron_publisher", "connection_errors", tags)
+	a.retryAttempts = selfstat.Register("aeron_publisher", "retry_attempts", tags)
+
+	if a.MediaDriver != nil {
+		if err := mediadriver.Register(a.AeronDir, a.MediaDriver, a.Log); err != nil {
+			return fmt.Errorf("registering media driver failed: %w", err)
+		}
+	}
+	return nil
+}
+
 // SetSerializer sets the serializer for the plugin
 func (a *AeronPublisher) SetSerializer(serializer telegraf.Serializer) {
 	a.serializer = serializer
@@ -81,6 +133,19 @@ func (a *AeronPublisher) Connect() error {
 	if a.MaxRetryDelay == 0 {
 		a.MaxRetryDelay = config.Duration(100 * time.Millisecond)
 	}
+	if a.PublicationType == "" {
+		a.PublicationType = "exclusive"
+	}
+	a.retry = common_aeron.Retry{
+		MaxRetries:        a.MaxRetries,
+		Delay:             time.Duration(a.RetryDelay),
+		BackoffMultiplier: a.RetryBackoffMultiplier,
+		MaxDelay:          time.Duration(a.MaxRetryDelay),
+		Attempts:          a.retryAttempts,
+		BackPressured:     a.backpressureErrors,
+		NotConnected:      a.connectionErrors,
+		Closed:            a.connectionErrors,
+	}
 
 	// Validate configuration
 	if a.Channel == "" {
@@ -89,21 +154,38 @@ func (a *AeronPublisher) Connect() error {
 	if a.StreamID == 0 {
 		return fmt.Errorf("stream_id is required and must be non-zero")
 	}
+	switch a.PublicationType {
+	case "exclusive", "concurrent":
+	default:
+		return fmt.Errorf("invalid publication_type %q", a.PublicationType)
+	}
 
-	// Initialize selfstat metrics for monitoring plugin health
-	tags := map[string]string{
-		"channel":   a.Channel,
-		"stream_id": fmt.Sprintf("%d", a.StreamID),
+	uri, err := aeron.ParseChannelUri(a.Channel)
+	if err != nil {
+		return fmt.Errorf("parsing channel failed: %w", err)
+	}
+	if len(a.Destinations) > 0 && uri.Get(aeron.MdcControlModeParamName) != aeron.MdcControlModeManual {
+		return errors.New("destinations require a channel with 'control-mode=manual'")
 	}
-	a.messagesSent = selfstat.Register("aeron_publisher", "messages_sent", tags)
-	a.messagesDropped = selfstat.Register("aeron_publisher", "messages_dropped", tags)
-	a.bytesTransferred = selfstat.Register("aeron_publisher", "bytes_transferred", tags)
-	a.backpressureErrors = selfstat.Register("aeron_publisher", "backpressure_errors", tags)
-	a.connectionErrors = selfstat.Register("aeron_publisher", "connection_errors", tags)
-	a.retryAttempts = selfstat.Register("aeron_publisher", "retry_attempts", tags)
 
 	a.Log.Infof("Connecting to Aeron: channel=%s, stream_id=%d", a.Channel, a.StreamID)
 
+	// Wait for the media driver if it is launched by Telegraf
+	release, err := mediadriver.Acquire(a.AeronDir, time.Duration(a.DriverTimeout))
+	if err != nil {
+		a.connectionErrors.Incr(1)
+		return err
+	}
+	// Drop the references to the closed client on failure so that neither
+	// Close nor a later Connect operate on it
+	defer func() {
+		if !a.connected {
+			a.aeronInstance = nil
+			a.aeronContext = nil
+			release()
+		}
+	}()
+
 	// Create Aeron context
 	a.aeronContext = aeron.NewContext()
 
@@ -123,12 +205,24 @@ func (a *AeronPublisher) Connect() error {
 	}
 	a.aeronInstance = aeronInstance
 
-	// Add exclusive publication
-	publication, err := a.aeronInstance.AddExclusivePublication(a.Channel, a.StreamID)
+	// Add the publication and its destinations
+	publication, err := a.addPublication()
 	if err != nil {
 		a.connectionErrors.Incr(1)
 		a.aeronInstance.Close()
-		return fmt.Errorf("failed to add exclusive publication: %w", err)
+		return err
+	}
+	if err := a.updateLimits(publication); err != nil {
+		publication.Close()
+		a.aeronInstance.Close()
+		return err
+	}
+	if err := a.updateDestinations(publication); err != nil {
+		a.connectionErrors.Incr(1)
+		a.closeDestinations(nil)
+		publication.Close()
+		a.aeronInstance.Close()
+		return err
 	}
 
 	// Wait for publication to be ready
@@ -143,115 +237,336 @@ func (a *AeronPublisher) Connect() error {
 
 	if a.publication == nil {
 		a.connectionErrors.Incr(1)
+		a.closeDestinations(publication)
+		publication.Close()
 		a.aeronInstance.Close()
 		return fmt.Errorf("publication not ready within timeout: %v", a.PublicationTimeout)
 	}
 
 	a.connected = true
+	a.releaseDriver = release
 	a.Log.Infof("Aeron publisher connected successfully")
 	return nil
 }
 
-// Write publishes metrics to the Aeron stream
+// reconnect re-establishes the Aeron client and the publication after the
+// connection was lost. Only the resources that are actually closed are
+// recreated so an open publication without subscribers is kept as is.
+func (a *AeronPublisher) reconnect() error {
+	if a.aeronInstance == nil || a.aeronInstance.IsClosed() {
+		if a.publication != nil {
+			a.publication.Close()
+			a.publication = nil
+		}
+		if a.aeronInstance != nil {
+			a.aeronInstance.Close()
+			a.aeronInstance = nil
+		}
+		// The driver might have been restarted so remap the CnC file
+		a.closeDestinations(nil)
+
+		aeronInstance, err := aeron.Connect(a.aeronContext)
+		if err != nil {
+			a.connectionErrors.Incr(1)
+			return fmt.Errorf("failed to connect to Aeron: %w", err)
+		}
+		a.aeronInstance = aeronInstance
+	}
+
+	if a.publication == nil || a.publication.IsClosed() {
+		publication, err := a.addPublication()
+		if err != nil {
+			a.connectionErrors.Incr(1)
+			return err
+		}
+		if err := a.updateLimits(publication); err != nil {
+			publication.Close()
+			return err
+		}
+		a.publication = publication
+		if a.destinations != nil {
+			a.destinations.reset()
+		}
+	}
+	if err := a.updateDestinations(a.publication); err != nil {
+		a.connectionErrors.Incr(1)
+		return err
+	}
+
+	if !a.publication.IsConnected() {
+		return common_aeron.ErrNotConnected
+	}
+
+	a.connected = true
+	a.Log.Infof("Aeron publisher reconnected")
+	return nil
+}
+
+// addPublication adds an exclusive or concurrent publication depending on the
+// configured publication type
+func (a *AeronPublisher) addPublication() (*aeron.Publication, error) {
+	if a.PublicationType == "concurrent" {
+		publication, err := a.aeronInstance.AddPublication(a.Channel, a.StreamID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add concurrent publication: %w", err)
+		}
+		return publication, nil
+	}
+
+	publication, err := a.aeronInstance.AddExclusivePublication(a.Channel, a.StreamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add exclusive publication: %w", err)
+	}
+	return publication, nil
+}
+
+// updateLimits determines the maximum payload of a single frame and the
+// maximum message length of the given publication. The aeron-go client does
+// not expose those limits, so they are read from the metadata of the log
+// buffer of the publication in the same way the client computes them.
+func (a *AeronPublisher) updateLimits(publication *aeron.Publication) error {
+	dir := filepath.Dir(a.aeronContext.CncFileName())
+	maxPayloadLength, maxMessageLength, err := readPublicationLimits(dir, publication.OriginalRegistrationID())
+	if err != nil {
+		return fmt.Errorf("determining limits of publication failed: %w", err)
+	}
+	a.maxPayloadLength = maxPayloadLength
+	a.maxMessageLength = maxMessageLength
+	return nil
+}
+
+// readPublicationLimits reads the MTU and term length from the log buffer of
+// the publication with the given registration ID and returns the maximum
+// payload of a single frame and the maximum length of a fragmented message
+func readPublicationLimits(dir string, registrationID int64) (maxPayloadLength, maxMessageLength int, err error) {
+	f, err := os.Open(filepath.Join(dir, "publications", strconv.FormatInt(registrationID, 10)+".logbuffer"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if stat.Size() < int64(logbuffer.LogMetaDataLength) {
+		return 0, 0, fmt.Errorf("log buffer %q too short", f.Name())
+	}
+
+	// The metadata is located at the end of the log buffer
+	data := make([]byte, logbuffer.LogMetaDataLength)
+	if _, err := f.ReadAt(data, stat.Size()-int64(len(data))); err != nil {
+		return 0, 0, fmt.Errorf("reading log buffer metadata failed: %w", err)
+	}
+	var meta logbuffer.LogBufferMetaData
+	meta.Wrap(atomic.MakeBuffer(data), 0)
+
+	mtu := meta.MTULen.Get()
+	termLength := meta.TermLen.Get()
+	if mtu <= logbuffer.DataFrameHeader.Length || termLength <= 0 {
+		return 0, 0, fmt.Errorf("invalid log buffer metadata with mtu %d and term length %d", mtu, termLength)
+	}
+	maxPayloadLength = int(mtu - logbuffer.DataFrameHeader.Length)
+	maxMessageLength = int(logbuffer.ComputeMaxMessageLength(termLength))
+	return maxPayloadLength, maxMessageLength, nil
+}
+
+// updateDestinations adds and removes the manual multi-destination-cast
+// destinations of the publication to match the configured destinations
+func (a *AeronPublisher) updateDestinations(publication publication) error {
+	if len(a.Destinations) == 0 && a.destinations == nil {
+		return nil
+	}
+
+	if a.destinations == nil {
+		m, err := newDestinationManager(a.aeronContext, a.aeronInstance.ClientID(), time.Duration(a.DriverTimeout))
+		if err != nil {
+			return err
+		}
+		a.destinations = m
+	}
+
+	return a.destinations.update(publication.RegistrationID(), a.Destinations)
+}
+
+// closeDestinations removes all destinations added by this plugin from the
+// given publication, if any, and releases the destination manager. Removing
+// the destinations is required for concurrent publications as those might
+// be shared with other publishers and thus outlive this plugin instance.
+func (a *AeronPublisher) closeDestinations(publication publication) {
+	if a.destinations == nil {
+		return
+	}
+
+	if publication != nil && !publication.IsClosed() {
+		if err := a.destinations.update(publication.RegistrationID(), nil); err != nil {
+			a.Log.Errorf("Removing destinations failed: %v", err)
+		}
+	}
+	if err := a.destinations.close(); err != nil {
+		a.Log.Errorf("Error closing destination manager: %v", err)
+	}
+	a.destinations = nil
+}
+
+// Write publishes metrics to the Aeron stream. Metrics that could not be
+// published due to backpressure or connection issues are kept in the output
+// buffer and retried on the next flush.
 func (a *AeronPublisher) Write(metrics []telegraf.Metric) error {
-	a.mutex.RLock()
-	defer a.mutex.RUnlock()
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
 
 	if !a.connected || a.publication == nil {
-		return fmt.Errorf("not connected to Aeron")
+		// A missing context means we never connected successfully or the
+		// plugin was already closed so there is nothing to reconnect.
+		if a.aeronContext == nil {
+			return errors.New("not connected to Aeron")
+		}
+		if err := a.reconnect(); err != nil {
+			return fmt.Errorf("not connected to Aeron: %w", err)
+		}
 	}
 
 	if a.serializer == nil {
-		return fmt.Errorf("serializer not set")
+		return errors.New("serializer not set")
 	}
 
-	for _, metric := range metrics {
-		data, err := a.serializer.Serialize(metric)
+	writeErr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	for start := 0; start < len(metrics); {
+		var data []byte
+		var end int
+		var err error
+		if a.BatchMessages {
+			data, end, err = a.serializeBatch(metrics, start)
+		} else {
+			data, err = a.serializer.Serialize(metrics[start])
+			end = start + 1
+		}
 		if err != nil {
 			a.Log.Errorf("Failed to serialize metric: %v", err)
+			writeErr.Err = internal.ErrSerialization
+			writeErr.MetricsReject = append(writeErr.MetricsReject, start)
+			writeErr.MetricsRejectErrors = append(writeErr.MetricsRejectErrors, err)
+			start = end
 			continue
 		}
 
-		// Optional size validation
-		if a.MaxMessageSize > 0 && len(data) > a.MaxMessageSize {
+		// Oversized metrics will never succeed so reject them instead of
+		// keeping them in the buffer
+		if limit := a.messageLimit(); len(data) > limit {
 			a.messagesDropped.Incr(1)
-			a.Log.Warnf("Dropping metric: serialized size %d exceeds max_message_size %d",
-				len(data), a.MaxMessageSize)
+			a.Log.Warnf("Dropping metric: serialized size %d exceeds the message limit %d",
+				len(data), limit)
+			writeErr.Err = internal.ErrSizeLimitReached
+			writeErr.MetricsReject = append(writeErr.MetricsReject, start)
+			writeErr.MetricsRejectErrors = append(writeErr.MetricsRejectErrors, internal.ErrSizeLimitReached)
+			start = end
 			continue
 		}
 
-		// Publish message with retry logic
-		if err := a.publishMessage(data); err != nil {
-			a.messagesDropped.Incr(1)
-			a.Log.Errorf("Failed to publish metric: %v", err)
-			continue
+		// Publish message with retry logic. On failure stop publishing and
+		// leave all remaining metrics in the buffer to preserve ordering.
+		if err := a.retry.Offer(func() int64 { return a.offerMessage(data) }); err != nil {
+			if errors.Is(err, common_aeron.ErrClosed) || errors.Is(err, common_aeron.ErrNotConnected) {
+				a.connected = false
+			}
+			writeErr.Err = fmt.Errorf("failed to publish metric: %w", err)
+			break
 		}
 
 		a.messagesSent.Incr(1)
+		a.metricsSent.Incr(int64(end - start))
 		a.bytesTransferred.Incr(int64(len(data)))
+		for i := start; i < end; i++ {
+			writeErr.MetricsAccept = append(writeErr.MetricsAccept, i)
+		}
+		start = end
 	}
 
-	return nil
+	if writeErr.Err == nil {
+		return nil
+	}
+	return writeErr
 }
 
-// publishMessage handles the actual message publishing with retry logic
-func (a *AeronPublisher) publishMessage(data []byte) error {
-	var lastErr error
-	delay := time.Duration(a.RetryDelay)
-
-	for attempt := 0; attempt <= a.MaxRetries; attempt++ {
-		if attempt > 0 {
-			a.retryAttempts.Incr(1)
-			time.Sleep(delay)
-			delay = time.Duration(float64(delay) * a.RetryBackoffMultiplier)
-			if delay > time.Duration(a.MaxRetryDelay) {
-				delay = time.Duration(a.MaxRetryDelay)
-			}
-		}
+// messageLimit returns the maximum size of a message, i.e. the configured
+// maximum if any but at most the maximum message length of the publication
+func (a *AeronPublisher) messageLimit() int {
+	if a.MaxMessageSize > 0 && a.MaxMessageSize < a.maxMessageLength {
+		return a.MaxMessageSize
+	}
+	return a.maxMessageLength
+}
+
+// serializeBatch packs as many metrics as possible, starting at the given
+// index, into a single message not exceeding the batch size limit. It returns
+// the message and the index of the first metric not contained in the message.
+// A single metric exceeding the limit is returned on its own. The number of
+// metrics per message is estimated from the previous message to avoid
+// serializing the metrics more than once in the common case.
+func (a *AeronPublisher) serializeBatch(metrics []telegraf.Metric, start int) ([]byte, int, error) {
+	limit := a.maxPayloadLength
+	if a.MaxMessageSize > 0 {
+		limit = a.messageLimit()
+	}
 
-		// Use standard Offer method
-		result, err := a.offerMessage(data)
+	n := max(a.batchEstimate, 1)
+	for {
+		n = min(n, len(metrics)-start)
+		data, err := a.serializer.SerializeBatch(metrics[start : start+n])
 		if err != nil {
-			lastErr = err
+			if n == 1 {
+				return nil, start + 1, err
+			}
+			// Isolate the offending metric by sending the metrics one by one
+			a.batchEstimate = 1
+			n = 1
 			continue
 		}
-
-		// Handle Aeron-specific result codes
-		switch result {
-		case aeron.BackPressured:
-			a.backpressureErrors.Incr(1)
-			lastErr = fmt.Errorf("backpressure: publication buffer full")
-			continue
-		case aeron.NotConnected:
-			a.connectionErrors.Incr(1)
-			lastErr = fmt.Errorf("publication not connected")
-			continue
-		case aeron.AdminAction:
-			lastErr = fmt.Errorf("admin action required")
-			continue
-		case aeron.PublicationClosed:
-			a.connectionErrors.Incr(1)
-			lastErr = fmt.Errorf("publication closed")
-			return lastErr // Don't retry on closed publication
-		default:
-			if result > 0 {
-				// Success - result is the new stream position
-				return nil
+		if len(data) <= limit || n == 1 {
+			// Adapt the estimate for the next message to fill it up as much
+			// as possible
+			if len(data) > 0 {
+				a.batchEstimate = max(n*limit/len(data), 1)
 			}
-			lastErr = fmt.Errorf("unknown result code: %d", result)
-			continue
+			return data, start + n, nil
 		}
-	}
 
-	return fmt.Errorf("failed after %d retries: %w", a.MaxRetries, lastErr)
+		// The message is too large so shrink the number of metrics
+		// proportionally to the overshoot
+		n = max(n*limit/len(data), 1)
+	}
 }
 
-// offerMessage uses the standard Offer method
-func (a *AeronPublisher) offerMessage(data []byte) (int64, error) {
+// offerMessage uses TryClaim for messages fitting into a single frame and
+// not exceeding the threshold if enabled and falls back to the standard Offer
+// method otherwise
+func (a *AeronPublisher) offerMessage(data []byte) int64 {
+	length := int32(len(data))
+	if a.useTryClaim(len(data)) {
+		// Write the data directly into the term buffer
+		result := a.publication.TryClaim(length, &a.claim)
+		if result > 0 {
+			a.claim.Buffer().PutBytesArray(a.claim.Offset(), &data, 0, length)
+			a.claim.Commit()
+		}
+		return result
+	}
+
 	// Create atomic buffer from data
 	buffer := atomic.MakeBuffer(data)
-	result := a.publication.Offer(buffer, 0, int32(len(data)), nil)
-	return result, nil
+	return a.publication.Offer(buffer, 0, length, nil)
+}
+
+// useTryClaim returns true if a message of the given length should be
+// published using TryClaim, which is limited to a single frame
+func (a *AeronPublisher) useTryClaim(length int) bool {
+	if !a.UseTryClaim || length > a.maxPayloadLength {
+		return false
+	}
+	return a.TryClaimThreshold <= 0 || length <= a.TryClaimThreshold
 }
 
 // Close shuts down the Aeron connection and cleans up resources
@@ -259,13 +574,15 @@ func (a *AeronPublisher) Close() error {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	if !a.connected {
+	// A missing context means we never connected or are already closed
+	if a.aeronContext == nil {
 		return nil
 	}
 
 	a.connected = false
 
-	// Close publication
+	// Remove the destinations and close publication
+	a.closeDestinations(a.publication)
 	if a.publication != nil {
 		a.publication.Close()
 		a.publication = nil
@@ -283,6 +600,11 @@ func (a *AeronPublisher) Close() error {
 	// Clean up context
 	a.aeronContext = nil
 
+	if a.releaseDriver != nil {
+		a.releaseDriver()
+		a.releaseDriver = nil
+	}
+
 	a.Log.Infof("Aeron publisher closed")
 	return nil
 }
@@ -2,11 +2,20 @@ package aeron_publisher
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/logbuffer"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_aeron "github.com/influxdata/telegraf/plugins/common/aeron"
+	"github.com/influxdata/telegraf/plugins/common/mediadriver"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -22,6 +31,21 @@ func TestAeronPublisher_SampleConfig(t *testing.T) {
 	require.Contains(t, config, "max_message_size")
 }
 
+func TestAeronPublisher_Init_MediaDriver(t *testing.T) {
+	plugin := &AeronPublisher{
+		AeronDir:    t.TempDir(),
+		Channel:     "aeron:ipc",
+		StreamID:    1001,
+		MediaDriver: &mediadriver.Config{},
+		Log:         testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "media driver command is required")
+
+	plugin.MediaDriver.Command = []string{"aeronmd"}
+	require.NoError(t, plugin.Init())
+	require.Equal(t, config.Duration(10*time.Second), plugin.MediaDriver.HeartbeatTimeout)
+}
+
 func TestAeronPublisher_Connect_EmptyChannel(t *testing.T) {
 	plugin := &AeronPublisher{
 		Channel:  "",
@@ -122,6 +146,7 @@ func TestAeronPublisher_ConnectAndWriteIntegration(t *testing.T) {
 
 	serializer := &influx.Serializer{}
 	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Init())
 
 	// This will fail if no media driver is running, which is expected
 	// Users can run with a media driver to test integration
@@ -235,3 +260,320 @@ func TestAeronPublisher_LargeMessage(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "not connected to Aeron")
 }
+
+// Test that a write without connection keeps all metrics in the buffer
+func TestAeronPublisher_Write_NotConnectedKeepsMetrics(t *testing.T) {
+	plugin := &AeronPublisher{
+		Log: testutil.Logger{},
+	}
+	plugin.SetSerializer(&influx.Serializer{})
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Now()),
+	}
+
+	// A plain error (not a partial-write error) signals the output model to
+	// keep all metrics of the batch for the next flush
+	err := plugin.Write(metrics)
+	require.ErrorContains(t, err, "not connected to Aeron")
+	var writeErr *internal.PartialWriteError
+	require.NotErrorAs(t, err, &writeErr)
+}
+
+func TestAeronPublisher_ReadPublicationLimits(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "publications"), 0o750))
+
+	// Create a log buffer with three terms followed by the metadata
+	termLength := logbuffer.TermMinLength
+	data := make([]byte, 3*termLength+logbuffer.LogMetaDataLength)
+	var meta logbuffer.LogBufferMetaData
+	meta.Wrap(atomic.MakeBuffer(data[3*termLength:]), 0)
+	meta.MTULen.Set(8192)
+	meta.TermLen.Set(termLength)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "publications", "42.logbuffer"), data, 0o600))
+
+	maxPayloadLength, maxMessageLength, err := readPublicationLimits(dir, 42)
+	require.NoError(t, err)
+	require.Equal(t, 8192-32, maxPayloadLength)
+	require.Equal(t, int(termLength)/8, maxMessageLength)
+
+	_, _, err = readPublicationLimits(dir, 43)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// Test that TryClaim is only used for messages fitting into a single frame
+func TestAeronPublisher_UseTryClaim(t *testing.T) {
+	plugin := &AeronPublisher{
+		UseTryClaim:      true,
+		maxPayloadLength: 1376,
+	}
+	require.True(t, plugin.useTryClaim(1376))
+	require.False(t, plugin.useTryClaim(1377))
+
+	plugin.TryClaimThreshold = 128
+	require.True(t, plugin.useTryClaim(128))
+	require.False(t, plugin.useTryClaim(129))
+
+	// The threshold cannot exceed the frame payload
+	plugin.TryClaimThreshold = 8192
+	require.False(t, plugin.useTryClaim(1377))
+
+	plugin.UseTryClaim = false
+	require.False(t, plugin.useTryClaim(1))
+}
+
+// Test that messages exceeding the maximum message length of the publication
+// are rejected instead of being offered
+func TestAeronPublisher_Write_RejectsOversized(t *testing.T) {
+	pub := &common_aeron.MockPublication{}
+	plugin := &AeronPublisher{
+		Channel:  "aeron:ipc",
+		StreamID: 1001,
+		Log:      testutil.Logger{},
+	}
+	plugin.SetSerializer(&influx.Serializer{})
+	require.NoError(t, plugin.Init())
+	plugin.publication = pub
+	plugin.connected = true
+	plugin.maxPayloadLength = 64
+	plugin.maxMessageLength = 128
+
+	fields := make(map[string]interface{})
+	for i := range 10 {
+		fields[fmt.Sprintf("field_%d", i)] = "very_long_value_that_exceeds_size_limit"
+	}
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("test", nil, fields, time.Unix(0, 0)),
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+
+	err := plugin.Write(metrics)
+	require.ErrorIs(t, err, internal.ErrSizeLimitReached)
+	var writeErr *internal.PartialWriteError
+	require.ErrorAs(t, err, &writeErr)
+	require.Equal(t, []int{1}, writeErr.MetricsAccept)
+	require.Equal(t, []int{0}, writeErr.MetricsReject)
+	require.Len(t, pub.Offered, 1)
+}
+
+// Test packing of metrics into messages limited by the max payload length
+func TestAeronPublisher_SerializeBatch(t *testing.T) {
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := &AeronPublisher{
+		BatchMessages:    true,
+		maxPayloadLength: 1376,
+		Log:              testutil.Logger{},
+	}
+	plugin.SetSerializer(serializer)
+
+	metrics := make([]telegraf.Metric, 0, 100)
+	for i := range 100 {
+		metrics = append(metrics, testutil.MustMetric("test",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"value": i},
+			time.Unix(1609459200, int64(i))),
+		)
+	}
+	expected, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	var messages int
+	var actual []byte
+	for start := 0; start < len(metrics); {
+		data, end, err := plugin.serializeBatch(metrics, start)
+		require.NoError(t, err)
+		require.Greater(t, end, start)
+		require.LessOrEqual(t, len(data), plugin.maxPayloadLength)
+		actual = append(actual, data...)
+		start = end
+		messages++
+	}
+	require.Equal(t, string(expected), string(actual))
+	require.Less(t, messages, len(metrics)/10)
+}
+
+// Test that a single metric exceeding the limit is returned on its own
+func TestAeronPublisher_SerializeBatch_Oversized(t *testing.T) {
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := &AeronPublisher{
+		BatchMessages:    true,
+		MaxMessageSize:   100,
+		batchEstimate:    10,
+		maxPayloadLength: 1376,
+		maxMessageLength: 8192,
+		Log:              testutil.Logger{},
+	}
+	plugin.SetSerializer(serializer)
+
+	fields := make(map[string]interface{})
+	for i := 0; i < 50; i++ {
+		fields[fmt.Sprintf("field_%d", i)] = "very_long_value_that_exceeds_size_limit"
+	}
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("test", nil, fields, time.Now()),
+		testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Now()),
+	}
+
+	data, end, err := plugin.serializeBatch(metrics, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, end)
+	require.Greater(t, len(data), plugin.MaxMessageSize)
+
+	data, end, err = plugin.serializeBatch(metrics, end)
+	require.NoError(t, err)
+	require.Equal(t, 2, end)
+	require.LessOrEqual(t, len(data), plugin.MaxMessageSize)
+}
+
+func BenchmarkAeronPublisher_Serialize(b *testing.B) {
+	serializer := &influx.Serializer{}
+	require.NoError(b, serializer.Init())
+
+	metrics := make([]telegraf.Metric, 0, 1000)
+	for i := range 1000 {
+		metrics = append(metrics, testutil.MustMetric("cpu",
+			map[string]string{"host": "localhost", "cpu": "cpu0"},
+			map[string]interface{}{"usage_user": float64(i), "usage_system": 0.5},
+			time.Unix(1609459200, int64(i))),
+		)
+	}
+
+	b.Run("single", func(b *testing.B) {
+		for b.Loop() {
+			for _, m := range metrics {
+				if _, err := serializer.Serialize(m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		plugin := &AeronPublisher{
+			BatchMessages:    true,
+			maxPayloadLength: 1376,
+			Log:              testutil.Logger{},
+		}
+		plugin.SetSerializer(serializer)
+
+		for b.Loop() {
+			for start := 0; start < len(metrics); {
+				_, end, err := plugin.serializeBatch(metrics, start)
+				if err != nil {
+					b.Fatal(err)
+				}
+				start = end
+			}
+		}
+	})
+}
+
+func TestAeronPublisher_Connect_InvalidPublicationType(t *testing.T) {
+	plugin := &AeronPublisher{
+		Channel:         "aeron:udp?endpoint=localhost:40123",
+		StreamID:        1001,
+		PublicationType: "shared",
+		Log:             testutil.Logger{},
+	}
+
+	err := plugin.Connect()
+	require.ErrorContains(t, err, `invalid publication_type "shared"`)
+}
+
+func TestAeronPublisher_Connect_DestinationsRequireManualControl(t *testing.T) {
+	plugin := &AeronPublisher{
+		Channel:      "aeron:udp?control=localhost:40456|control-mode=dynamic",
+		StreamID:     1001,
+		Destinations: []string{"aeron:udp?endpoint=localhost:40123"},
+		Log:          testutil.Logger{},
+	}
+
+	err := plugin.Connect()
+	require.ErrorContains(t, err, "destinations require a channel with 'control-mode=manual'")
+}
+
+func TestAeronPublisher_Connect_FailureResetsClient(t *testing.T) {
+	plugin := &AeronPublisher{
+		AeronDir:      t.TempDir(),
+		Channel:       "aeron:ipc",
+		StreamID:      1001,
+		DriverTimeout: config.Duration(100 * time.Millisecond),
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	// There is no media driver in the directory so connecting fails
+	require.ErrorContains(t, plugin.Connect(), "failed to connect to Aeron")
+	require.Nil(t, plugin.aeronContext)
+	require.Nil(t, plugin.aeronInstance)
+	require.False(t, plugin.connected)
+
+	// Neither closing nor writing must touch the failed client
+	require.NoError(t, plugin.Close())
+	require.ErrorContains(t, plugin.Write(testutil.MockMetrics()), "not connected to Aeron")
+}
+
+// Test that metrics which could not be published due to backpressure or a
+// missing subscriber are kept in the buffer
+func TestAeronPublisher_Write_KeepsRemainingMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []int64
+		accepted  []int
+		connected bool
+		expected  string
+	}{
+		{
+			name:      "backpressure",
+			results:   []int64{1, aeron.BackPressured, aeron.BackPressured},
+			accepted:  []int{0},
+			connected: true,
+			expected:  "backpressure: publication buffer full",
+		},
+		{
+			name:     "not connected",
+			results:  []int64{aeron.NotConnected, aeron.NotConnected},
+			accepted: []int{},
+			expected: "publication not connected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &common_aeron.MockPublication{Results: tt.results}
+			plugin := &AeronPublisher{
+				Channel:  "aeron:ipc",
+				StreamID: 1001,
+				Log:      testutil.Logger{},
+			}
+			plugin.SetSerializer(&influx.Serializer{})
+			require.NoError(t, plugin.Init())
+			plugin.retry = common_aeron.Retry{MaxRetries: 1, Delay: time.Microsecond}
+			plugin.publication = pub
+			plugin.connected = true
+			plugin.maxPayloadLength = 1376
+			plugin.maxMessageLength = 8192
+
+			metrics := []telegraf.Metric{
+				testutil.MustMetric("test", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+				testutil.MustMetric("test", nil, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+				testutil.MustMetric("test", nil, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+			}
+
+			// Neither accepted nor rejected metrics are kept in the buffer
+			err := plugin.Write(metrics)
+			require.ErrorContains(t, err, tt.expected)
+			var writeErr *internal.PartialWriteError
+			require.ErrorAs(t, err, &writeErr)
+			require.Equal(t, tt.accepted, writeErr.MetricsAccept)
+			require.Empty(t, writeErr.MetricsReject)
+			require.Len(t, pub.Offered, len(tt.accepted))
+			require.Equal(t, tt.connected, plugin.connected)
+		})
+	}
+}
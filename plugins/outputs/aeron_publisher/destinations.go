@@ -0,0 +1,225 @@
+package aeron_publisher
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lirm/aeron-go/aeron"
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/broadcast"
+	"github.com/lirm/aeron-go/aeron/command"
+	"github.com/lirm/aeron-go/aeron/counters"
+	"github.com/lirm/aeron-go/aeron/driver"
+	"github.com/lirm/aeron-go/aeron/flyweight"
+	rb "github.com/lirm/aeron-go/aeron/ringbuffer"
+	"github.com/lirm/aeron-go/aeron/util/memmap"
+)
+
+// Length of the destination command preceding the channel consisting of the
+// client, correlation and registration IDs and the length of the channel
+const destinationMessageHeaderLength = 3*8 + 4
+
+// destinationCommander executes destination commands for a publication and
+// waits for the response of the media driver
+type destinationCommander interface {
+	execute(msgTypeID int32, registrationID int64, channel string) error
+	close() error
+}
+
+// destinationManager handles the destinations of a multi-destination-cast
+// (MDC) publication in manual control mode
+type destinationManager struct {
+	commander destinationCommander
+
+	// Destinations added to the publication by this plugin and acknowledged
+	// by the media driver
+	active []string
+}
+
+// newDestinationManager creates a destination manager sending the commands
+// on behalf of the given Aeron client
+func newDestinationManager(ctx *aeron.Context, clientID int64, timeout time.Duration) (*destinationManager, error) {
+	commander, err := newCncCommander(ctx.CncFileName(), clientID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &destinationManager{commander: commander}, nil
+}
+
+// update adds and removes destinations of the publication with the given
+// registration ID such that the active destinations match the given list.
+// Only destinations previously added by this manager are removed.
+func (m *destinationManager) update(registrationID int64, destinations []string) error {
+	// Remove the destinations not configured anymore
+	active := make([]string, 0, len(destinations))
+	for i, dest := range m.active {
+		if slices.Contains(destinations, dest) {
+			active = append(active, dest)
+			continue
+		}
+		if err := m.commander.execute(command.RemoveDestination, registrationID, dest); err != nil {
+			m.active = append(active, m.active[i:]...)
+			return fmt.Errorf("removing destination %q failed: %w", dest, err)
+		}
+	}
+	m.active = active
+
+	// Add the new destinations
+	for _, dest := range destinations {
+		if slices.Contains(m.active, dest) {
+			continue
+		}
+		if err := m.commander.execute(command.AddDestination, registrationID, dest); err != nil {
+			return fmt.Errorf("adding destination %q failed: %w", dest, err)
+		}
+		m.active = append(m.active, dest)
+	}
+
+	return nil
+}
+
+// reset forgets about all active destinations, e.g. after the publication
+// was recreated
+func (m *destinationManager) reset() {
+	m.active = nil
+}
+
+// close releases the resources of the manager
+func (m *destinationManager) close() error {
+	m.active = nil
+	return m.commander.close()
+}
+
+// cncCommander sends the destination commands through the to-driver buffer of
+// the CnC file and awaits the responses on the to-clients broadcast buffer.
+// The aeron-go client neither exposes destination handling for publications
+// nor the responses of the media driver, so the commands are sent with the
+// client ID of the plugin's Aeron client and matched by correlation ID.
+type cncCommander struct {
+	cncFile   *memmap.File
+	toDriver  rb.ManyToOne
+	toClients *atomic.Buffer
+	clientID  int64
+	timeout   time.Duration
+}
+
+func newCncCommander(filename string, clientID int64, timeout time.Duration) (*cncCommander, error) {
+	meta, cncFile, err := counters.MapFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("mapping CnC file failed: %w", err)
+	}
+
+	c := &cncCommander{
+		cncFile:   cncFile,
+		toClients: meta.ToClientsBuf.Get(),
+		clientID:  clientID,
+		timeout:   timeout,
+	}
+	c.toDriver.Init(meta.ToDriverBuf.Get())
+
+	return c, nil
+}
+
+func (c *cncCommander) execute(msgTypeID int32, registrationID int64, channel string) error {
+	if channel == "" {
+		return errors.New("empty channel")
+	}
+
+	// Start receiving before sending the command to not miss the response
+	receiver, err := broadcast.NewReceiver(c.toClients)
+	if err != nil {
+		return fmt.Errorf("creating broadcast receiver failed: %w", err)
+	}
+	responses := broadcast.NewCopyReceiver(receiver)
+
+	correlationID := c.toDriver.NextCorrelationID()
+	buf, length := encodeDestinationMessage(c.clientID, correlationID, registrationID, channel)
+	if !c.toDriver.Write(msgTypeID, buf, 0, length) {
+		return errors.New("writing command to driver failed")
+	}
+
+	var done bool
+	var respErr error
+	handler := func(typeID int32, buffer *atomic.Buffer, offset, _ int32) {
+		if !done {
+			done, respErr = checkResponse(correlationID, typeID, buffer, offset)
+		}
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	for {
+		if responses.Receive(handler) > 0 {
+			if done {
+				return respErr
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no response from media driver within %v", c.timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *cncCommander) close() error {
+	if c.cncFile == nil {
+		return nil
+	}
+	err := c.cncFile.Close()
+	c.cncFile = nil
+	return err
+}
+
+// errorResponse is the flyweight of the error response of the media driver
+type errorResponse struct {
+	flyweight.FWBase
+
+	offendingCorrelationID flyweight.Int64Field
+	errorCode              flyweight.Int32Field
+	errorMessage           flyweight.StringField
+}
+
+func (m *errorResponse) Wrap(buf *atomic.Buffer, offset int) flyweight.Flyweight {
+	pos := offset
+	pos += m.offendingCorrelationID.Wrap(buf, pos)
+	pos += m.errorCode.Wrap(buf, pos)
+	pos += m.errorMessage.Wrap(buf, pos, m, true)
+
+	m.SetSize(pos - offset)
+	return m
+}
+
+// encodeDestinationMessage returns the buffer containing the destination
+// command for the given channel and the length of the command
+func encodeDestinationMessage(clientID, correlationID, registrationID int64, channel string) (*atomic.Buffer, int32) {
+	buf := atomic.MakeBuffer(make([]byte, destinationMessageHeaderLength+len(channel)))
+	var message command.DestinationMessage
+	message.Wrap(buf, 0)
+	message.ClientID.Set(clientID)
+	message.CorrelationID.Set(correlationID)
+	message.RegistrationCorrelationID.Set(registrationID)
+	message.Channel.Set(channel)
+	return buf, int32(message.Size())
+}
+
+// checkResponse returns true if the given message is the response to the
+// command with the given correlation ID and the error reported by the media
+// driver, if any
+func checkResponse(correlationID int64, typeID int32, buffer *atomic.Buffer, offset int32) (bool, error) {
+	switch typeID {
+	case driver.Events.OnOperationSuccess:
+		var msg command.CorrelatedMessage
+		msg.Wrap(buffer, int(offset))
+		return msg.CorrelationID.Get() == correlationID, nil
+	case driver.Events.OnError:
+		var msg errorResponse
+		msg.Wrap(buffer, int(offset))
+		if msg.offendingCorrelationID.Get() != correlationID {
+			return false, nil
+		}
+		return true, fmt.Errorf("media driver error %d: %s", msg.errorCode.Get(), msg.errorMessage.Get())
+	}
+	return false, nil
+}
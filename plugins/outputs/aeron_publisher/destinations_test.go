@@ -0,0 +1,131 @@
+package aeron_publisher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lirm/aeron-go/aeron/atomic"
+	"github.com/lirm/aeron-go/aeron/command"
+	"github.com/lirm/aeron-go/aeron/driver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationManager_Update(t *testing.T) {
+	commander := &mockCommander{}
+	m := &destinationManager{commander: commander}
+
+	require.NoError(t, m.update(42, []string{"aeron:udp?endpoint=a:40123", "aeron:udp?endpoint=b:40123"}))
+	require.Equal(t, []string{"aeron:udp?endpoint=a:40123", "aeron:udp?endpoint=b:40123"}, m.active)
+
+	require.NoError(t, m.update(42, []string{"aeron:udp?endpoint=b:40123", "aeron:udp?endpoint=c:40123"}))
+	require.Equal(t, []string{"aeron:udp?endpoint=b:40123", "aeron:udp?endpoint=c:40123"}, m.active)
+
+	expected := []destinationCommand{
+		{command.AddDestination, 42, "aeron:udp?endpoint=a:40123"},
+		{command.AddDestination, 42, "aeron:udp?endpoint=b:40123"},
+		{command.RemoveDestination, 42, "aeron:udp?endpoint=a:40123"},
+		{command.AddDestination, 42, "aeron:udp?endpoint=c:40123"},
+	}
+	require.Equal(t, expected, commander.commands)
+}
+
+func TestDestinationManager_RejectedDestination(t *testing.T) {
+	commander := &mockCommander{
+		reject: map[string]bool{"aeron:udp?endpoint=b:40123": true},
+	}
+	m := &destinationManager{commander: commander}
+
+	// A destination rejected by the driver must not be tracked as active
+	err := m.update(42, []string{"aeron:udp?endpoint=a:40123", "aeron:udp?endpoint=b:40123"})
+	require.ErrorContains(t, err, `adding destination "aeron:udp?endpoint=b:40123" failed: rejected`)
+	require.Equal(t, []string{"aeron:udp?endpoint=a:40123"}, m.active)
+
+	// Only destinations added by the manager are removed
+	commander.commands = nil
+	require.NoError(t, m.update(42, nil))
+	expected := []destinationCommand{
+		{command.RemoveDestination, 42, "aeron:udp?endpoint=a:40123"},
+	}
+	require.Equal(t, expected, commander.commands)
+	require.Empty(t, m.active)
+
+	require.NoError(t, m.close())
+	require.True(t, commander.closed)
+}
+
+func TestCheckResponse(t *testing.T) {
+	buf := atomic.MakeBuffer(make([]byte, 256))
+
+	var success command.CorrelatedMessage
+	success.Wrap(buf, 0)
+	success.ClientID.Set(1)
+	success.CorrelationID.Set(42)
+
+	done, err := checkResponse(42, driver.Events.OnOperationSuccess, buf, 0)
+	require.True(t, done)
+	require.NoError(t, err)
+
+	done, err = checkResponse(43, driver.Events.OnOperationSuccess, buf, 0)
+	require.False(t, done)
+	require.NoError(t, err)
+
+	var failure errorResponse
+	failure.Wrap(buf, 0)
+	failure.offendingCorrelationID.Set(42)
+	failure.errorCode.Set(1)
+	failure.errorMessage.Set("unknown publication")
+
+	done, err = checkResponse(42, driver.Events.OnError, buf, 0)
+	require.True(t, done)
+	require.EqualError(t, err, "media driver error 1: unknown publication")
+
+	done, err = checkResponse(43, driver.Events.OnError, buf, 0)
+	require.False(t, done)
+	require.NoError(t, err)
+
+	done, err = checkResponse(42, driver.Events.OnClientTimeout, buf, 0)
+	require.False(t, done)
+	require.NoError(t, err)
+}
+
+func TestEncodeDestinationMessage(t *testing.T) {
+	// Channels longer than any fixed-size buffer must be encoded completely
+	channel := "aeron:udp?endpoint=localhost:40123|alias=" + strings.Repeat("a", 1024)
+	buf, length := encodeDestinationMessage(1, 2, 42, channel)
+	require.Equal(t, int32(destinationMessageHeaderLength+len(channel)), length)
+
+	var message command.DestinationMessage
+	message.Wrap(buf, 0)
+	require.Equal(t, int64(1), message.ClientID.Get())
+	require.Equal(t, int64(2), message.CorrelationID.Get())
+	require.Equal(t, int64(42), message.RegistrationCorrelationID.Get())
+	require.Equal(t, channel, message.Channel.Get())
+}
+
+type destinationCommand struct {
+	msgTypeID      int32
+	registrationID int64
+	channel        string
+}
+
+// mockCommander records the executed commands and rejects the commands for
+// the given channels
+type mockCommander struct {
+	commands []destinationCommand
+	reject   map[string]bool
+	closed   bool
+}
+
+func (c *mockCommander) execute(msgTypeID int32, registrationID int64, channel string) error {
+	c.commands = append(c.commands, destinationCommand{msgTypeID, registrationID, channel})
+	if c.reject[channel] {
+		return errors.New("rejected")
+	}
+	return nil
+}
+
+func (c *mockCommander) close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,5 @@
+//go:build !custom || outputs || outputs.aeron_archive
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/aeron_archive" // register plugin
@@ -0,0 +1,213 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	defaultHTTPServiceAddress = "http://localhost:4318"
+	metricsPath               = "/v1/metrics"
+
+	// Upper limit for the time to wait as requested by the server to protect
+	// against excessive values
+	maxRetryAfter = 10 * time.Minute
+	// Time to wait if the server requests retrying without specifying a time
+	defaultRetryAfter = 5 * time.Second
+)
+
+// rejectedError signals the server rejected the data permanently, i.e. the
+// request must not be retried.
+type rejectedError struct {
+	err error
+}
+
+func (e *rejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rejectedError) Unwrap() error {
+	return e.err
+}
+
+func (o *OpenTelemetry) connectHTTP(tlsConfig *tls.Config) error {
+	switch o.Encoding {
+	case "":
+		o.Encoding = "protobuf"
+	case "protobuf", "json":
+	default:
+		return fmt.Errorf("invalid encoding %q", o.Encoding)
+	}
+
+	switch o.Compression {
+	case "none":
+	case "gzip":
+		encoder, err := internal.NewGzipEncoder()
+		if err != nil {
+			return fmt.Errorf("creating gzip encoder failed: %w", err)
+		}
+		o.encoder = encoder
+	default:
+		return fmt.Errorf("invalid compression %q", o.Compression)
+	}
+
+	if o.ServiceAddress == defaultServiceAddress {
+		o.ServiceAddress = defaultHTTPServiceAddress
+	}
+	u, err := url.Parse(o.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("parsing service address failed: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid scheme %q for the HTTP service address", u.Scheme)
+	}
+	// A base URL gets the signal path appended while a URL with path is used
+	// as-is according to the OTLP exporter specification
+	if u.Path == "" || u.Path == "/" {
+		u.Path = metricsPath
+	}
+	o.url = u.String()
+
+	proxy, err := o.HTTPProxy.Proxy()
+	if err != nil {
+		return err
+	}
+	o.httpClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: time.Duration(o.Timeout),
+	}
+
+	return nil
+}
+
+func (o *OpenTelemetry) exportHTTP(ctx context.Context, req pmetricotlp.ExportRequest) error {
+	// Respect the time to wait requested by the server
+	if wait := time.Until(o.retryTime); wait > 0 {
+		return fmt.Errorf("waiting %s before sending metrics again as requested by the server", wait.Round(time.Second))
+	}
+
+	var body []byte
+	var err error
+	contentType := "application/x-protobuf"
+	if o.Encoding == "json" {
+		contentType = "application/json"
+		body, err = req.MarshalJSON()
+	} else {
+		body, err = req.MarshalProto()
+	}
+	if err != nil {
+		return &rejectedError{fmt.Errorf("encoding request failed: %w", err)}
+	}
+	if o.encoder != nil {
+		if body, err = o.encoder.Encode(body); err != nil {
+			return fmt.Errorf("compressing request failed: %w", err)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("User-Agent", userAgent)
+	if o.encoder != nil {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range o.Headers {
+		if strings.EqualFold(k, "host") {
+			httpReq.Host = v
+		} else {
+			httpReq.Header.Set(k, v)
+		}
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response failed: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		response := pmetricotlp.NewExportResponse()
+		if o.Encoding == "json" {
+			err = response.UnmarshalJSON(buf)
+		} else {
+			err = response.UnmarshalProto(buf)
+		}
+		if err != nil {
+			o.Log.Debugf("Decoding response failed: %v", err)
+			return nil
+		}
+		o.checkPartialSuccess(response)
+		return nil
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		now := time.Now()
+		wait := retryAfter(resp.Header.Get("Retry-After"), now)
+		o.retryTime = now.Add(wait)
+		o.Log.Warnf("Server responded with %q, retrying in %s", resp.Status, wait)
+		return fmt.Errorf("sending metrics failed: %s%s", resp.Status, o.statusMessage(buf))
+	}
+
+	err = fmt.Errorf("sending metrics failed: %s%s", resp.Status, o.statusMessage(buf))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout {
+		return &rejectedError{err}
+	}
+	return err
+}
+
+// statusMessage decodes the message of the google.rpc.Status contained in
+// error responses
+func (o *OpenTelemetry) statusMessage(buf []byte) string {
+	if len(buf) == 0 {
+		return ""
+	}
+	var s status.Status
+	var err error
+	if o.Encoding == "json" {
+		err = protojson.Unmarshal(buf, &s)
+	} else {
+		err = proto.Unmarshal(buf, &s)
+	}
+	if err != nil || s.GetMessage() == "" {
+		return ""
+	}
+	return ": " + s.GetMessage()
+}
+
+// retryAfter returns the time to wait according to the Retry-After header
+// being either a number of seconds or a HTTP date.
+func retryAfter(header string, now time.Time) time.Duration {
+	wait := defaultRetryAfter
+	if header != "" {
+		if seconds, err := strconv.ParseUint(header, 10, 32); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(header); err == nil {
+			wait = max(t.Sub(now), 0)
+		}
+	}
+	return min(wait, maxRetryAfter)
+}
@@ -5,6 +5,9 @@ import (
 	"context"
 	ntls "crypto/tls"
 	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
 	"sort"
 	"time"
 
@@ -20,6 +23,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/proxy"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -31,8 +35,11 @@ var sampleConfig string
 
 type OpenTelemetry struct {
 	ServiceAddress string `toml:"service_address"`
+	Protocol       string `toml:"protocol"`
+	Encoding       string `toml:"encoding"`
 
 	tls.ClientConfig
+	proxy.HTTPProxy
 	Timeout     config.Duration   `toml:"timeout"`
 	Compression string            `toml:"compression"`
 	Headers     map[string]string `toml:"headers"`
@@ -45,6 +52,11 @@ type OpenTelemetry struct {
 	grpcClientConn       *grpc.ClientConn
 	metricsServiceClient pmetricotlp.GRPCClient
 	callOptions          []grpc.CallOption
+
+	httpClient *http.Client
+	url        string
+	encoder    internal.ContentEncoder
+	retryTime  time.Time
 }
 
 type CoralogixConfig struct {
@@ -83,10 +95,29 @@ func (o *OpenTelemetry) Connect() error {
 		return err
 	}
 
-	var grpcTLSDialOption grpc.DialOption
-	if tlsConfig, err := o.ClientConfig.TLSConfig(); err != nil {
+	tlsConfig, err := o.ClientConfig.TLSConfig()
+	if err != nil {
 		return err
-	} else if tlsConfig != nil {
+	}
+
+	switch o.Protocol {
+	case "", "grpc":
+		o.Protocol = "grpc"
+	case "http":
+		if tlsConfig == nil && o.Coralogix != nil {
+			tlsConfig = &ntls.Config{}
+		}
+		if err := o.connectHTTP(tlsConfig); err != nil {
+			return err
+		}
+		o.metricsConverter = metricsConverter
+		return nil
+	default:
+		return fmt.Errorf("invalid protocol %q", o.Protocol)
+	}
+
+	var grpcTLSDialOption grpc.DialOption
+	if tlsConfig != nil {
 		grpcTLSDialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
 	} else if o.Coralogix != nil {
 		// For coralogix, we enforce GRPC connection with TLS
@@ -114,6 +145,10 @@ func (o *OpenTelemetry) Connect() error {
 }
 
 func (o *OpenTelemetry) Close() error {
+	if o.httpClient != nil {
+		o.httpClient.CloseIdleConnections()
+		o.httpClient = nil
+	}
 	if o.grpcClientConn != nil {
 		err := o.grpcClientConn.Close()
 		o.grpcClientConn = nil
@@ -124,14 +159,14 @@ func (o *OpenTelemetry) Close() error {
 
 // Split metrics up by timestamp and send to Google Cloud Stackdriver
 func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
-	metricBatch := make(map[int64][]telegraf.Metric)
+	metricBatch := make(map[int64][]int)
 	timestamps := make([]int64, 0, len(metrics))
-	for _, metric := range metrics {
+	for i, metric := range metrics {
 		timestamp := metric.Time().UnixNano()
 		if existingSlice, ok := metricBatch[timestamp]; ok {
-			metricBatch[timestamp] = append(existingSlice, metric)
+			metricBatch[timestamp] = append(existingSlice, i)
 		} else {
-			metricBatch[timestamp] = []telegraf.Metric{metric}
+			metricBatch[timestamp] = []int{i}
 			timestamps = append(timestamps, timestamp)
 		}
 	}
@@ -140,13 +175,41 @@ func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
 	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
 
 	o.Log.Debugf("Received %d metrics and split into %d groups by timestamp", len(metrics), len(metricBatch))
+	writeErr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
 	for _, timestamp := range timestamps {
-		if err := o.sendBatch(metricBatch[timestamp]); err != nil {
-			return err
+		indices := metricBatch[timestamp]
+		batch := make([]telegraf.Metric, 0, len(indices))
+		for _, i := range indices {
+			batch = append(batch, metrics[i])
+		}
+
+		if err := o.sendBatch(batch); err != nil {
+			// Metrics rejected by the server will never succeed so drop them
+			// instead of keeping them in the buffer
+			var rerr *rejectedError
+			if errors.As(err, &rerr) {
+				o.Log.Errorf("Dropping %d metrics: %v", len(indices), err)
+				writeErr.Err = err
+				for _, i := range indices {
+					writeErr.MetricsReject = append(writeErr.MetricsReject, i)
+					writeErr.MetricsRejectErrors = append(writeErr.MetricsRejectErrors, err)
+				}
+				continue
+			}
+
+			// Keep the remaining metrics for retrying
+			writeErr.Err = err
+			break
 		}
+		writeErr.MetricsAccept = append(writeErr.MetricsAccept, indices...)
 	}
 
-	return nil
+	if writeErr.Err == nil {
+		return nil
+	}
+	return writeErr
 }
 
 func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
@@ -189,13 +252,32 @@ func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
+	defer cancel()
+
+	if o.Protocol == "http" {
+		return o.exportHTTP(ctx, md)
+	}
 
 	if len(o.Headers) > 0 {
 		ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.Headers))
 	}
-	defer cancel()
-	_, err := o.metricsServiceClient.Export(ctx, md, o.callOptions...)
-	return err
+	resp, err := o.metricsServiceClient.Export(ctx, md, o.callOptions...)
+	if err != nil {
+		return err
+	}
+	o.checkPartialSuccess(resp)
+	return nil
+}
+
+// checkPartialSuccess logs the data points rejected by the server. Those must
+// not be retried according to the OTLP specification.
+func (o *OpenTelemetry) checkPartialSuccess(resp pmetricotlp.ExportResponse) {
+	partial := resp.PartialSuccess()
+	if rejected := partial.RejectedDataPoints(); rejected > 0 {
+		o.Log.Warnf("Server rejected %d data points: %s", rejected, partial.ErrorMessage())
+	} else if msg := partial.ErrorMessage(); msg != "" {
+		o.Log.Warnf("Server accepted data points with warning: %s", msg)
+	}
 }
 
 const (
@@ -1,8 +1,13 @@
 package opentelemetry
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,12 +18,16 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -81,6 +90,243 @@ func TestOpenTelemetry(t *testing.T) {
 	require.JSONEq(t, string(expectJSON), string(gotJSON))
 }
 
+func TestOpenTelemetryHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    string
+		compression string
+		contentType string
+	}{
+		{
+			name:        "protobuf",
+			encoding:    "protobuf",
+			compression: "gzip",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name:        "json",
+			encoding:    "json",
+			compression: "none",
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received pmetricotlp.ExportRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/metrics" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get("Content-Type") != tt.contentType || r.Header.Get("test") != "header1" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				var body io.Reader = r.Body
+				if tt.compression == "gzip" {
+					if r.Header.Get("Content-Encoding") != "gzip" {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					reader, err := gzip.NewReader(r.Body)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					body = reader
+				}
+				buf, err := io.ReadAll(body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				received = pmetricotlp.NewExportRequest()
+				resp := pmetricotlp.NewExportResponse()
+				resp.PartialSuccess().SetRejectedDataPoints(1)
+				resp.PartialSuccess().SetErrorMessage("invalid data point")
+				if tt.encoding == "json" {
+					err = received.UnmarshalJSON(buf)
+					buf, _ = resp.MarshalJSON()
+				} else {
+					err = received.UnmarshalProto(buf)
+					buf, _ = resp.MarshalProto()
+				}
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(buf)
+			}))
+			defer server.Close()
+
+			logger := &testutil.CaptureLogger{}
+			plugin := &OpenTelemetry{
+				ServiceAddress: server.URL,
+				Protocol:       "http",
+				Encoding:       tt.encoding,
+				Compression:    tt.compression,
+				Timeout:        config.Duration(time.Second),
+				Headers:        map[string]string{"test": "header1"},
+				Log:            logger,
+			}
+			require.NoError(t, plugin.Connect())
+			defer plugin.Close()
+
+			input := testutil.MustMetric(
+				"cpu_temp",
+				map[string]string{"foo": "bar"},
+				map[string]interface{}{"gauge": 87.332},
+				time.Unix(0, 1622848686000000000),
+			)
+			require.NoError(t, plugin.Write([]telegraf.Metric{input}))
+
+			require.Equal(t, 1, received.Metrics().DataPointCount())
+			require.Equal(t, "cpu_temp", received.Metrics().ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
+			warnings := logger.Warnings()
+			require.Len(t, warnings, 1)
+			require.Contains(t, warnings[0], "Server rejected 1 data points: invalid data point")
+		})
+	}
+}
+
+func TestOpenTelemetryHTTPRetryAfter(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	plugin := &OpenTelemetry{
+		ServiceAddress: server.URL,
+		Protocol:       "http",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+	}
+
+	// All metrics are kept for retrying
+	err := plugin.Write(metrics)
+	require.ErrorContains(t, err, "503 Service Unavailable")
+	var writeErr *internal.PartialWriteError
+	require.ErrorAs(t, err, &writeErr)
+	require.Empty(t, writeErr.MetricsAccept)
+	require.Empty(t, writeErr.MetricsReject)
+	require.Equal(t, int64(1), requests.Load())
+	require.WithinDuration(t, time.Now().Add(2*time.Minute), plugin.retryTime, 5*time.Second)
+
+	// No request is sent before the requested time passed
+	require.ErrorContains(t, plugin.Write(metrics), "as requested by the server")
+	require.Equal(t, int64(1), requests.Load())
+}
+
+func TestOpenTelemetryHTTPRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		req := pmetricotlp.NewExportRequest()
+		if err := req.UnmarshalProto(buf); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Reject the metrics of the first timestamp
+		ts := req.Metrics().ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).Timestamp()
+		if ts.AsTime().Unix() != 1 {
+			_, _ = w.Write(nil)
+			return
+		}
+		msg, err := proto.Marshal(status.New(codes.InvalidArgument, "invalid metric").Proto())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(msg)
+	}))
+	defer server.Close()
+
+	plugin := &OpenTelemetry{
+		ServiceAddress: server.URL,
+		Protocol:       "http",
+		Compression:    "none",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0), telegraf.Gauge),
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0), telegraf.Gauge),
+	}
+
+	// Rejected metrics are dropped while the others are accepted
+	err := plugin.Write(metrics)
+	require.ErrorContains(t, err, "400 Bad Request: invalid metric")
+	var writeErr *internal.PartialWriteError
+	require.ErrorAs(t, err, &writeErr)
+	require.Equal(t, []int{0}, writeErr.MetricsAccept)
+	require.Equal(t, []int{1}, writeErr.MetricsReject)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{
+			name:     "missing",
+			expected: defaultRetryAfter,
+		},
+		{
+			name:     "seconds",
+			header:   "30",
+			expected: 30 * time.Second,
+		},
+		{
+			name:     "date",
+			header:   now.Add(time.Minute).Format(http.TimeFormat),
+			expected: time.Minute,
+		},
+		{
+			name:     "date in the past",
+			header:   now.Add(-time.Minute).Format(http.TimeFormat),
+			expected: 0,
+		},
+		{
+			name:     "limited",
+			header:   "86400",
+			expected: maxRetryAfter,
+		},
+		{
+			name:     "invalid",
+			header:   "soon",
+			expected: defaultRetryAfter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, retryAfter(tt.header, now))
+		})
+	}
+}
+
 var _ pmetricotlp.GRPCServer = (*mockOtelService)(nil)
 
 type mockOtelService struct {
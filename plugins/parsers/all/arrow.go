@@ -0,0 +1,5 @@
+//go:build !custom || parsers || parsers.arrow
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/parsers/arrow" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || parsers || parsers.sbe
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/parsers/sbe" // register plugin
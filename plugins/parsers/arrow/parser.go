@@ -0,0 +1,182 @@
+package arrow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Schema metadata key containing the measurement name
+const measurementKey = "measurement"
+
+type Parser struct {
+	MeasurementColumn string   `toml:"arrow_measurement_column"`
+	TagColumns        []string `toml:"arrow_tag_columns"`
+	TimestampColumn   string   `toml:"arrow_timestamp_column"`
+	TimestampFormat   string   `toml:"arrow_timestamp_format"`
+	TimestampTimezone string   `toml:"arrow_timestamp_timezone"`
+
+	defaultTags map[string]string
+	location    *time.Location
+	metricName  string
+}
+
+func (p *Parser) Init() error {
+	if p.TimestampFormat == "" {
+		p.TimestampFormat = "unix"
+	}
+	if p.TimestampTimezone == "" {
+		p.location = time.UTC
+	} else {
+		loc, err := time.LoadLocation(p.TimestampTimezone)
+		if err != nil {
+			return fmt.Errorf("invalid location %s: %w", p.TimestampTimezone, err)
+		}
+		p.location = loc
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+	reader := bytes.NewReader(buf)
+
+	// Data may contain multiple concatenated streams
+	var metrics []telegraf.Metric
+	for reader.Len() > 0 {
+		streamReader, err := ipc.NewReader(reader, ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create stream reader: %w", err)
+		}
+
+		for streamReader.Next() {
+			m, err := p.parseRecord(streamReader.Record(), now)
+			if err != nil {
+				streamReader.Release()
+				return nil, err
+			}
+			metrics = append(metrics, m...)
+		}
+		err = streamReader.Err()
+		streamReader.Release()
+		if err != nil {
+			return nil, fmt.Errorf("reading stream failed: %w", err)
+		}
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) parseRecord(record arrow.Record, now time.Time) ([]telegraf.Metric, error) {
+	schema := record.Schema()
+
+	name := p.metricName
+	if idx := schema.Metadata().FindKey(measurementKey); idx >= 0 {
+		name = schema.Metadata().Values()[idx]
+	}
+
+	metrics := make([]telegraf.Metric, 0, record.NumRows())
+	for row := range int(record.NumRows()) {
+		m := metric.New(name, p.defaultTags, nil, now)
+		for i, field := range schema.Fields() {
+			val, err := columnar.Value(record.Column(i), row)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", field.Name, err)
+			}
+			if val == nil {
+				continue
+			}
+
+			switch {
+			case p.MeasurementColumn != "" && field.Name == p.MeasurementColumn:
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				m.SetName(valStr)
+			case p.TimestampColumn != "" && field.Name == p.TimestampColumn:
+				if ts, ok := val.(time.Time); ok {
+					m.SetTime(ts)
+					continue
+				}
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				timestamp, err := internal.ParseTimestamp(p.TimestampFormat, valStr, p.location)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse '%s' to '%s'", valStr, p.TimestampFormat)
+				}
+				m.SetTime(timestamp)
+			case isTag(field) || slices.Contains(p.TagColumns, field.Name):
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				m.AddTag(field.Name, valStr)
+			default:
+				m.AddField(field.Name, val)
+			}
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+// isTag returns true for dictionary-encoded string columns
+func isTag(field arrow.Field) bool {
+	dt, ok := field.Type.(*arrow.DictionaryType)
+	if !ok {
+		return false
+	}
+	switch dt.ValueType.ID() {
+	case arrow.STRING, arrow.LARGE_STRING:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, nil
+	}
+	if len(metrics) > 1 {
+		return nil, errors.New("line contains multiple metrics")
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.defaultTags = tags
+}
+
+func init() {
+	parsers.Add("arrow",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{
+				metricName:      defaultMetricName,
+				TimestampColumn: "timestamp",
+			}
+		},
+	)
+}
@@ -0,0 +1,140 @@
+package arrow
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func writeStream(t *testing.T, buf *bytes.Buffer, schema *arrow.Schema, fill func(*array.RecordBuilder)) {
+	t.Helper()
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	fill(builder)
+	record := builder.NewRecord()
+	defer record.Release()
+
+	writer := ipc.NewWriter(buf, ipc.WithSchema(schema))
+	require.NoError(t, writer.Write(record))
+	require.NoError(t, writer.Close())
+}
+
+func TestParse(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "host", Type: arrow.BinaryTypes.String},
+		{Name: "time", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float32, Nullable: true},
+		{Name: "count", Type: arrow.PrimitiveTypes.Uint16},
+		{Name: "ok", Type: arrow.FixedWidthTypes.Boolean},
+	}, nil)
+
+	var buf bytes.Buffer
+	writeStream(t, &buf, schema, func(b *array.RecordBuilder) {
+		b.Field(0).(*array.StringBuilder).AppendValues([]string{"cpu", "mem"}, nil)
+		b.Field(1).(*array.StringBuilder).AppendValues([]string{"server01", "server02"}, nil)
+		b.Field(2).(*array.Int64Builder).AppendValues([]int64{1700000000, 1700000010}, nil)
+		b.Field(3).(*array.Float32Builder).AppendValues([]float32{1.5, 0}, []bool{true, false})
+		b.Field(4).(*array.Uint16Builder).AppendValues([]uint16{1, 2}, nil)
+		b.Field(5).(*array.BooleanBuilder).AppendValues([]bool{true, false}, nil)
+	})
+
+	parser := &Parser{
+		MeasurementColumn: "name",
+		TagColumns:        []string{"host"},
+		TimestampColumn:   "time",
+		metricName:        "arrow",
+	}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"value": 1.5, "count": uint64(1), "ok": true},
+			time.Unix(1700000000, 0),
+		),
+		metric.New(
+			"mem",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"count": uint64(2), "ok": false},
+			time.Unix(1700000010, 0),
+		),
+	}
+
+	actual, err := parser.Parse(buf.Bytes())
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseMultipleStreams(t *testing.T) {
+	tagType := &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int8, ValueType: arrow.BinaryTypes.String}
+
+	var buf bytes.Buffer
+	for i, name := range []string{"cpu", "disk"} {
+		metadata := arrow.NewMetadata([]string{"measurement"}, []string{name})
+		schema := arrow.NewSchema([]arrow.Field{
+			{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ms},
+			{Name: "host", Type: tagType},
+			{Name: "value", Type: arrow.PrimitiveTypes.Int32},
+		}, &metadata)
+		writeStream(t, &buf, schema, func(b *array.RecordBuilder) {
+			b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(1700000000000 + i))
+			require.NoError(t, b.Field(1).(*array.BinaryDictionaryBuilder).AppendString("server01"))
+			b.Field(2).(*array.Int32Builder).Append(int32(i))
+		})
+	}
+
+	parser := &Parser{TimestampColumn: "timestamp", metricName: "arrow"}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"value": int64(0)},
+			time.UnixMilli(1700000000000),
+		),
+		metric.New(
+			"disk",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"value": int64(1)},
+			time.UnixMilli(1700000000001),
+		),
+	}
+
+	actual, err := parser.Parse(buf.Bytes())
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{metricName: "arrow"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte("not an arrow stream"))
+	require.ErrorContains(t, err, "unable to create stream reader")
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "values", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64)},
+	}, nil)
+	var buf bytes.Buffer
+	writeStream(t, &buf, schema, func(b *array.RecordBuilder) {
+		lb := b.Field(0).(*array.ListBuilder)
+		lb.Append(true)
+		lb.ValueBuilder().(*array.Int64Builder).Append(1)
+	})
+	_, err = parser.Parse(buf.Bytes())
+	require.ErrorContains(t, err, `column "values": unsupported type`)
+}
@@ -0,0 +1,226 @@
+package sbe
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/sbe"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Separator used to flatten composites, sets, arrays and groups
+const separator = "_"
+
+type Message struct {
+	Name            string   `toml:"name"`
+	Measurement     string   `toml:"measurement"`
+	Tags            []string `toml:"tags"`
+	Fields          []string `toml:"fields"`
+	Timestamp       string   `toml:"timestamp"`
+	TimestampFormat string   `toml:"timestamp_format"`
+	SplitGroup      string   `toml:"split_group"`
+
+	tags   filter.Filter
+	fields filter.Filter
+}
+
+type Parser struct {
+	Schema   string          `toml:"sbe_schema"`
+	Messages []*Message      `toml:"sbe_message"`
+	Log      telegraf.Logger `toml:"-"`
+
+	defaultTags map[string]string
+	decoder     *sbe.Decoder
+	messages    map[string]*Message
+}
+
+func (p *Parser) Init() error {
+	if p.Schema == "" {
+		return errors.New("'sbe_schema' is required")
+	}
+	schema, err := sbe.LoadSchema(p.Schema)
+	if err != nil {
+		return fmt.Errorf("loading schema %q failed: %w", p.Schema, err)
+	}
+	p.decoder = &sbe.Decoder{Schema: schema, Separator: separator}
+
+	p.messages = make(map[string]*Message, len(p.Messages))
+	for i, m := range p.Messages {
+		msg, found := schema.MessageByName(m.Name)
+		if !found {
+			return fmt.Errorf("message %d: unknown message %q", i, m.Name)
+		}
+		if _, found := p.messages[m.Name]; found {
+			return fmt.Errorf("message %d: duplicate message %q", i, m.Name)
+		}
+		if err := m.init(msg); err != nil {
+			return fmt.Errorf("message %q: %w", m.Name, err)
+		}
+		p.messages[m.Name] = m
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+
+	// The buffer might contain multiple concatenated messages
+	metrics := make([]telegraf.Metric, 0)
+	for len(buf) > 0 {
+		msg, rec, n, err := p.decoder.Decode(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[n:]
+
+		cfg, found := p.messages[msg.Name]
+		if !found {
+			if len(p.messages) > 0 {
+				p.Log.Debugf("Ignoring message %q without configuration", msg.Name)
+				continue
+			}
+			cfg = &Message{Name: msg.Name, Measurement: msg.Name}
+		}
+
+		m, err := cfg.metrics(rec, now)
+		if err != nil {
+			return nil, fmt.Errorf("message %q: %w", msg.Name, err)
+		}
+		for _, x := range m {
+			for k, v := range p.defaultTags {
+				if !x.HasTag(k) {
+					x.AddTag(k, v)
+				}
+			}
+		}
+		metrics = append(metrics, m...)
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(metrics) {
+	case 0:
+		return nil, nil
+	case 1:
+		return metrics[0], nil
+	default:
+		return metrics[0], fmt.Errorf("cannot parse line with multiple (%d) metrics", len(metrics))
+	}
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.defaultTags = tags
+}
+
+func (m *Message) init(msg *sbe.Message) error {
+	if m.Measurement == "" {
+		m.Measurement = m.Name
+	}
+
+	switch m.TimestampFormat {
+	case "":
+		m.TimestampFormat = "unix_ns"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid 'timestamp_format' %q", m.TimestampFormat)
+	}
+
+	if m.SplitGroup != "" {
+		var found bool
+		for _, g := range msg.Groups {
+			if g.Name == m.SplitGroup {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown group %q", m.SplitGroup)
+		}
+	}
+
+	var err error
+	if m.tags, err = filter.Compile(m.Tags); err != nil {
+		return fmt.Errorf("compiling tags failed: %w", err)
+	}
+	if m.fields, err = filter.Compile(m.Fields); err != nil {
+		return fmt.Errorf("compiling fields failed: %w", err)
+	}
+	return nil
+}
+
+// metrics creates the metrics of the decoded message. In case a group is split
+// one metric is created per entry containing the entry's values in addition
+// to the values of the message.
+func (m *Message) metrics(rec *sbe.Record, now time.Time) ([]telegraf.Metric, error) {
+	if m.SplitGroup == "" {
+		x, err := m.metric(rec.Flatten(separator), now)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{x}, nil
+	}
+
+	entries := rec.Groups[m.SplitGroup]
+	root := &sbe.Record{Values: rec.Values, Groups: maps.Clone(rec.Groups)}
+	delete(root.Groups, m.SplitGroup)
+	values := root.Flatten(separator)
+
+	metrics := make([]telegraf.Metric, 0, len(entries))
+	for _, entry := range entries {
+		merged := maps.Clone(values)
+		maps.Copy(merged, entry.Flatten(separator))
+		x, err := m.metric(merged, now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, x)
+	}
+	return metrics, nil
+}
+
+func (m *Message) metric(values map[string]interface{}, now time.Time) (telegraf.Metric, error) {
+	t := now
+	tags := make(map[string]string)
+	fields := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		switch {
+		case m.Timestamp != "" && k == m.Timestamp:
+			ts, err := internal.ParseTimestamp(m.TimestampFormat, v, nil)
+			if err != nil {
+				return nil, fmt.Errorf("parsing timestamp %q failed: %w", k, err)
+			}
+			t = ts
+		case m.tags != nil && m.tags.Match(k):
+			tag, err := internal.ToString(v)
+			if err != nil {
+				return nil, fmt.Errorf("converting tag %q failed: %w", k, err)
+			}
+			tags[k] = tag
+		case m.fields == nil || m.fields.Match(k):
+			fields[k] = v
+		}
+	}
+	return metric.New(m.Measurement, tags, fields, t), nil
+}
+
+func init() {
+	parsers.Add("sbe",
+		func(string) telegraf.Parser {
+			return &Parser{}
+		},
+	)
+}
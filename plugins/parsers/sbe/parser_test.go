@@ -0,0 +1,208 @@
+package sbe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/sbe"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const schemaFile = "../../common/sbe/testdata/schema.xml"
+
+func encode(t *testing.T, name string, values map[string]interface{}) []byte {
+	t.Helper()
+
+	schema, err := sbe.LoadSchema(schemaFile)
+	require.NoError(t, err)
+	msg, found := schema.MessageByName(name)
+	require.True(t, found)
+
+	encoder := &sbe.Encoder{Schema: schema, Separator: separator}
+	buf, err := encoder.Encode(msg, values)
+	require.NoError(t, err)
+	return buf
+}
+
+var sensorValues = map[string]interface{}{
+	"timestamp":        int64(1700000000000000000),
+	"host":             "node-1",
+	"state":            "Running",
+	"flags_calibrated": true,
+	"temperature":      21.5,
+	"position_x":       1.25,
+	"position_y":       -3.5,
+	"humidity":         48.0,
+	"channels_0_index": 0,
+	"channels_0_value": -12,
+	"channels_1_index": 1,
+	"channels_1_value": 34,
+	"location":         "rack 7",
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		expected string
+	}{
+		{
+			name:     "missing schema",
+			parser:   &Parser{},
+			expected: "'sbe_schema' is required",
+		},
+		{
+			name:     "unknown message",
+			parser:   &Parser{Schema: schemaFile, Messages: []*Message{{Name: "Unknown"}}},
+			expected: `unknown message "Unknown"`,
+		},
+		{
+			name: "duplicate message",
+			parser: &Parser{
+				Schema:   schemaFile,
+				Messages: []*Message{{Name: "Sensor"}, {Name: "Sensor"}},
+			},
+			expected: `duplicate message "Sensor"`,
+		},
+		{
+			name: "invalid timestamp format",
+			parser: &Parser{
+				Schema:   schemaFile,
+				Messages: []*Message{{Name: "Sensor", Timestamp: "timestamp", TimestampFormat: "RFC3339"}},
+			},
+			expected: `invalid 'timestamp_format' "RFC3339"`,
+		},
+		{
+			name: "unknown split group",
+			parser: &Parser{
+				Schema:   schemaFile,
+				Messages: []*Message{{Name: "Heartbeat", SplitGroup: "channels"}},
+			},
+			expected: `unknown group "channels"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.parser.Init(), tt.expected)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	parser := &Parser{
+		Schema: schemaFile,
+		Messages: []*Message{
+			{
+				Name:        "Sensor",
+				Measurement: "sensor",
+				Tags:        []string{"host", "state"},
+				Fields:      []string{"temperature", "humidity", "position_*", "flags_*"},
+				Timestamp:   "timestamp",
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"source": "aeron", "host": "default"})
+
+	// Unconfigured messages are ignored
+	buf := encode(t, "Sensor", sensorValues)
+	buf = append(buf, encode(t, "Heartbeat", map[string]interface{}{"timestamp": 1, "sequence": 2})...)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{
+				"host":   "node-1",
+				"state":  "Running",
+				"source": "aeron",
+			},
+			map[string]interface{}{
+				"temperature":      21.5,
+				"humidity":         48.0,
+				"position_x":       1.25,
+				"position_y":       -3.5,
+				"flags_calibrated": true,
+				"flags_simulated":  false,
+			},
+			time.Unix(0, 1700000000000000000),
+		),
+	}
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseWithoutMessageConfig(t *testing.T) {
+	parser := &Parser{Schema: schemaFile}
+	require.NoError(t, parser.Init())
+
+	buf := encode(t, "Heartbeat", map[string]interface{}{"timestamp": 1, "sequence": 2})
+	expected := []telegraf.Metric{
+		metric.New(
+			"Heartbeat",
+			map[string]string{},
+			map[string]interface{}{
+				"timestamp": int64(1),
+				"sequence":  uint64(2),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestParseSplitGroup(t *testing.T) {
+	parser := &Parser{
+		Schema: schemaFile,
+		Messages: []*Message{
+			{
+				Name:            "Sensor",
+				Measurement:     "channel",
+				Tags:            []string{"host", "index"},
+				Fields:          []string{"value"},
+				Timestamp:       "timestamp",
+				TimestampFormat: "unix_ns",
+				SplitGroup:      "channels",
+			},
+		},
+	}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"channel",
+			map[string]string{"host": "node-1", "index": "0"},
+			map[string]interface{}{"value": int64(-12)},
+			time.Unix(0, 1700000000000000000),
+		),
+		metric.New(
+			"channel",
+			map[string]string{"host": "node-1", "index": "1"},
+			map[string]interface{}{"value": int64(34)},
+			time.Unix(0, 1700000000000000000),
+		),
+	}
+
+	actual, err := parser.Parse(encode(t, "Sensor", sensorValues))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{Schema: schemaFile}
+	require.NoError(t, parser.Init())
+
+	buf := encode(t, "Heartbeat", map[string]interface{}{"timestamp": 1, "sequence": 2})
+	_, err := parser.Parse(buf[:len(buf)-1])
+	require.ErrorContains(t, err, "exceeds the message")
+}
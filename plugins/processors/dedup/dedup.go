@@ -4,6 +4,7 @@ package dedup
 import (
 	_ "embed"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -22,6 +23,9 @@ type Dedup struct {
 
 	flushTime time.Time
 	cache     map[uint64]telegraf.Metric
+
+	// Protects the cache as the state can be checkpointed while running
+	cacheLock sync.Mutex
 }
 
 func (*Dedup) SampleConfig() string {
@@ -29,6 +33,9 @@ func (*Dedup) SampleConfig() string {
 }
 
 func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	d.cacheLock.Lock()
+	defer d.cacheLock.Unlock()
+
 	idx := 0
 	for _, metric := range metrics {
 		id := metric.HashID()
@@ -101,10 +108,12 @@ func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 
 func (d *Dedup) GetState() interface{} {
 	s := &serializers_influx.Serializer{}
+	d.cacheLock.Lock()
 	v := make([]telegraf.Metric, 0, len(d.cache))
 	for _, value := range d.cache {
 		v = append(v, value)
 	}
+	d.cacheLock.Unlock()
 	state, err := s.SerializeBatch(v)
 	if err != nil {
 		d.Log.Errorf("dedup processor failed to serialize metric batch: %v", err)
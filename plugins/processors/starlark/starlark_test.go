@@ -3711,6 +3711,74 @@ def apply(metric):
 	require.EqualValues(t, expectedState, actualState, "mismatch in state")
 }
 
+func TestStatePersistenceConcurrent(t *testing.T) {
+	source := `
+def apply(metric):
+  count = state.get("count", 0)
+  count += 1
+  state["count"] = count
+  state["last"] = metric.fields["value"]
+
+  metric.fields["count"] = count
+  return metric
+`
+	// Configure the plugin
+	plugin := &Starlark{
+		Common: common.Common{
+			StarlarkLoadFunc: testLoadFunc,
+			Source:           source,
+			Log:              testutil.Logger{},
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Checkpoint the state while metrics are processed like the persister does
+	started := make(chan struct{})
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		close(started)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				stateData, ok := plugin.GetState().([]byte)
+				if !ok {
+					t.Error("state is not a bytes array")
+					return
+				}
+				var state map[string]interface{}
+				if err := gob.NewDecoder(bytes.NewBuffer(stateData)).Decode(&state); err != nil {
+					t.Errorf("decoding state failed: %v", err)
+					return
+				}
+			}
+		}
+	}()
+
+	<-started
+	for i := range 1000 {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(0, 0))
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	close(done)
+	wg.Wait()
+
+	// Check the final state
+	var actualState map[string]interface{}
+	stateData, ok := plugin.GetState().([]byte)
+	require.True(t, ok, "state is not a bytes array")
+	require.NoError(t, gob.NewDecoder(bytes.NewBuffer(stateData)).Decode(&actualState))
+	require.Equal(t, map[string]interface{}{"count": int64(1000), "last": int64(999)}, actualState)
+}
+
 func TestUsePredefinedStateName(t *testing.T) {
 	source := `
 def apply(metric):
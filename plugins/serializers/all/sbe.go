@@ -0,0 +1,7 @@
+//go:build !custom || serializers || serializers.sbe
+
+package all
+
+import (
+	_ "github.com/influxdata/telegraf/plugins/serializers/sbe" // register plugin
+)
@@ -0,0 +1,174 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Schema metadata key containing the measurement name
+const measurementKey = "measurement"
+
+type Serializer struct {
+	TimestampColumn string `toml:"arrow_timestamp_column"`
+	Compression     string `toml:"arrow_compression"`
+
+	options []ipc.Option
+}
+
+// column describes the source of the values of a column
+type column struct {
+	name      string
+	tag       bool
+	timestamp bool
+}
+
+func (s *Serializer) Init() error {
+	switch s.Compression {
+	case "", "none":
+	case "lz4":
+		s.options = append(s.options, ipc.WithLZ4())
+	case "zstd":
+		s.options = append(s.options, ipc.WithZstd())
+	default:
+		return fmt.Errorf("invalid 'arrow_compression' %q", s.Compression)
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Group the metrics by measurement keeping the order of first occurrence
+	var names []string
+	groups := make(map[string][]telegraf.Metric)
+	for _, m := range metrics {
+		if _, found := groups[m.Name()]; !found {
+			names = append(names, m.Name())
+		}
+		groups[m.Name()] = append(groups[m.Name()], m)
+	}
+
+	// Write one stream with a single record batch per measurement
+	var buf bytes.Buffer
+	for _, name := range names {
+		if err := s.writeStream(&buf, name, groups[name]); err != nil {
+			return nil, fmt.Errorf("measurement %q: %w", name, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) writeStream(buf *bytes.Buffer, name string, metrics []telegraf.Metric) error {
+	schema, columns, err := s.createSchema(name, metrics)
+	if err != nil {
+		return err
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for idx, col := range columns {
+		for _, m := range metrics {
+			var value interface{}
+			switch {
+			case col.timestamp:
+				value = m.Time()
+			case col.tag:
+				if v, found := m.GetTag(col.name); found {
+					value = v
+				}
+			default:
+				value, _ = m.GetField(col.name)
+			}
+			if err := columnar.Append(builder.Field(idx), value); err != nil {
+				return fmt.Errorf("appending value of column %q failed: %w", col.name, err)
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	writer := ipc.NewWriter(buf, append(slices.Clone(s.options), ipc.WithSchema(schema))...)
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("writing record batch failed: %w", err)
+	}
+	return writer.Close()
+}
+
+// createSchema returns the schema with the timestamp column followed by the
+// tag and field columns in alphabetical order. Tags are dictionary-encoded
+// and the types of fields are widened if they differ between metrics.
+func (s *Serializer) createSchema(name string, metrics []telegraf.Metric) (*arrow.Schema, []column, error) {
+	tags := make(map[string]bool)
+	fields := make(map[string]arrow.DataType)
+	for _, m := range metrics {
+		for _, field := range m.FieldList() {
+			t, err := columnar.DataType(field.Value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("field %q: %w", field.Key, err)
+			}
+			if current, found := fields[field.Key]; found {
+				t = columnar.MergeTypes(current, t)
+			}
+			fields[field.Key] = t
+		}
+		for _, tag := range m.TagList() {
+			tags[tag.Key] = true
+		}
+	}
+
+	arrowFields := make([]arrow.Field, 0, 1+len(tags)+len(fields))
+	columns := make([]column, 0, cap(arrowFields))
+	if s.TimestampColumn != "" {
+		arrowFields = append(arrowFields, arrow.Field{Name: s.TimestampColumn, Type: arrow.FixedWidthTypes.Timestamp_ns})
+		columns = append(columns, column{name: s.TimestampColumn, timestamp: true})
+	}
+	// Fields take precedence over tags of the same name and the timestamp
+	// takes precedence over both
+	for _, key := range sortedKeys(tags) {
+		if _, found := fields[key]; found || key == s.TimestampColumn {
+			continue
+		}
+		arrowFields = append(arrowFields, arrow.Field{Name: key, Type: columnar.TagType, Nullable: true})
+		columns = append(columns, column{name: key, tag: true})
+	}
+	for _, key := range sortedKeys(fields) {
+		if key == s.TimestampColumn {
+			continue
+		}
+		arrowFields = append(arrowFields, arrow.Field{Name: key, Type: fields[key], Nullable: true})
+		columns = append(columns, column{name: key})
+	}
+
+	metadata := arrow.NewMetadata([]string{measurementKey}, []string{name})
+	return arrow.NewSchema(arrowFields, &metadata), columns, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func init() {
+	serializers.Add("arrow",
+		func() telegraf.Serializer {
+			return &Serializer{TimestampColumn: "timestamp"}
+		},
+	)
+}
@@ -0,0 +1,119 @@
+package arrow
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	parsers_arrow "github.com/influxdata/telegraf/plugins/parsers/arrow"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitInvalid(t *testing.T) {
+	serializer := &Serializer{Compression: "gzip"}
+	require.ErrorContains(t, serializer.Init(), `invalid 'arrow_compression' "gzip"`)
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	for _, compression := range []string{"none", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			serializer := &Serializer{TimestampColumn: "timestamp", Compression: compression}
+			require.NoError(t, serializer.Init())
+
+			metrics := []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_idle": 98.5, "active": true},
+					time.Unix(1700000000, 1),
+				),
+				metric.New(
+					"mem",
+					map[string]string{"host": "server01"},
+					map[string]interface{}{"used": uint64(1024), "state": "ok"},
+					time.Unix(1700000000, 2),
+				),
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server02"},
+					map[string]interface{}{"usage_idle": 50.0},
+					time.Unix(1700000000, 3),
+				),
+			}
+
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			parser := &parsers_arrow.Parser{TimestampColumn: "timestamp"}
+			require.NoError(t, parser.Init())
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+
+			// The metrics are grouped by measurement
+			expected := []telegraf.Metric{metrics[0], metrics[2], metrics[1]}
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestSerializeSchema(t *testing.T) {
+	serializer := &Serializer{TimestampColumn: "time"}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "value": "tag"},
+			map[string]interface{}{"value": int64(1), "count": int64(2), "mode": true, "time": "field"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"value": 1.5, "count": uint64(3), "mode": "user"},
+			time.Unix(1, 0),
+		),
+	}
+
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	reader, err := ipc.NewReader(bytes.NewReader(buf))
+	require.NoError(t, err)
+	defer reader.Release()
+
+	// Fields take precedence over tags and the timestamp over both
+	expected := []arrow.Field{
+		{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_ns},
+		{Name: "host", Type: columnar.TagType, Nullable: true},
+		{Name: "count", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "mode", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	}
+	schema := reader.Schema()
+	require.Len(t, schema.Fields(), len(expected))
+	for i, field := range schema.Fields() {
+		require.Equal(t, expected[i].Name, field.Name)
+		require.Truef(t, arrow.TypeEqual(expected[i].Type, field.Type), "column %q has type %s", field.Name, field.Type)
+		require.Equal(t, expected[i].Nullable, field.Nullable)
+	}
+	idx := schema.Metadata().FindKey("measurement")
+	require.GreaterOrEqual(t, idx, 0)
+	require.Equal(t, "cpu", schema.Metadata().Values()[idx])
+
+	require.True(t, reader.Next())
+	record := reader.Record()
+	require.Equal(t, int64(2), record.NumRows())
+	mode, err := columnar.Value(record.Column(3), 0)
+	require.NoError(t, err)
+	require.Equal(t, "true", mode)
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+}
@@ -0,0 +1,259 @@
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// If SchemaRegistry is set, the messages are prefixed with the magic byte and
+// the schema ID according to the Confluent Wire Format
+// (https://docs.confluent.io/platform/current/schema-registry/serdes-develop/index.html#wire-format).
+// Otherwise, the messages are plain Avro binary without schema information.
+
+type Serializer struct {
+	SchemaRegistry      string            `toml:"avro_schema_registry"`
+	CaCertPath          string            `toml:"avro_schema_registry_cert"`
+	AutoRegister        bool              `toml:"avro_auto_register_schemas"`
+	SubjectNameStrategy string            `toml:"avro_subject_name_strategy"`
+	Topic               string            `toml:"avro_topic"`
+	Schemas             map[string]string `toml:"avro_schemas"`
+	Namespace           string            `toml:"avro_namespace"`
+	MeasurementField    string            `toml:"avro_measurement_field"`
+	Timestamp           string            `toml:"avro_timestamp"`
+	TimestampFormat     string            `toml:"avro_timestamp_format"`
+	Log                 telegraf.Logger   `toml:"-"`
+
+	registry *schemaRegistry
+	records  map[string]*record
+	derived  map[string]*derivedSchema
+	reserved []string
+}
+
+func (s *Serializer) Init() error {
+	switch s.SubjectNameStrategy {
+	case "":
+		s.SubjectNameStrategy = "record_name"
+	case "record_name":
+	case "topic_name", "topic_record_name":
+		if s.SchemaRegistry != "" && s.Topic == "" {
+			return fmt.Errorf("'avro_topic' is required for subject name strategy %q", s.SubjectNameStrategy)
+		}
+	default:
+		return fmt.Errorf("invalid 'avro_subject_name_strategy' %q", s.SubjectNameStrategy)
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix_ns"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid 'avro_timestamp_format' %q", s.TimestampFormat)
+	}
+
+	if s.Namespace != "" && !namespacePattern.MatchString(s.Namespace) {
+		return fmt.Errorf("invalid 'avro_namespace' %q", s.Namespace)
+	}
+	if s.MeasurementField != "" && s.MeasurementField == s.Timestamp {
+		return errors.New("'avro_measurement_field' and 'avro_timestamp' must differ")
+	}
+	for _, name := range []string{s.MeasurementField, s.Timestamp} {
+		if name != "" {
+			s.reserved = append(s.reserved, name)
+			if sanitizeName(name) != name {
+				return fmt.Errorf("invalid field name %q", name)
+			}
+		}
+	}
+
+	s.records = make(map[string]*record, len(s.Schemas))
+	for measurement, schema := range s.Schemas {
+		r, err := parseRecord(schema)
+		if err != nil {
+			return fmt.Errorf("invalid schema for measurement %q: %w", measurement, err)
+		}
+		s.records[measurement] = r
+	}
+	s.derived = make(map[string]*derivedSchema)
+
+	if s.SchemaRegistry != "" {
+		registry, err := newSchemaRegistry(s.SchemaRegistry, s.CaCertPath, s.AutoRegister)
+		if err != nil {
+			return fmt.Errorf("error connecting to the schema registry %q: %w", s.SchemaRegistry, err)
+		}
+		s.registry = registry
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, metric)
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	buf := make([]byte, 0)
+	for _, m := range metrics {
+		var err error
+		buf, err = s.serialize(buf, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	r, values, err := s.recordFor(metric)
+	if err != nil {
+		return nil, fmt.Errorf("metric %q: %w", metric.Name(), err)
+	}
+
+	native := make(map[string]interface{}, len(r.fields))
+	for _, f := range r.fields {
+		var v interface{}
+		switch f.name {
+		case s.MeasurementField:
+			v = metric.Name()
+		case s.Timestamp:
+			v = s.timestamp(f, metric.Time())
+		default:
+			v = values[f.name]
+		}
+
+		if v == nil {
+			switch {
+			case f.hasDefault:
+				// The codec encodes the default value for missing fields
+			case f.nullable:
+				native[f.name] = nil
+			default:
+				return nil, fmt.Errorf("metric %q: missing value for field %q", metric.Name(), f.name)
+			}
+			continue
+		}
+		nv, err := f.native(v)
+		if err != nil {
+			return nil, fmt.Errorf("metric %q: field %q: %w", metric.Name(), f.name, err)
+		}
+		native[f.name] = nv
+	}
+
+	if s.registry != nil {
+		id, err := s.registry.getSchemaID(s.subject(r), r.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, 0)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(id))
+	}
+
+	buf, err = r.codec.BinaryFromNative(buf, native)
+	if err != nil {
+		return nil, fmt.Errorf("metric %q: %w", metric.Name(), err)
+	}
+	return buf, nil
+}
+
+// recordFor returns the record schema of the metric along with the values
+// of the metric named after the fields of the schema
+func (s *Serializer) recordFor(metric telegraf.Metric) (*record, map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(metric.TagList())+len(metric.FieldList()))
+
+	// Use the configured schema without renaming the values
+	if r, found := s.records[metric.Name()]; found {
+		for _, tag := range metric.TagList() {
+			values[tag.Key] = tag.Value
+		}
+		for _, field := range metric.FieldList() {
+			values[field.Key] = field.Value
+		}
+		return r, values, nil
+	}
+
+	// Derive the schema from the metric converting the names to valid Avro
+	// names
+	tags := make(map[string]string, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		name := sanitizeName(tag.Key)
+		if _, found := tags[name]; found {
+			return nil, nil, fmt.Errorf("tag %q conflicts with another tag after renaming", tag.Key)
+		}
+		tags[name] = tag.Value
+		values[name] = tag.Value
+	}
+	fields := make(map[string]interface{}, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		name := sanitizeName(field.Key)
+		if _, found := fields[name]; found {
+			return nil, nil, fmt.Errorf("field %q conflicts with another field after renaming", field.Key)
+		}
+		fields[name] = field.Value
+		values[name] = field.Value
+	}
+
+	d, found := s.derived[metric.Name()]
+	if !found {
+		d = newDerivedSchema(sanitizeName(metric.Name()))
+		s.derived[metric.Name()] = d
+	}
+	changed, err := d.update(tags, fields, s.reserved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if changed || d.record == nil {
+		r, err := d.build(s.Namespace, s.MeasurementField, s.Timestamp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("deriving schema failed: %w", err)
+		}
+		s.Log.Debugf("Derived schema for measurement %q: %s", metric.Name(), r.schema)
+		d.record = r
+	}
+
+	return d.record, values, nil
+}
+
+func (s *Serializer) timestamp(f *recordField, t time.Time) interface{} {
+	// Logical timestamp types are encoded by the codec
+	for _, typ := range f.types {
+		if strings.HasPrefix(typ, "long.timestamp-") {
+			return t
+		}
+	}
+
+	switch s.TimestampFormat {
+	case "unix":
+		return t.Unix()
+	case "unix_ms":
+		return t.UnixMilli()
+	case "unix_us":
+		return t.UnixMicro()
+	}
+	return t.UnixNano()
+}
+
+func (s *Serializer) subject(r *record) string {
+	switch s.SubjectNameStrategy {
+	case "topic_name":
+		return s.Topic + "-value"
+	case "topic_record_name":
+		return s.Topic + "-" + r.fullname
+	}
+	return r.fullname
+}
+
+func init() {
+	serializers.Add("avro",
+		func() telegraf.Serializer {
+			return &Serializer{
+				AutoRegister: true,
+				Timestamp:    "timestamp",
+			}
+		},
+	)
+}
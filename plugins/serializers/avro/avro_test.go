@@ -0,0 +1,383 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	parsers_avro "github.com/influxdata/telegraf/plugins/parsers/avro"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// registry mocks the Confluent Schema Registry API used by the serializer and
+// the parser
+type registry struct {
+	schemas  []string
+	subjects map[string][]int
+	sync.Mutex
+}
+
+func newRegistry() *registry {
+	return &registry{subjects: make(map[string][]int)}
+}
+
+func (r *registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == http.MethodGet && len(path) == 3 && path[0] == "schemas" && path[1] == "ids":
+		id, err := strconv.Atoi(path[2])
+		if err != nil || id < 1 || id > len(r.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+			return
+		}
+		//nolint:errchkjson // Ignore the error in tests
+		json.NewEncoder(w).Encode(map[string]interface{}{"schema": r.schemas[id-1]})
+	case req.Method == http.MethodPost && len(path) >= 2 && path[0] == "subjects":
+		var request registryRequest
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"error_code":42201,"message":"Invalid schema"}`)
+			return
+		}
+		subject := path[1]
+		for _, id := range r.subjects[subject] {
+			if r.schemas[id-1] == request.Schema {
+				fmt.Fprintf(w, `{"id":%d}`, id)
+				return
+			}
+		}
+		// Lookup of a schema not registered yet
+		if len(path) == 2 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+			return
+		}
+		r.schemas = append(r.schemas, request.Schema)
+		id := len(r.schemas)
+		r.subjects[subject] = append(r.subjects[subject], id)
+		fmt.Fprintf(w, `{"id":%d}`, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "invalid subject name strategy",
+			serializer: &Serializer{SubjectNameStrategy: "foo"},
+			expected:   `invalid 'avro_subject_name_strategy' "foo"`,
+		},
+		{
+			name: "missing topic",
+			serializer: &Serializer{
+				SchemaRegistry:      "http://localhost:8081",
+				SubjectNameStrategy: "topic_name",
+			},
+			expected: "'avro_topic' is required",
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{TimestampFormat: "RFC3339"},
+			expected:   `invalid 'avro_timestamp_format' "RFC3339"`,
+		},
+		{
+			name:       "invalid namespace",
+			serializer: &Serializer{Namespace: "com..example"},
+			expected:   `invalid 'avro_namespace' "com..example"`,
+		},
+		{
+			name:       "invalid timestamp name",
+			serializer: &Serializer{Timestamp: "@timestamp"},
+			expected:   `invalid field name "@timestamp"`,
+		},
+		{
+			name:       "invalid schema",
+			serializer: &Serializer{Schemas: map[string]string{"cpu": `{"type":"record"}`}},
+			expected:   `invalid schema for measurement "cpu"`,
+		},
+		{
+			name: "unsupported type",
+			serializer: &Serializer{
+				Schemas: map[string]string{
+					"cpu": `{"type":"record","name":"cpu","fields":[{"name":"values","type":{"type":"array","items":"long"}}]}`,
+				},
+			},
+			expected: `field "values": unsupported type "array"`,
+		},
+		{
+			name: "null type",
+			serializer: &Serializer{
+				Schemas: map[string]string{
+					"cpu": `{"type":"record","name":"cpu","fields":[{"name":"value","type":"null"}]}`,
+				},
+			},
+			expected: `field "value": type must not only be null`,
+		},
+		{
+			name: "null union",
+			serializer: &Serializer{
+				Schemas: map[string]string{
+					"cpu": `{"type":"record","name":"cpu","fields":[{"name":"value","type":["null"]}]}`,
+				},
+			},
+			expected: `field "value": type must not only be null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerializeDerivedSchema(t *testing.T) {
+	r := newRegistry()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		AutoRegister:   true,
+		Namespace:      "telegraf",
+		Timestamp:      "timestamp",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "cpu-id": "cpu0"},
+			map[string]interface{}{"usage_idle": int64(98), "active": true},
+			time.Unix(1700000000, 0),
+		),
+		// Widen the integer to a float and add a field
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "cpu-id": "cpu1"},
+			map[string]interface{}{"usage_idle": 97.5, "usage_user": uint64(2)},
+			time.Unix(1700000010, 0),
+		),
+		// Metric matching the current schema
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server02", "cpu-id": "cpu0"},
+			map[string]interface{}{"usage_idle": int64(99)},
+			time.Unix(1700000020, 0),
+		),
+	}
+
+	parser := &parsers_avro.Parser{
+		SchemaRegistry:  server.URL,
+		Tags:            []string{"host", "cpu_id"},
+		Timestamp:       "timestamp",
+		TimestampFormat: "unix_ns",
+		UnionMode:       "nullable",
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"telegraf.cpu",
+			map[string]string{"host": "server01", "cpu_id": "cpu0"},
+			map[string]interface{}{"usage_idle": int64(98), "active": true, "timestamp": int64(1700000000000000000)},
+			time.Unix(1700000000, 0),
+		),
+		metric.New(
+			"telegraf.cpu",
+			map[string]string{"host": "server01", "cpu_id": "cpu1"},
+			map[string]interface{}{"usage_idle": 97.5, "usage_user": int64(2), "timestamp": int64(1700000010000000000)},
+			time.Unix(1700000010, 0),
+		),
+		metric.New(
+			"telegraf.cpu",
+			map[string]string{"host": "server02", "cpu_id": "cpu0"},
+			map[string]interface{}{"usage_idle": float64(99), "timestamp": int64(1700000020000000000)},
+			time.Unix(1700000020, 0),
+		),
+	}
+
+	actual := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		buf, err := serializer.Serialize(m)
+		require.NoError(t, err)
+		require.Equal(t, byte(0), buf[0])
+
+		parsed, err := parser.Parse(buf)
+		require.NoError(t, err)
+		actual = append(actual, parsed...)
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// The widened schema is registered as new version of the subject
+	require.Len(t, r.schemas, 2)
+	require.Equal(t, []int{1, 2}, r.subjects["telegraf.cpu"])
+}
+
+func TestSerializeConfiguredSchema(t *testing.T) {
+	schema := `{
+		"type": "record",
+		"name": "Sensor",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "name", "type": "string"},
+			{"name": "state", "type": {"type": "enum", "name": "State", "symbols": ["IDLE", "RUNNING"]}},
+			{"name": "value", "type": "float"},
+			{"name": "count", "type": ["null", "int"], "default": null},
+			{"name": "unit", "type": "string", "default": "C"}
+		]
+	}`
+	serializer := &Serializer{
+		Schemas:          map[string]string{"sensor": schema},
+		MeasurementField: "name",
+		Timestamp:        "ts",
+		Log:              testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"sensor",
+		map[string]string{"state": "RUNNING"},
+		map[string]interface{}{"value": 21.5, "ignored": "x"},
+		time.UnixMilli(1700000000123),
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	codec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+	native, remaining, err := codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+
+	expected := map[string]interface{}{
+		"ts":    time.UnixMilli(1700000000123).UTC(),
+		"name":  "sensor",
+		"state": "RUNNING",
+		"value": float32(21.5),
+		"count": nil,
+		"unit":  "C",
+	}
+	require.Equal(t, expected, native)
+
+	// Missing required values result in an error
+	m = metric.New("sensor", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	_, err = serializer.Serialize(m)
+	require.ErrorContains(t, err, `missing value for field "state"`)
+
+	// Invalid enum symbols are rejected by the codec
+	m = metric.New("sensor", map[string]string{"state": "FAULT"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	_, err = serializer.Serialize(m)
+	require.ErrorContains(t, err, "FAULT")
+}
+
+func TestSerializeBatchPlain(t *testing.T) {
+	serializer := &Serializer{Timestamp: "timestamp", Log: testutil.Logger{}}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": int64(1)}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": int64(2)}, time.Unix(2, 0)),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	codec, err := goavro.NewCodec(serializer.derived["mem"].record.schema)
+	require.NoError(t, err)
+	for i := range metrics {
+		var native interface{}
+		native, buf, err = codec.NativeFromBinary(buf)
+		require.NoError(t, err)
+		expected := map[string]interface{}{
+			"free":      goavro.Union("long", int64(i+1)),
+			"timestamp": int64(i+1) * int64(time.Second),
+		}
+		require.Equal(t, expected, native)
+	}
+	require.Empty(t, buf)
+}
+
+func TestSubjectNameStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		expected string
+	}{
+		{strategy: "record_name", expected: "telegraf.cpu"},
+		{strategy: "topic_name", expected: "metrics-value"},
+		{strategy: "topic_record_name", expected: "metrics-telegraf.cpu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			r := newRegistry()
+			server := httptest.NewServer(r)
+			defer server.Close()
+
+			serializer := &Serializer{
+				SchemaRegistry:      server.URL,
+				AutoRegister:        true,
+				SubjectNameStrategy: tt.strategy,
+				Topic:               "metrics",
+				Namespace:           "telegraf",
+				Log:                 testutil.Logger{},
+			}
+			require.NoError(t, serializer.Init())
+
+			m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+			buf, err := serializer.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), binary.BigEndian.Uint32(buf[1:5]))
+			require.Contains(t, r.subjects, tt.expected)
+		})
+	}
+}
+
+func TestSchemaLookup(t *testing.T) {
+	r := newRegistry()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	// The schema is not registered and cannot be found
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `getting schema ID for subject "cpu" failed`)
+	require.ErrorContains(t, err, "Schema not found")
+
+	// Register the schema and retry
+	r.schemas = append(r.schemas, "{}", serializer.derived["cpu"].record.schema)
+	r.subjects["cpu"] = []int{2}
+
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), binary.BigEndian.Uint32(buf[1:5]))
+}
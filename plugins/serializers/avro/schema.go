@@ -0,0 +1,361 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	namespacePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// record is an Avro record schema with the information required to convert
+// metrics to the native representation of the codec
+type record struct {
+	schema   string
+	fullname string
+	codec    *goavro.Codec
+	fields   []*recordField
+}
+
+type recordField struct {
+	name       string
+	types      []string
+	union      bool
+	nullable   bool
+	hasDefault bool
+}
+
+type schemaField struct {
+	Name    string          `json:"name"`
+	Type    json.RawMessage `json:"type"`
+	Default json.RawMessage `json:"default"`
+}
+
+type schemaType struct {
+	Type        string        `json:"type"`
+	Name        string        `json:"name"`
+	Namespace   string        `json:"namespace"`
+	LogicalType string        `json:"logicalType"`
+	Fields      []schemaField `json:"fields"`
+}
+
+func parseRecord(schema string) (*record, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var def schemaType
+	if err := json.Unmarshal([]byte(schema), &def); err != nil {
+		return nil, err
+	}
+	if def.Type != "record" {
+		return nil, fmt.Errorf("schema must be a record but is %q", def.Type)
+	}
+
+	namespace := def.Namespace
+	fullname := def.Name
+	if idx := strings.LastIndex(def.Name, "."); idx >= 0 {
+		namespace = def.Name[:idx]
+	} else if namespace != "" {
+		fullname = namespace + "." + def.Name
+	}
+
+	r := &record{
+		schema:   schema,
+		fullname: fullname,
+		codec:    codec,
+		fields:   make([]*recordField, 0, len(def.Fields)),
+	}
+	named := make(map[string]bool)
+	for _, f := range def.Fields {
+		field := &recordField{
+			name:       f.Name,
+			hasDefault: len(f.Default) > 0,
+		}
+
+		var members []json.RawMessage
+		if err := json.Unmarshal(f.Type, &members); err == nil {
+			field.union = true
+		} else {
+			members = []json.RawMessage{f.Type}
+		}
+		for _, raw := range members {
+			t, err := resolveType(raw, namespace, named)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name, err)
+			}
+			if t == "null" {
+				field.nullable = true
+				continue
+			}
+			field.types = append(field.types, t)
+		}
+		if len(field.types) == 0 {
+			return nil, fmt.Errorf("field %q: type must not only be null", f.Name)
+		}
+		r.fields = append(r.fields, field)
+	}
+
+	return r, nil
+}
+
+// resolveType returns the name of the type as used by the codec for unions
+func resolveType(raw json.RawMessage, namespace string, named map[string]bool) (string, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		switch name {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return name, nil
+		}
+		fullname := name
+		if !strings.Contains(name, ".") && namespace != "" {
+			fullname = namespace + "." + name
+		}
+		if named[fullname] {
+			return fullname, nil
+		}
+		return "", fmt.Errorf("unsupported type %q", name)
+	}
+
+	var def schemaType
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return "", fmt.Errorf("invalid type %s", string(raw))
+	}
+	switch def.Type {
+	case "enum":
+		fullname := def.Name
+		if !strings.Contains(def.Name, ".") {
+			if def.Namespace != "" {
+				fullname = def.Namespace + "." + def.Name
+			} else if namespace != "" {
+				fullname = namespace + "." + def.Name
+			}
+		}
+		named[fullname] = true
+		return fullname, nil
+	case "long":
+		switch def.LogicalType {
+		case "":
+			return def.Type, nil
+		case "timestamp-millis", "timestamp-micros":
+			return def.Type + "." + def.LogicalType, nil
+		}
+	case "boolean", "int", "float", "double", "bytes", "string":
+		if def.LogicalType == "" {
+			return def.Type, nil
+		}
+	default:
+		return "", fmt.Errorf("unsupported type %q", def.Type)
+	}
+	return "", fmt.Errorf("unsupported logical type %q of %q", def.LogicalType, def.Type)
+}
+
+// native converts the value to the native representation of the field
+func (f *recordField) native(v interface{}) (interface{}, error) {
+	if !f.union {
+		return convert(f.types[0], v)
+	}
+
+	// Prefer the member matching the type of the value before trying to
+	// convert the value to any of the members
+	for _, t := range f.types {
+		if matchesType(t, v) {
+			nv, err := convert(t, v)
+			if err != nil {
+				return nil, err
+			}
+			return goavro.Union(t, nv), nil
+		}
+	}
+	for _, t := range f.types {
+		if nv, err := convert(t, v); err == nil {
+			return goavro.Union(t, nv), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %v (%T) to any of %v", v, v, f.types)
+}
+
+func matchesType(t string, v interface{}) bool {
+	switch v.(type) {
+	case bool:
+		return t == "boolean"
+	case int64, uint64:
+		return t == "long" || t == "int"
+	case float64:
+		return t == "double" || t == "float"
+	case string:
+		return t == "string"
+	case time.Time:
+		return strings.HasPrefix(t, "long.timestamp-")
+	}
+	return false
+}
+
+func convert(t string, v interface{}) (interface{}, error) {
+	switch t {
+	case "boolean":
+		return internal.ToBool(v)
+	case "int":
+		return internal.ToInt32(v)
+	case "long":
+		return internal.ToInt64(v)
+	case "float":
+		return internal.ToFloat32(v)
+	case "double":
+		return internal.ToFloat64(v)
+	case "bytes":
+		s, err := internal.ToString(v)
+		return []byte(s), err
+	case "long.timestamp-millis", "long.timestamp-micros":
+		if ts, ok := v.(time.Time); ok {
+			return ts, nil
+		}
+		return internal.ToInt64(v)
+	}
+	// Strings and enum symbols
+	return internal.ToString(v)
+}
+
+// derivedSchema is the schema of a measurement derived from the metrics
+// serialized so far. The schema only grows to stay compatible with the
+// previous versions of the schema.
+type derivedSchema struct {
+	name   string
+	tags   map[string]bool
+	fields map[string]string
+	record *record
+}
+
+func newDerivedSchema(name string) *derivedSchema {
+	return &derivedSchema{
+		name:   name,
+		tags:   make(map[string]bool),
+		fields: make(map[string]string),
+	}
+}
+
+// update adds the tags and fields of the metric to the schema and widens the
+// field types if necessary. The function returns true if the schema changed.
+func (d *derivedSchema) update(tags map[string]string, fields map[string]interface{}, reserved []string) (bool, error) {
+	var changed bool
+	for k := range tags {
+		if slices.Contains(reserved, k) {
+			return false, fmt.Errorf("tag %q conflicts with a reserved name", k)
+		}
+		if _, found := d.fields[k]; found {
+			return false, fmt.Errorf("tag %q conflicts with a field", k)
+		}
+		if !d.tags[k] {
+			d.tags[k] = true
+			changed = true
+		}
+	}
+	for k, v := range fields {
+		if slices.Contains(reserved, k) {
+			return false, fmt.Errorf("field %q conflicts with a reserved name", k)
+		}
+		if d.tags[k] {
+			return false, fmt.Errorf("field %q conflicts with a tag", k)
+		}
+		t, err := fieldType(v)
+		if err != nil {
+			return false, fmt.Errorf("field %q: %w", k, err)
+		}
+		current, found := d.fields[k]
+		switch {
+		case !found:
+			d.fields[k] = t
+			changed = true
+		case current == "long" && t == "double":
+			// Integer fields turning into floats are widened as Avro allows
+			// to promote long to double. For all other type changes the
+			// value is converted to the existing type.
+			d.fields[k] = t
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+func (d *derivedSchema) build(namespace, measurementField, timestamp string) (*record, error) {
+	type field struct {
+		Name    string      `json:"name"`
+		Type    interface{} `json:"type"`
+		Default interface{} `json:"default"`
+	}
+	type schema struct {
+		Type      string  `json:"type"`
+		Name      string  `json:"name"`
+		Namespace string  `json:"namespace,omitempty"`
+		Fields    []field `json:"fields"`
+	}
+
+	def := schema{
+		Type:      "record",
+		Name:      d.name,
+		Namespace: namespace,
+		Fields:    make([]field, 0, 2+len(d.tags)+len(d.fields)),
+	}
+	if measurementField != "" {
+		def.Fields = append(def.Fields, field{Name: measurementField, Type: "string", Default: ""})
+	}
+	if timestamp != "" {
+		def.Fields = append(def.Fields, field{Name: timestamp, Type: "long", Default: 0})
+	}
+	for _, k := range sortedKeys(d.tags) {
+		def.Fields = append(def.Fields, field{Name: k, Type: []string{"null", "string"}})
+	}
+	for _, k := range sortedKeys(d.fields) {
+		def.Fields = append(def.Fields, field{Name: k, Type: []string{"null", d.fields[k]}})
+	}
+
+	buf, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecord(string(buf))
+}
+
+func fieldType(v interface{}) (string, error) {
+	switch v.(type) {
+	case bool:
+		return "boolean", nil
+	case int64, uint64:
+		return "long", nil
+	case float64:
+		return "double", nil
+	case string:
+		return "string", nil
+	}
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// sanitizeName converts the name to a valid Avro name by replacing all
+// invalid characters by underscores
+func sanitizeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,146 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	subjectVersions = "%s/subjects/%s/versions"
+	subjectLookup   = "%s/subjects/%s"
+
+	contentType = "application/vnd.schemaregistry.v1+json"
+)
+
+type schemaRegistry struct {
+	url      string
+	username string
+	password string
+	register bool
+	cache    map[string]int
+	client   *http.Client
+	mu       sync.Mutex
+}
+
+type registryRequest struct {
+	Schema string `json:"schema"`
+}
+
+type registryResponse struct {
+	ID        int    `json:"id"`
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func newSchemaRegistry(addr, caCertPath string, register bool) (*schemaRegistry, error) {
+	var tlsCfg *tls.Config
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsCfg = &tls.Config{
+			RootCAs: caCertPool,
+		}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			MaxIdleConns:    10,
+			IdleConnTimeout: 90 * time.Second,
+		},
+		Timeout: 30 * time.Second,
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry URL failed: %w", err)
+	}
+
+	var username, password string
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	registry := &schemaRegistry{
+		url:      u.String(),
+		username: username,
+		password: password,
+		register: register,
+		cache:    make(map[string]int),
+		client:   client,
+	}
+
+	return registry, nil
+}
+
+// getSchemaID returns the ID of the schema for the given subject. Depending on
+// the configuration the schema is registered or looked up in the registry.
+// IDs are cached so the registry is only queried once per subject and schema.
+func (sr *schemaRegistry) getSchemaID(subject, schema string) (int, error) {
+	key := subject + "\x00" + schema
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if id, found := sr.cache[key]; found {
+		return id, nil
+	}
+
+	format := subjectLookup
+	if sr.register {
+		format = subjectVersions
+	}
+	id, err := sr.query(fmt.Sprintf(format, sr.url, url.PathEscape(subject)), schema)
+	if err != nil {
+		return 0, fmt.Errorf("getting schema ID for subject %q failed: %w", subject, err)
+	}
+	sr.cache[key] = id
+
+	return id, nil
+}
+
+func (sr *schemaRegistry) query(addr, schema string) (int, error) {
+	body, err := json.Marshal(&registryRequest{Schema: schema})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	if sr.username != "" {
+		req.SetBasicAuth(sr.username, sr.password)
+	}
+
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var response registryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("decoding response with status %q failed: %w", resp.Status, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("registry responded with %q: %s (error code %d)", resp.Status, response.Message, response.ErrorCode)
+	}
+
+	return response.ID, nil
+}
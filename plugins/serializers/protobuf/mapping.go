@@ -0,0 +1,176 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const timestampMessage = "google.protobuf.Timestamp"
+
+// fieldPath is a resolved dot-separated path to a field of a message. All
+// elements except the last are singular message fields.
+type fieldPath struct {
+	path   string
+	fields []protoreflect.FieldDescriptor
+}
+
+func resolvePath(desc protoreflect.MessageDescriptor, path string) (*fieldPath, error) {
+	if path == "" {
+		return nil, errors.New("empty path")
+	}
+
+	parts := strings.Split(path, ".")
+	fp := &fieldPath{
+		path:   path,
+		fields: make([]protoreflect.FieldDescriptor, 0, len(parts)),
+	}
+	for i, name := range parts {
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("message %q has no field %q", desc.FullName(), name)
+		}
+		fp.fields = append(fp.fields, fd)
+		if i == len(parts)-1 {
+			break
+		}
+		if fd.Kind() != protoreflect.MessageKind || fd.Cardinality() == protoreflect.Repeated {
+			return nil, fmt.Errorf("field %q of path %q is not a singular message", name, path)
+		}
+		desc = fd.Message()
+	}
+
+	return fp, nil
+}
+
+func (fp *fieldPath) last() protoreflect.FieldDescriptor {
+	return fp.fields[len(fp.fields)-1]
+}
+
+// parent returns the message containing the last field of the path creating
+// intermediate messages if necessary
+func (fp *fieldPath) parent(msg protoreflect.Message) protoreflect.Message {
+	for _, fd := range fp.fields[:len(fp.fields)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	return msg
+}
+
+// checkScalar returns an error if the last field of the path cannot hold a
+// tag or field value
+func (fp *fieldPath) checkScalar() error {
+	fd := fp.last()
+	if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return fmt.Errorf("field %q is not a scalar field", fp.path)
+	}
+	return nil
+}
+
+// set sets the last field of the path to the value or appends the value if
+// the field is repeated
+func (fp *fieldPath) set(msg protoreflect.Message, v interface{}) error {
+	fd := fp.last()
+	value, err := convert(fd, v)
+	if err != nil {
+		return fmt.Errorf("field %q: %w", fp.path, err)
+	}
+
+	parent := fp.parent(msg)
+	if fd.Cardinality() == protoreflect.Repeated {
+		parent.Mutable(fd).List().Append(value)
+	} else {
+		parent.Set(fd, value)
+	}
+	return nil
+}
+
+// setMapEntry adds the key-value pair to the map field of the path
+func (fp *fieldPath) setMapEntry(msg protoreflect.Message, key string, v interface{}) error {
+	fd := fp.last()
+	value, err := convert(fd.MapValue(), v)
+	if err != nil {
+		return fmt.Errorf("field %q key %q: %w", fp.path, key, err)
+	}
+	fp.parent(msg).Mutable(fd).Map().Set(protoreflect.ValueOfString(key).MapKey(), value)
+	return nil
+}
+
+// setTimestamp sets the last field of the path to the timestamp either as
+// google.protobuf.Timestamp message or as number in the given format
+func (fp *fieldPath) setTimestamp(msg protoreflect.Message, t time.Time, format string) error {
+	fd := fp.last()
+	if fd.Kind() == protoreflect.MessageKind {
+		parent := fp.parent(msg)
+		ts := parent.NewField(fd).Message()
+		ts.Set(fd.Message().Fields().ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		ts.Set(fd.Message().Fields().ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+		parent.Set(fd, protoreflect.ValueOfMessage(ts))
+		return nil
+	}
+
+	var v int64
+	switch format {
+	case "unix":
+		v = t.Unix()
+	case "unix_ms":
+		v = t.UnixMilli()
+	case "unix_us":
+		v = t.UnixMicro()
+	default:
+		v = t.UnixNano()
+	}
+	return fp.set(msg, v)
+}
+
+func convert(fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := internal.ToBool(v)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := internal.ToInt32(v)
+		return protoreflect.ValueOfInt32(i), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := internal.ToInt64(v)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := internal.ToUint32(v)
+		return protoreflect.ValueOfUint32(u), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := internal.ToUint64(v)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := internal.ToFloat32(v)
+		return protoreflect.ValueOfFloat32(f), err
+	case protoreflect.DoubleKind:
+		f, err := internal.ToFloat64(v)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		s, err := internal.ToString(v)
+		return protoreflect.ValueOfString(s), err
+	case protoreflect.BytesKind:
+		s, err := internal.ToString(v)
+		return protoreflect.ValueOfBytes([]byte(s)), err
+	case protoreflect.EnumKind:
+		// Accept the name of the enum value or its number
+		if s, ok := v.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		n, err := internal.ToInt32(v)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid value %v for enum %q", v, fd.Enum().FullName())
+		}
+		if fd.Enum().Values().ByNumber(protoreflect.EnumNumber(n)) == nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid value %v for enum %q", v, fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %q", fd.Kind())
+}
@@ -0,0 +1,287 @@
+package protobuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+type Serializer struct {
+	Files           []string          `toml:"protobuf_files"`
+	ImportPaths     []string          `toml:"protobuf_import_paths"`
+	MessageType     string            `toml:"protobuf_type"`
+	BatchField      string            `toml:"protobuf_batch_field"`
+	LengthDelimited bool              `toml:"protobuf_length_delimited"`
+	Measurement     string            `toml:"protobuf_measurement"`
+	Timestamp       string            `toml:"protobuf_timestamp"`
+	TimestampFormat string            `toml:"protobuf_timestamp_format"`
+	Tags            map[string]string `toml:"protobuf_tags"`
+	Fields          map[string]string `toml:"protobuf_fields"`
+	TagsMap         string            `toml:"protobuf_tags_map"`
+	FieldsMap       string            `toml:"protobuf_fields_map"`
+	Log             telegraf.Logger   `toml:"-"`
+
+	msgDesc     protoreflect.MessageDescriptor
+	elemDesc    protoreflect.MessageDescriptor
+	batch       *fieldPath
+	measurement *fieldPath
+	timestamp   *fieldPath
+	tags        map[string]*fieldPath
+	fields      map[string]*fieldPath
+	tagsMap     *fieldPath
+	fieldsMap   *fieldPath
+}
+
+func (s *Serializer) Init() error {
+	if len(s.Files) == 0 {
+		return errors.New("'protobuf_files' is required")
+	}
+	if s.MessageType == "" {
+		return errors.New("'protobuf_type' is required")
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix_ns"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid 'protobuf_timestamp_format' %q", s.TimestampFormat)
+	}
+
+	// Load the message type from the given protocol-buffer definition
+	ctx := context.Background()
+	resolver := &protocompile.SourceResolver{ImportPaths: s.ImportPaths}
+	compiler := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(resolver),
+	}
+	files, err := compiler.Compile(ctx, s.Files...)
+	if err != nil {
+		return fmt.Errorf("parsing protocol-buffer definition failed: %w", err)
+	}
+
+	var registry protoregistry.Files
+	for _, f := range files {
+		if err := registry.RegisterFile(f); err != nil {
+			return fmt.Errorf("adding file %q to registry failed: %w", f.Path(), err)
+		}
+	}
+	descriptor, err := registry.FindDescriptorByName(protoreflect.FullName(s.MessageType))
+	if err != nil {
+		return fmt.Errorf("finding message type %q failed: %w", s.MessageType, err)
+	}
+	msgDesc, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return fmt.Errorf("%q is not a message descriptor (%T)", s.MessageType, descriptor)
+	}
+	s.msgDesc = msgDesc
+	s.elemDesc = msgDesc
+
+	// Metrics are added as elements of the batch field if any
+	if s.BatchField != "" {
+		s.batch, err = resolvePath(msgDesc, s.BatchField)
+		if err != nil {
+			return fmt.Errorf("invalid 'protobuf_batch_field': %w", err)
+		}
+		fd := s.batch.last()
+		if fd.Kind() != protoreflect.MessageKind || !fd.IsList() {
+			return fmt.Errorf("batch field %q is not a repeated message", s.BatchField)
+		}
+		s.elemDesc = fd.Message()
+	}
+
+	// Resolve the paths relative to the message of a metric
+	if s.Measurement != "" {
+		s.measurement, err = resolvePath(s.elemDesc, s.Measurement)
+		if err != nil {
+			return fmt.Errorf("invalid 'protobuf_measurement': %w", err)
+		}
+		if err := s.measurement.checkScalar(); err != nil {
+			return fmt.Errorf("invalid 'protobuf_measurement': %w", err)
+		}
+	}
+
+	if s.Timestamp != "" {
+		s.timestamp, err = resolvePath(s.elemDesc, s.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid 'protobuf_timestamp': %w", err)
+		}
+		fd := s.timestamp.last()
+		switch fd.Kind() {
+		case protoreflect.MessageKind:
+			if fd.Message().FullName() != timestampMessage || fd.IsList() {
+				return fmt.Errorf("timestamp field %q must be a number or %s", s.Timestamp, timestampMessage)
+			}
+		case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+			protoreflect.Uint64Kind, protoreflect.Fixed64Kind, protoreflect.DoubleKind:
+		default:
+			return fmt.Errorf("timestamp field %q must be a 64-bit number or %s", s.Timestamp, timestampMessage)
+		}
+	}
+
+	s.tags = make(map[string]*fieldPath, len(s.Tags))
+	for key, path := range s.Tags {
+		fp, err := resolvePath(s.elemDesc, path)
+		if err != nil {
+			return fmt.Errorf("invalid path for tag %q: %w", key, err)
+		}
+		if err := fp.checkScalar(); err != nil {
+			return fmt.Errorf("invalid path for tag %q: %w", key, err)
+		}
+		s.tags[key] = fp
+	}
+
+	s.fields = make(map[string]*fieldPath, len(s.Fields))
+	for key, path := range s.Fields {
+		fp, err := resolvePath(s.elemDesc, path)
+		if err != nil {
+			return fmt.Errorf("invalid path for field %q: %w", key, err)
+		}
+		if err := fp.checkScalar(); err != nil {
+			return fmt.Errorf("invalid path for field %q: %w", key, err)
+		}
+		s.fields[key] = fp
+	}
+
+	if s.TagsMap != "" {
+		if s.tagsMap, err = resolveMap(s.elemDesc, s.TagsMap); err != nil {
+			return fmt.Errorf("invalid 'protobuf_tags_map': %w", err)
+		}
+	}
+	if s.FieldsMap != "" {
+		if s.fieldsMap, err = resolveMap(s.elemDesc, s.FieldsMap); err != nil {
+			return fmt.Errorf("invalid 'protobuf_fields_map': %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Put all metrics into the batch field of a single message
+	if s.batch != nil {
+		msg := dynamicpb.NewMessage(s.msgDesc)
+		list := s.batch.parent(msg).Mutable(s.batch.last()).List()
+		for _, m := range metrics {
+			elem := list.NewElement()
+			if err := s.fill(elem.Message(), m); err != nil {
+				return nil, err
+			}
+			list.Append(elem)
+		}
+		return s.marshal(nil, msg)
+	}
+
+	// Otherwise, concatenate the messages which requires framing to be able to
+	// separate them again
+	if len(metrics) > 1 && !s.LengthDelimited {
+		return nil, errors.New("serializing multiple metrics requires 'protobuf_batch_field' or 'protobuf_length_delimited'")
+	}
+	buf := make([]byte, 0)
+	for _, m := range metrics {
+		msg := dynamicpb.NewMessage(s.msgDesc)
+		if err := s.fill(msg, m); err != nil {
+			return nil, err
+		}
+		var err error
+		if buf, err = s.marshal(buf, msg); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) marshal(buf []byte, msg proto.Message) ([]byte, error) {
+	opts := proto.MarshalOptions{Deterministic: true}
+	if s.LengthDelimited {
+		buf = protowire.AppendVarint(buf, uint64(opts.Size(msg)))
+	}
+	return opts.MarshalAppend(buf, msg)
+}
+
+// fill sets the fields of the message from the metric
+func (s *Serializer) fill(msg protoreflect.Message, metric telegraf.Metric) error {
+	if s.measurement != nil {
+		if err := s.measurement.set(msg, metric.Name()); err != nil {
+			return fmt.Errorf("metric %q: %w", metric.Name(), err)
+		}
+	}
+	if s.timestamp != nil {
+		if err := s.timestamp.setTimestamp(msg, metric.Time(), s.TimestampFormat); err != nil {
+			return fmt.Errorf("metric %q: %w", metric.Name(), err)
+		}
+	}
+
+	for _, tag := range metric.TagList() {
+		if err := s.setValue(msg, tag.Key, tag.Value, s.tags, s.tagsMap); err != nil {
+			return fmt.Errorf("metric %q tag %q: %w", metric.Name(), tag.Key, err)
+		}
+	}
+	for _, field := range metric.FieldList() {
+		if err := s.setValue(msg, field.Key, field.Value, s.fields, s.fieldsMap); err != nil {
+			return fmt.Errorf("metric %q field %q: %w", metric.Name(), field.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// setValue sets the value in the message field mapped explicitly, the
+// top-level field of the same name or the map field in this order. Values
+// without a target are ignored.
+func (s *Serializer) setValue(msg protoreflect.Message, key string, value interface{}, mapping map[string]*fieldPath, m *fieldPath) error {
+	if fp, found := mapping[key]; found {
+		return fp.set(msg, value)
+	}
+
+	if fd := s.elemDesc.Fields().ByName(protoreflect.Name(key)); fd != nil {
+		fp := &fieldPath{path: key, fields: []protoreflect.FieldDescriptor{fd}}
+		if err := fp.checkScalar(); err == nil {
+			return fp.set(msg, value)
+		}
+	}
+
+	if m != nil {
+		return m.setMapEntry(msg, key, value)
+	}
+
+	s.Log.Tracef("Ignoring %q without message field", key)
+	return nil
+}
+
+func resolveMap(desc protoreflect.MessageDescriptor, path string) (*fieldPath, error) {
+	fp, err := resolvePath(desc, path)
+	if err != nil {
+		return nil, err
+	}
+	fd := fp.last()
+	if !fd.IsMap() || fd.MapKey().Kind() != protoreflect.StringKind {
+		return nil, fmt.Errorf("field %q is not a map with string keys", path)
+	}
+	switch fd.MapValue().Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return nil, fmt.Errorf("field %q is not a map with scalar values", path)
+	}
+	return fp, nil
+}
+
+func init() {
+	serializers.Add("protobuf",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,274 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var protoFiles = []string{"testdata/metrics.proto"}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "missing files",
+			serializer: &Serializer{MessageType: "telegraf.test.Metric"},
+			expected:   "'protobuf_files' is required",
+		},
+		{
+			name:       "missing type",
+			serializer: &Serializer{Files: protoFiles},
+			expected:   "'protobuf_type' is required",
+		},
+		{
+			name:       "unknown type",
+			serializer: &Serializer{Files: protoFiles, MessageType: "telegraf.test.Unknown"},
+			expected:   `finding message type "telegraf.test.Unknown" failed`,
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{Files: protoFiles, MessageType: "telegraf.test.Metric", TimestampFormat: "RFC3339"},
+			expected:   `invalid 'protobuf_timestamp_format' "RFC3339"`,
+		},
+		{
+			name:       "batch field not repeated",
+			serializer: &Serializer{Files: protoFiles, MessageType: "telegraf.test.Metric", BatchField: "source"},
+			expected:   `batch field "source" is not a repeated message`,
+		},
+		{
+			name: "unknown field",
+			serializer: &Serializer{
+				Files:       protoFiles,
+				MessageType: "telegraf.test.Metric",
+				Tags:        map[string]string{"host": "source.hostname"},
+			},
+			expected: `invalid path for tag "host": message "telegraf.test.Source" has no field "hostname"`,
+		},
+		{
+			name: "path through scalar",
+			serializer: &Serializer{
+				Files:       protoFiles,
+				MessageType: "telegraf.test.Metric",
+				Fields:      map[string]string{"value": "name.value"},
+			},
+			expected: `field "name" of path "name.value" is not a singular message`,
+		},
+		{
+			name: "message as value",
+			serializer: &Serializer{
+				Files:       protoFiles,
+				MessageType: "telegraf.test.Metric",
+				Tags:        map[string]string{"host": "source"},
+			},
+			expected: `field "source" is not a scalar field`,
+		},
+		{
+			name: "invalid timestamp field",
+			serializer: &Serializer{
+				Files:       protoFiles,
+				MessageType: "telegraf.test.Metric",
+				Timestamp:   "name",
+			},
+			expected: `timestamp field "name" must be a 64-bit number`,
+		},
+		{
+			name: "invalid tags map",
+			serializer: &Serializer{
+				Files:       protoFiles,
+				MessageType: "telegraf.test.Metric",
+				TagsMap:     "flags",
+			},
+			expected: `field "flags" is not a map with string keys`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	serializer := &Serializer{
+		Files:       protoFiles,
+		MessageType: "telegraf.test.Metric",
+		Measurement: "name",
+		Timestamp:   "time",
+		Tags: map[string]string{
+			"host":   "source.host",
+			"region": "source.region",
+			"flag_a": "flags",
+			"flag_b": "flags",
+		},
+		Fields:    map[string]string{"requests": "count"},
+		TagsMap:   "labels",
+		FieldsMap: "values",
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"cpu",
+		map[string]string{
+			"host":   "server01",
+			"region": "eu",
+			"state":  "RUNNING",
+			"flag_a": "a",
+			"flag_b": "b",
+			"cpu":    "cpu0",
+		},
+		map[string]interface{}{
+			"usage_idle": 98.5,
+			"requests":   uint64(42),
+			"usage_user": int64(1),
+		},
+		time.Unix(1700000000, 123000000),
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(serializer.msgDesc)
+	require.NoError(t, proto.Unmarshal(buf, msg))
+	actual, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+
+	expected := `{
+		"name": "cpu",
+		"time": "2023-11-14T22:13:20.123Z",
+		"source": {"host": "server01", "region": "eu"},
+		"state": "RUNNING",
+		"usageIdle": 98.5,
+		"count": "42",
+		"flags": ["a", "b"],
+		"labels": {"cpu": "cpu0"},
+		"values": {"usage_user": 1}
+	}`
+	require.JSONEq(t, expected, string(actual))
+}
+
+func TestSerializeBatchField(t *testing.T) {
+	serializer := &Serializer{
+		Files:           protoFiles,
+		MessageType:     "telegraf.test.Batch",
+		BatchField:      "metrics",
+		Measurement:     "name",
+		Timestamp:       "timestamp_ns",
+		TimestampFormat: "unix_ms",
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"state": "IDLE"}, map[string]interface{}{"usage_idle": 99.0}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"state": "2"}, map[string]interface{}{"usage_idle": 50.0}, time.Unix(2, 0)),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(serializer.msgDesc)
+	require.NoError(t, proto.Unmarshal(buf, msg))
+	actual, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+
+	expected := `{
+		"metrics": [
+			{"name": "cpu", "state": "IDLE", "usageIdle": 99, "timestampNs": "1000"},
+			{"name": "cpu", "state": "RUNNING", "usageIdle": 50, "timestampNs": "2000"}
+		]
+	}`
+	require.JSONEq(t, expected, string(actual))
+}
+
+func TestSerializeLengthDelimited(t *testing.T) {
+	serializer := &Serializer{
+		Files:           protoFiles,
+		MessageType:     "telegraf.test.Metric",
+		LengthDelimited: true,
+		Measurement:     "name",
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"usage_idle": 99.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"count": int64(7)}, time.Unix(2, 0)),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	expected := []string{
+		`{"name": "cpu", "usageIdle": 99}`,
+		`{"name": "mem", "count": "7"}`,
+	}
+	for _, e := range expected {
+		size, n := protowire.ConsumeVarint(buf)
+		require.Positive(t, n)
+		buf = buf[n:]
+		require.GreaterOrEqual(t, uint64(len(buf)), size)
+
+		msg := dynamicpb.NewMessage(serializer.msgDesc)
+		require.NoError(t, proto.Unmarshal(buf[:size], msg))
+		actual, err := protojson.Marshal(msg)
+		require.NoError(t, err)
+		require.JSONEq(t, e, string(actual))
+		buf = buf[size:]
+	}
+	require.Empty(t, buf)
+}
+
+func TestSerializeBatchUnframed(t *testing.T) {
+	serializer := &Serializer{
+		Files:       protoFiles,
+		MessageType: "telegraf.test.Metric",
+		Measurement: "name",
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"usage_idle": 99.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"count": int64(7)}, time.Unix(2, 0)),
+	}
+	_, err := serializer.SerializeBatch(metrics)
+	require.ErrorContains(t, err, "requires 'protobuf_batch_field' or 'protobuf_length_delimited'")
+
+	// A single metric does not need any framing
+	buf, err := serializer.SerializeBatch(metrics[:1])
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(serializer.msgDesc)
+	require.NoError(t, proto.Unmarshal(buf, msg))
+	actual, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "cpu", "usageIdle": 99}`, string(actual))
+}
+
+func TestSerializeInvalidValue(t *testing.T) {
+	serializer := &Serializer{
+		Files:       protoFiles,
+		MessageType: "telegraf.test.Metric",
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("cpu", map[string]string{"state": "FAULT"}, map[string]interface{}{"usage_idle": 1.0}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `invalid value FAULT for enum "telegraf.test.State"`)
+
+	m = metric.New("cpu", map[string]string{}, map[string]interface{}{"count": "many"}, time.Unix(0, 0))
+	_, err = serializer.Serialize(m)
+	require.ErrorContains(t, err, `metric "cpu" field "count"`)
+}
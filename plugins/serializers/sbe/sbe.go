@@ -0,0 +1,123 @@
+package sbe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/sbe"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Separator used to flatten composites, sets, arrays and groups
+const separator = "_"
+
+type Message struct {
+	Name            string `toml:"name"`
+	Measurement     string `toml:"measurement"`
+	Timestamp       string `toml:"timestamp"`
+	TimestampFormat string `toml:"timestamp_format"`
+
+	msg *sbe.Message
+}
+
+type Serializer struct {
+	Schema   string     `toml:"sbe_schema"`
+	Messages []*Message `toml:"sbe_message"`
+
+	encoder      *sbe.Encoder
+	measurements map[string]*Message
+}
+
+func (s *Serializer) Init() error {
+	if s.Schema == "" {
+		return errors.New("'sbe_schema' is required")
+	}
+	schema, err := sbe.LoadSchema(s.Schema)
+	if err != nil {
+		return fmt.Errorf("loading schema %q failed: %w", s.Schema, err)
+	}
+	s.encoder = &sbe.Encoder{Schema: schema, Separator: separator}
+
+	s.measurements = make(map[string]*Message, len(s.Messages))
+	for i, m := range s.Messages {
+		msg, found := schema.MessageByName(m.Name)
+		if !found {
+			return fmt.Errorf("message %d: unknown message %q", i, m.Name)
+		}
+		m.msg = msg
+
+		if m.Measurement == "" {
+			m.Measurement = m.Name
+		}
+		if _, found := s.measurements[m.Measurement]; found {
+			return fmt.Errorf("message %d: duplicate measurement %q", i, m.Measurement)
+		}
+		switch m.TimestampFormat {
+		case "":
+			m.TimestampFormat = "unix_ns"
+		case "unix", "unix_ms", "unix_us", "unix_ns":
+		default:
+			return fmt.Errorf("message %q: invalid 'timestamp_format' %q", m.Name, m.TimestampFormat)
+		}
+		s.measurements[m.Measurement] = m
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	m, found := s.measurements[metric.Name()]
+	if !found {
+		// Without configuration the metric name selects the message
+		msg, ok := s.encoder.Schema.MessageByName(metric.Name())
+		if len(s.measurements) > 0 || !ok {
+			return nil, fmt.Errorf("no message for metric %q", metric.Name())
+		}
+		m = &Message{Name: msg.Name, msg: msg}
+	}
+
+	values := make(map[string]interface{}, len(metric.TagList())+len(metric.FieldList()))
+	for _, tag := range metric.TagList() {
+		values[tag.Key] = tag.Value
+	}
+	for _, field := range metric.FieldList() {
+		values[field.Key] = field.Value
+	}
+
+	if m.Timestamp != "" {
+		ts := metric.Time()
+		switch m.TimestampFormat {
+		case "unix":
+			values[m.Timestamp] = ts.Unix()
+		case "unix_ms":
+			values[m.Timestamp] = ts.UnixMilli()
+		case "unix_us":
+			values[m.Timestamp] = ts.UnixMicro()
+		default:
+			values[m.Timestamp] = ts.UnixNano()
+		}
+	}
+
+	return s.encoder.Encode(m.msg, values)
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	serialized := make([]byte, 0)
+	for _, metric := range metrics {
+		buf, err := s.Serialize(metric)
+		if err != nil {
+			return nil, err
+		}
+		serialized = append(serialized, buf...)
+	}
+	return serialized, nil
+}
+
+func init() {
+	serializers.Add("sbe",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,172 @@
+package sbe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	parsers_sbe "github.com/influxdata/telegraf/plugins/parsers/sbe"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const schemaFile = "../../common/sbe/testdata/schema.xml"
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "missing schema",
+			serializer: &Serializer{},
+			expected:   "'sbe_schema' is required",
+		},
+		{
+			name:       "unknown message",
+			serializer: &Serializer{Schema: schemaFile, Messages: []*Message{{Name: "Unknown"}}},
+			expected:   `unknown message "Unknown"`,
+		},
+		{
+			name: "duplicate measurement",
+			serializer: &Serializer{
+				Schema: schemaFile,
+				Messages: []*Message{
+					{Name: "Sensor", Measurement: "data"},
+					{Name: "Heartbeat", Measurement: "data"},
+				},
+			},
+			expected: `duplicate measurement "data"`,
+		},
+		{
+			name: "invalid timestamp format",
+			serializer: &Serializer{
+				Schema:   schemaFile,
+				Messages: []*Message{{Name: "Sensor", TimestampFormat: "RFC3339"}},
+			},
+			expected: `invalid 'timestamp_format' "RFC3339"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	serializer := &Serializer{
+		Schema: schemaFile,
+		Messages: []*Message{
+			{Name: "Sensor", Measurement: "sensor", Timestamp: "timestamp", TimestampFormat: "unix_ms"},
+			{Name: "Heartbeat", Measurement: "heartbeat", Timestamp: "timestamp", TimestampFormat: "unix_ms"},
+		},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{
+				"host":  "node-1",
+				"state": "Fault",
+			},
+			map[string]interface{}{
+				"flags_simulated":  true,
+				"position_x":       1.5,
+				"position_y":       2.5,
+				"humidity":         int64(50),
+				"channels_0_index": uint64(3),
+				"channels_0_value": int64(7),
+				"location":         "rack 7",
+			},
+			time.Unix(1700000000, 0),
+		),
+		metric.New(
+			"heartbeat",
+			map[string]string{},
+			map[string]interface{}{"sequence": 42},
+			time.Unix(1700000001, 0),
+		),
+	}
+
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	parser := &parsers_sbe.Parser{
+		Schema: schemaFile,
+		Messages: []*parsers_sbe.Message{
+			{
+				Name:            "Sensor",
+				Measurement:     "sensor",
+				Tags:            []string{"host", "state"},
+				Timestamp:       "timestamp",
+				TimestampFormat: "unix_ms",
+			},
+			{
+				Name:            "Heartbeat",
+				Measurement:     "heartbeat",
+				Timestamp:       "timestamp",
+				TimestampFormat: "unix_ms",
+			},
+		},
+	}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{
+				"host":  "node-1",
+				"state": "Fault",
+			},
+			map[string]interface{}{
+				"flags_calibrated": false,
+				"flags_simulated":  true,
+				"position_x":       1.5,
+				"position_y":       2.5,
+				"unit":             "C",
+				"humidity":         50.0,
+				"channels_0_index": uint64(3),
+				"channels_0_value": int64(7),
+				"location":         "rack 7",
+			},
+			time.Unix(1700000000, 0),
+		),
+		metric.New(
+			"heartbeat",
+			map[string]string{},
+			map[string]interface{}{"sequence": uint64(42)},
+			time.Unix(1700000001, 0),
+		),
+	}
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestSerializeUnknownMetric(t *testing.T) {
+	serializer := &Serializer{
+		Schema:   schemaFile,
+		Messages: []*Message{{Name: "Heartbeat"}},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("Sensor", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `no message for metric "Sensor"`)
+}
+
+func TestSerializeMissingValue(t *testing.T) {
+	serializer := &Serializer{Schema: schemaFile}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("Heartbeat", map[string]string{}, map[string]interface{}{"sequence": 1}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `missing required value "timestamp"`)
+}
@@ -81,12 +81,34 @@ func Unregister(measurement, field string, tags map[string]string) {
 	registry.remove("internal_"+measurement, field, tags)
 }
 
+// UnregisterAll removes all statistics containing the given tags from the
+// registry, e.g. the statistics of a discarded plugin instance
+func UnregisterAll(tags map[string]string) {
+	registry.removeAll(tags)
+}
+
 // Metrics returns all registered stats as telegraf metrics.
 func Metrics() []telegraf.Metric {
-	registry.mu.Lock()
+	return registry.metrics(Stat.Get)
+}
+
+// Peek returns all registered stats as telegraf metrics like Metrics but
+// without clearing the timings accumulated since the last call to Metrics.
+// This allows inspecting the stats without affecting the reported averages.
+func Peek() []telegraf.Metric {
+	return registry.metrics(func(s Stat) int64 {
+		if t, ok := s.(*timingStat); ok {
+			return t.peek()
+		}
+		return s.Get()
+	})
+}
+
+func (r *Registry) metrics(get func(Stat) int64) []telegraf.Metric {
+	r.mu.Lock()
 	now := time.Now()
-	metrics := make([]telegraf.Metric, 0, len(registry.stats))
-	for _, stats := range registry.stats {
+	metrics := make([]telegraf.Metric, 0, len(r.stats))
+	for _, stats := range r.stats {
 		if len(stats) > 0 {
 			var tags map[string]string
 			var name string
@@ -97,14 +119,14 @@ func Metrics() []telegraf.Metric {
 					tags = stat.Tags()
 					name = stat.Name()
 				}
-				fields[fieldname] = stat.Get()
+				fields[fieldname] = get(stat)
 				j++
 			}
 			m := metric.New(name, tags, fields, now)
 			metrics = append(metrics, m)
 		}
 	}
-	registry.mu.Unlock()
+	r.mu.Unlock()
 	return metrics
 }
 
@@ -173,6 +195,31 @@ func (r *Registry) remove(measurement, field string, tags map[string]string) {
 	}
 }
 
+func (r *Registry) removeAll(tags map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, stats := range r.stats {
+		for field, stat := range stats {
+			if containsTags(stat.Tags(), tags) {
+				delete(stats, field)
+			}
+		}
+		if len(stats) == 0 {
+			delete(r.stats, key)
+		}
+	}
+}
+
+func containsTags(tags, subset map[string]string) bool {
+	for k, v := range subset {
+		if tv, found := tags[k]; !found || tv != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Registry) get(key uint64, field string) (Stat, bool) {
 	if _, ok := r.stats[key]; !ok {
 		return nil, false
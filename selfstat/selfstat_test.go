@@ -95,6 +95,23 @@ func TestRegisterTimingAndIncrAndSet(t *testing.T) {
 	require.Equal(t, "internal_test", foo.Name())
 }
 
+func TestPeekKeepsTimings(t *testing.T) {
+	defer testCleanup()
+	s := RegisterTiming("test", "test_field_ns", map[string]string{"test": "foo"})
+	s.Incr(10)
+	s.Incr(20)
+
+	peeked := Peek()
+	require.Len(t, peeked, 1)
+	require.Equal(t, map[string]interface{}{"test_field_ns": int64(15)}, peeked[0].Fields())
+
+	// The timings are still averaged on the next regular collection
+	s.Incr(30)
+	metrics := Metrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{"test_field_ns": int64(20)}, metrics[0].Fields())
+}
+
 func TestStatKeyConsistency(t *testing.T) {
 	lhs := key("internal_stats", map[string]string{
 		"foo":   "bar",
@@ -197,3 +214,18 @@ func TestRegisterCopy(t *testing.T) {
 	tags["new"] = "value"
 	require.NotEqual(t, tags, stat.Tags())
 }
+
+func TestUnregisterAll(t *testing.T) {
+	testCleanup()
+	defer testCleanup()
+	Register("test", "field1", map[string]string{"_id": "a", "output": "file"})
+	RegisterTiming("test", "field2", map[string]string{"_id": "a", "output": "file"})
+	Register("test", "field3", map[string]string{"_id": "a", "output": "file", "tag": "extra"})
+	Register("test", "field1", map[string]string{"_id": "b", "output": "file"})
+
+	UnregisterAll(map[string]string{"_id": "a", "output": "file"})
+
+	metrics := Metrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]string{"_id": "b", "output": "file"}, metrics[0].Tags())
+}